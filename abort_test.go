@@ -0,0 +1,158 @@
+package feather
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// unauthorized is a middleware aborting every request with a 401.
+func unauthorized(c *Context) {
+	c.String(http.StatusUnauthorized, "unauthorized")
+	c.Abort()
+}
+
+func TestAbortPreventsHandlerExecution(t *testing.T) {
+	server := NewServer()
+	server.AddMiddleware(unauthorized)
+
+	calls := 0
+	server.GET("/secret", func(c *Context) {
+		calls++
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/secret", nil))
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("got %d, want 401", recorder.Code)
+	}
+	if calls != 0 {
+		t.Errorf("the handler ran %d times after the abort", calls)
+	}
+}
+
+func TestAbortSkipsFollowingMiddlewares(t *testing.T) {
+	server := NewServer()
+
+	var order []string
+	server.AddMiddleware(func(c *Context) { order = append(order, "server") })
+	server.AddMiddleware(unauthorized)
+	server.GET("/secret", func(c *Context) {
+		order = append(order, "handler")
+	}).Use(func(c *Context) { order = append(order, "route") })
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/secret", nil))
+
+	if len(order) != 1 || order[0] != "server" {
+		t.Errorf("ran %v, want only the middleware before the abort", order)
+	}
+}
+
+func TestAbortAllowsPostFuncs(t *testing.T) {
+	server := NewServer()
+
+	var status int
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) {
+			status = c.Writer.(*httptest.ResponseRecorder).Code
+		})
+	})
+	server.AddMiddleware(unauthorized)
+	server.GET("/secret", func(c *Context) {})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/secret", nil))
+
+	if status != http.StatusUnauthorized {
+		t.Errorf("the post function saw %d, want it to run after the abort with the 401", status)
+	}
+}
+
+func TestDataKeysDoNotControlTheRequest(t *testing.T) {
+	server := NewServer()
+
+	var order []string
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) { order = append(order, "post") })
+
+		// "Abort" and "PostFunc" used to hold the state of the request in Data, they are plain keys now
+		c.Set("Abort", true)
+		c.Set("PostFunc", 1)
+	})
+	server.GET("/data", func(c *Context) {
+		order = append(order, "handler")
+		c.Post(func(c *Context) { order = append(order, "handler post") })
+		c.String(http.StatusOK, "ok")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/data", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "ok" {
+		t.Errorf("got %d %q, want 200 \"ok\"", recorder.Code, recorder.Body.String())
+	}
+	if strings.Join(order, ",") != "handler,post,handler post" {
+		t.Errorf("ran %v, want the handler then both post functions", order)
+	}
+}
+
+func TestAbortWithError(t *testing.T) {
+	server := NewServer()
+
+	var recorded []error
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) { recorded = c.Errors })
+	})
+	server.AddMiddleware(func(c *Context) {
+		c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+	})
+
+	calls := 0
+	server.GET("/secret", func(c *Context) { calls++ })
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/secret", nil))
+
+	if recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), "forbidden") {
+		t.Errorf("got %d %q, want 403 with the message", recorder.Code, recorder.Body.String())
+	}
+	if calls != 0 {
+		t.Errorf("the handler ran %d times after the abort", calls)
+	}
+	if len(recorded) != 1 || recorded[0].Error() != "forbidden" {
+		t.Errorf("the post function saw the errors %v", recorded)
+	}
+}
+
+func TestAbortWithErrorJSON(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/secret", nil)
+	c.Request.Header.Set("Accept", "application/json")
+
+	c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+
+	if recorder.Code != http.StatusForbidden || strings.TrimSpace(recorder.Body.String()) != `{"error":"forbidden"}` {
+		t.Errorf("got %d %q, want a JSON error", recorder.Code, recorder.Body.String())
+	}
+	if !c.IsAborted() {
+		t.Error("the Context is not aborted")
+	}
+}
+
+func TestAbortWithErrorUsesErrorHandler(t *testing.T) {
+	server := NewServer()
+	server.SetErrorHandler(func(c *Context, status int, message string) {
+		c.String(status, "custom: "+message)
+	})
+	server.GET("/secret", func(c *Context) {
+		c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/secret", nil))
+
+	if recorder.Code != http.StatusForbidden || recorder.Body.String() != "custom: forbidden" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
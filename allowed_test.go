@@ -0,0 +1,85 @@
+package feather
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// newMixedServer returns a server with a mixed table of static and dynamic routes.
+func newMixedServer() *Server {
+	server := NewServer()
+	handler := func(c *Context) {}
+
+	server.GET("/users", handler)
+	server.POST("/users", handler)
+	server.GET("/users/:id", handler)
+	server.PUT("/users/:id", handler)
+	server.DELETE("/users/:id", handler)
+	server.GET("/users/me", handler)
+	server.PATCH("/users/me", handler)
+	server.HostPattern(":tenant.app.com").POST("/users/me", handler)
+
+	return server
+}
+
+func TestAllowedMethods(t *testing.T) {
+	server := newMixedServer()
+
+	tests := []struct {
+		host string
+		path string
+		want []string
+	}{
+		{"example.com", "/users", []string{"GET", "OPTIONS", "POST"}},
+		{"example.com", "/users/42", []string{"DELETE", "GET", "OPTIONS", "PUT"}},
+		{"example.com", "/users/me", []string{"DELETE", "GET", "OPTIONS", "PATCH", "PUT"}},
+		{"acme.app.com", "/users/me", []string{"DELETE", "GET", "OPTIONS", "PATCH", "POST", "PUT"}},
+		{"example.com", "/unknown", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := server.AllowedMethods(test.host, test.path); !slices.Equal(got, test.want) {
+			t.Errorf("AllowedMethods(%q, %q) = %v, want %v", test.host, test.path, got, test.want)
+		}
+	}
+}
+
+func TestAllowedMethodsWithOptionsRoute(t *testing.T) {
+	server := NewServer()
+	server.GET("/items", func(c *Context) {})
+	server.Handle("/items", func(c *Context) {}, "OPTIONS")
+
+	want := []string{"GET", "OPTIONS"}
+	if got := server.AllowedMethods("example.com", "/items"); !slices.Equal(got, want) {
+		t.Errorf("AllowedMethods = %v, want %v", got, want)
+	}
+}
+
+func TestMethodNotAllowedHeader(t *testing.T) {
+	server := newMixedServer()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("POST", "/users/42", nil))
+
+	if recorder.Code != 405 {
+		t.Errorf("got %d, want 405", recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "DELETE, GET, OPTIONS, PUT" {
+		t.Errorf("Allow = %q", allow)
+	}
+}
+
+func TestAutomaticOptions(t *testing.T) {
+	server := newMixedServer()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("OPTIONS", "/users", nil))
+
+	if recorder.Code != 204 {
+		t.Errorf("got %d, want 204", recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "GET, OPTIONS, POST" {
+		t.Errorf("Allow = %q", allow)
+	}
+}
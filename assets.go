@@ -0,0 +1,84 @@
+package feather
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// assetManifest maps the names of the static assets to their fingerprinted names, see AssetPath.
+var assetManifest = struct {
+	sync.RWMutex
+	entries map[string]string
+}{entries: make(map[string]string)}
+
+/*
+	SetAssetManifest replaces the manifest used by AssetPath.
+
+	The manifest is usually produced by the build step fingerprinting the assets, and maps each asset to its
+	fingerprinted name, e.g. {"/static/app.js": "/static/app.3f2a9c1e.js"}.
+
+	Parameters:
+		- manifest (map[string]string): The map of the asset names to their fingerprinted names.
+
+	Returns:
+		- This function does not return any value.
+*/
+func SetAssetManifest(manifest map[string]string) {
+	entries := make(map[string]string, len(manifest))
+	for name, fingerprinted := range manifest {
+		entries[name] = fingerprinted
+	}
+
+	assetManifest.Lock()
+	defer assetManifest.Unlock()
+
+	assetManifest.entries = entries
+}
+
+/*
+	LoadAssetManifest reads a JSON manifest (a single object mapping the asset names to their fingerprinted names)
+	and makes it the manifest used by AssetPath.
+
+	Parameters:
+		- path (string): The path of the manifest file (e.g. "static/manifest.json").
+
+	Returns:
+		- error: An error if the file cannot be read or isn't a valid manifest.
+*/
+func LoadAssetManifest(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	manifest := make(map[string]string)
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return err
+	}
+
+	SetAssetManifest(manifest)
+	return nil
+}
+
+/*
+	AssetPath returns the fingerprinted name of an asset, as found in the manifest set with SetAssetManifest or
+	LoadAssetManifest. It is meant to be used as a template function, so that pages always reference the current
+	version of the assets served with long-lived cache headers.
+
+	Parameters:
+		- name (string): The name of the asset, as written in the manifest (e.g. "/static/app.js").
+
+	Returns:
+		- string: The fingerprinted name of the asset, or name itself if the manifest has no entry for it.
+*/
+func AssetPath(name string) string {
+	assetManifest.RLock()
+	defer assetManifest.RUnlock()
+
+	if fingerprinted, ok := assetManifest.entries[name]; ok {
+		return fingerprinted
+	}
+
+	return name
+}
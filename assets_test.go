@@ -0,0 +1,88 @@
+package feather
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useAssetManifest makes manifest the asset manifest for the duration of the test.
+func useAssetManifest(t *testing.T, manifest map[string]string) {
+	t.Helper()
+
+	SetAssetManifest(manifest)
+	t.Cleanup(func() { SetAssetManifest(nil) })
+}
+
+func TestAssetPath(t *testing.T) {
+	manifest := map[string]string{"/static/app.js": "/static/app.3f2a9c1e.js"}
+	useAssetManifest(t, manifest)
+
+	// The manifest is copied, later changes of the map are not seen
+	manifest["/static/app.css"] = "/static/app.0b1c2d3e.css"
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/static/app.js", "/static/app.3f2a9c1e.js"},
+		{"/static/app.css", "/static/app.css"},
+		{"/static/missing.png", "/static/missing.png"},
+	}
+
+	for _, test := range tests {
+		if got := AssetPath(test.name); got != test.want {
+			t.Errorf("AssetPath(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLoadAssetManifest(t *testing.T) {
+	t.Cleanup(func() { SetAssetManifest(nil) })
+	dir := t.TempDir()
+
+	manifest := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(manifest, []byte(`{"/static/app.js": "/static/app.3f2a9c1e.js"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadAssetManifest(manifest); err != nil {
+		t.Fatalf("LoadAssetManifest: %v", err)
+	}
+	if got := AssetPath("/static/app.js"); got != "/static/app.3f2a9c1e.js" {
+		t.Errorf("AssetPath = %q", got)
+	}
+
+	// An invalid manifest is reported and keeps the current one
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`["/static/app.js"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadAssetManifest(invalid); err == nil {
+		t.Error("no error for an invalid manifest")
+	}
+	if err := LoadAssetManifest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("no error for a missing manifest")
+	}
+	if got := AssetPath("/static/app.js"); got != "/static/app.3f2a9c1e.js" {
+		t.Errorf("AssetPath = %q after a failed load", got)
+	}
+}
+
+func TestTemplateAssetPath(t *testing.T) {
+	useAssetManifest(t, map[string]string{"/static/app.js": "/static/app.3f2a9c1e.js"})
+
+	page := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(page, []byte(`<script src="{{ assetPath "/static/app.js" }}"></script>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	server.GET("/", func(c *Context) {
+		c.Template([]string{page}, nil, nil)
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, nil)
+	if body := recorder.Body.String(); body != `<script src="/static/app.3f2a9c1e.js"></script>` {
+		t.Errorf("body = %q", body)
+	}
+}
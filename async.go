@@ -0,0 +1,182 @@
+package feather
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// ErrQueueFull is the error of the requests rejected by Async because the queue of the WorkerPool is full.
+var ErrQueueFull = errors.New("feather: job queue is full")
+
+// ErrPoolClosed is the error of the requests rejected by Async because the WorkerPool has been closed.
+var ErrPoolClosed = errors.New("feather: worker pool is closed")
+
+/*
+	Job is a unit of work enqueued by Async and processed by a WorkerPool.
+*/
+type Job struct {
+	ID      string   // ID is the identifier of the job, sent to the client in the 202 response.
+	Payload any      // Payload is the value returned by the enqueue function of Async.
+	Context *Context // Context is a detached copy of the Context of the request which enqueued the job, see Context.Detach.
+}
+
+/*
+	WorkerPool processes the jobs enqueued by Async with a fixed number of goroutines.
+*/
+type WorkerPool struct {
+	process func(job *Job) // process is the function processing each job.
+	jobs    chan *Job      // jobs is the queue of the jobs waiting for a worker.
+	workers sync.WaitGroup // workers tracks the running workers, so that Close can wait for them.
+
+	mutex  sync.RWMutex // mutex protects closed, so that no job is enqueued once the queue is closed.
+	closed bool         // closed is set by Close.
+}
+
+/*
+	NewWorkerPool creates a WorkerPool and starts its workers.
+
+	The pool must be closed when the server stops, so that the queued jobs are processed before the process exits:
+
+		pool := feather.NewWorkerPool(4, 100, processImage)
+		server.OnShutdown(pool.Close)
+
+	Parameters:
+		- size (int): The number of workers, i.e. of jobs processed concurrently. At least one worker is started.
+		- queueDepth (int): The maximum number of jobs waiting for a worker. Async answers 503 once it is reached.
+		- process (func(job *Job)): The function processing each job. A panic is recovered and logged.
+
+	Returns:
+		- *WorkerPool: The started pool.
+*/
+func NewWorkerPool(size int, queueDepth int, process func(job *Job)) *WorkerPool {
+	pool := &WorkerPool{
+		process: process,
+		jobs:    make(chan *Job, max(queueDepth, 0)),
+	}
+
+	for range max(size, 1) {
+		pool.workers.Add(1)
+		go pool.work()
+	}
+
+	return pool
+}
+
+/*
+	Submit enqueues a job without blocking.
+
+	Parameters:
+		- job (*Job): The job to process.
+
+	Returns:
+		- error: ErrQueueFull if the queue is full, ErrPoolClosed if the pool has been closed, nil otherwise.
+*/
+func (pool *WorkerPool) Submit(job *Job) error {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	if pool.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case pool.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+/*
+	Close stops accepting jobs and waits for the workers to process the queued ones.
+	It is meant to be registered with Server.OnShutdown, and can be called several times.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (pool *WorkerPool) Close() {
+	pool.mutex.Lock()
+	if !pool.closed {
+		pool.closed = true
+		close(pool.jobs)
+	}
+	pool.mutex.Unlock()
+
+	pool.workers.Wait()
+}
+
+// work processes the jobs of the queue until it is closed.
+func (pool *WorkerPool) work() {
+	defer pool.workers.Done()
+
+	for job := range pool.jobs {
+		pool.run(job)
+	}
+}
+
+// run processes a single job, recovering and logging its panic.
+func (pool *WorkerPool) run(job *Job) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("feather: job %s panicked: %v", job.ID, recovered)
+		}
+	}()
+
+	pool.process(job)
+}
+
+/*
+	Async creates a handler which enqueues the work of the request in a WorkerPool and answers immediately,
+	for the endpoints starting heavy work (image processing, email sending, ...).
+
+	The enqueue function validates the request and returns the payload of the job. On success, the job is
+	submitted to the pool with a detached copy of the Context (see Context.Detach), and the handler answers
+	202 Accepted with the JSON body {"job_id": "..."}. When enqueue returns an error, the request is aborted
+	with a 400 status (see Context.AbortWithError), unless enqueue has aborted it after sending its own response.
+	When the queue is full or the pool is closed, the request is aborted with a 503 status and a
+	"Retry-After" header.
+
+	Parameters:
+		- pool (*WorkerPool): The pool processing the jobs.
+		- enqueue (func(c *Context) (any, error)): The function validating the request and returning the payload of the job.
+
+	Returns:
+		- HandlerFunc: The handler of the route.
+*/
+func Async(pool *WorkerPool, enqueue func(c *Context) (job any, err error)) HandlerFunc {
+	return func(c *Context) {
+		payload, err := enqueue(c)
+		if err != nil {
+			if !c.IsAborted() {
+				c.AbortWithError(http.StatusBadRequest, err)
+			}
+			return
+		}
+
+		job := &Job{
+			ID:      newJobID(),
+			Payload: payload,
+			Context: c.Detach(),
+		}
+
+		if err := pool.Submit(job); err != nil {
+			c.SetHeader("Retry-After", "1")
+			c.AbortWithError(http.StatusServiceUnavailable, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, map[string]string{"job_id": job.ID})
+	}
+}
+
+// newJobID generates a random 32-character hexadecimal job ID.
+func newJobID() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,218 @@
+package feather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsyncCompletesAfterResponse(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan *Job, 1)
+
+	pool := NewWorkerPool(1, 10, func(job *Job) {
+		<-release
+		done <- job
+	})
+	defer pool.Close()
+
+	server := NewServer()
+	server.POST("/images/:id", Async(pool, func(c *Context) (any, error) {
+		c.Set("user", "alice")
+		return "resize " + c.Params["id"], nil
+	}))
+
+	recorder := server.TestRequest("POST", "/images/42", nil, nil)
+
+	// The response is sent while the job is still waiting
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", recorder.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || len(body["job_id"]) != 32 {
+		t.Fatalf("body = %s", recorder.Body.String())
+	}
+
+	close(release)
+
+	var job *Job
+	select {
+	case job = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the job has not been processed")
+	}
+
+	if job.ID != body["job_id"] || job.Payload != "resize 42" {
+		t.Errorf("job = %+v, want the ID %s", job, body["job_id"])
+	}
+
+	// The detached Context is still usable once the request has been handled
+	if job.Context.Params["id"] != "42" || job.Context.GetString("user") != "alice" {
+		t.Errorf("detached Context has the params %v and the user %q", job.Context.Params, job.Context.GetString("user"))
+	}
+	if err := job.Context.Request.Context().Err(); err != nil {
+		t.Errorf("the detached request is cancelled: %v", err)
+	}
+}
+
+func TestAsyncQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	pool := NewWorkerPool(1, 1, func(job *Job) {
+		started <- struct{}{}
+		<-release
+	})
+	defer pool.Close()
+	defer close(release)
+
+	server := NewServer()
+	server.POST("/jobs", Async(pool, func(c *Context) (any, error) { return nil, nil }))
+
+	// The first job occupies the worker, the second one fills the queue
+	if code := server.TestRequest("POST", "/jobs", nil, nil).Code; code != http.StatusAccepted {
+		t.Fatalf("first status = %d", code)
+	}
+	<-started
+	if code := server.TestRequest("POST", "/jobs", nil, nil).Code; code != http.StatusAccepted {
+		t.Fatalf("second status = %d", code)
+	}
+
+	recorder := server.TestRequest("POST", "/jobs", nil, nil)
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("got %d with Retry-After %q, want 503 with Retry-After", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestAsyncEnqueueError(t *testing.T) {
+	var processed atomic.Int32
+	pool := NewWorkerPool(1, 10, func(job *Job) { processed.Add(1) })
+
+	server := NewServer()
+	server.POST("/invalid", Async(pool, func(c *Context) (any, error) {
+		return nil, errors.New("missing image")
+	}))
+	server.POST("/custom", Async(pool, func(c *Context) (any, error) {
+		c.AbortWithError(http.StatusUnprocessableEntity, errors.New("unsupported format"))
+		return nil, errors.New("unsupported format")
+	}))
+
+	if code := server.TestRequest("POST", "/invalid", nil, nil).Code; code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", code)
+	}
+	if code := server.TestRequest("POST", "/custom", nil, nil).Code; code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want the 422 of the enqueue function", code)
+	}
+
+	pool.Close()
+	if n := processed.Load(); n != 0 {
+		t.Errorf("%d jobs processed for rejected requests", n)
+	}
+}
+
+func TestWorkerPoolClose(t *testing.T) {
+	var processed atomic.Int32
+	pool := NewWorkerPool(2, 10, func(job *Job) {
+		time.Sleep(10 * time.Millisecond)
+		processed.Add(1)
+	})
+
+	for range 6 {
+		if err := pool.Submit(&Job{}); err != nil {
+			t.Fatalf("Submit = %v", err)
+		}
+	}
+
+	// Close waits for the queued jobs, and can be called again
+	pool.Close()
+	pool.Close()
+
+	if n := processed.Load(); n != 6 {
+		t.Errorf("%d jobs processed before Close returned, want 6", n)
+	}
+	if err := pool.Submit(&Job{}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit after Close = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	var processed atomic.Int32
+	pool := NewWorkerPool(1, 10, func(job *Job) {
+		if job.ID == "bad" {
+			panic("boom")
+		}
+		processed.Add(1)
+	})
+
+	pool.Submit(&Job{ID: "bad"})
+	pool.Submit(&Job{ID: "good"})
+	pool.Close()
+
+	if n := processed.Load(); n != 1 {
+		t.Errorf("%d jobs processed after the panic, want 1", n)
+	}
+}
+
+func TestShutdownWaitsForWorkerPool(t *testing.T) {
+	addr := freeAddr(t)
+
+	var processed atomic.Bool
+	pool := NewWorkerPool(1, 10, func(job *Job) {
+		time.Sleep(200 * time.Millisecond)
+		processed.Store(true)
+	})
+
+	server := NewServer()
+	server.ShutdownTimeout = 2 * time.Second
+	server.OnShutdown(pool.Close)
+	server.POST("/jobs", Async(pool, func(c *Context) (any, error) { return nil, nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- server.ListenWithShutdown(addr, ctx) }()
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	resp, err := http.Post("http://"+addr+"/jobs", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Fatalf("ListenWithShutdown = %v", err)
+	}
+	if !processed.Load() {
+		t.Error("the shutdown returned before the job was processed")
+	}
+}
+
+func TestAsyncPoolClosed(t *testing.T) {
+	pool := NewWorkerPool(1, 0, func(job *Job) {})
+	pool.Close()
+
+	server := NewServer()
+	server.POST("/jobs", Async(pool, func(c *Context) (any, error) { return nil, nil }))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("POST", "/jobs", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d for a closed pool, want 503", recorder.Code)
+	}
+}
@@ -0,0 +1,79 @@
+// Package autotls serves a Feather server over HTTPS with certificates obtained and renewed automatically
+// from Let's Encrypt, using golang.org/x/crypto/acme/autocert. It is a separate module, so that the
+// applications which do not use it keep a Feather without dependencies.
+package autotls
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/esmyxvatu/feather"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+/*
+	Listen serves the server over HTTPS on ":443" for domain, with a certificate obtained from Let's Encrypt on
+	the first request and renewed before it expires, and redirects the plain HTTP requests of ":80" to HTTPS:
+
+		log.Fatal(autotls.Listen(server, "example.com", "/var/lib/myapp/certs"))
+
+	Both ports must be reachable from the Internet for the ACME challenges, and the process needs the right
+	to bind them. The certificates are only requested for domain: the TLS handshakes for other host names are refused,
+	so that a client cannot make the server request certificates for arbitrary names.
+
+	Parameters:
+		- server (*feather.Server): The server to serve.
+		- domain (string): The domain name of the certificate (e.g. "example.com").
+		- cacheDir (string): The directory storing the certificates and the ACME account key (autocert.DirCache),
+				so that they survive the restarts instead of being requested again. It is created if needed,
+				and must not be readable by other users.
+
+	Returns:
+		- error: The error of the first of the two servers which fails to start or stops. Otherwise, it blocks
+				indefinitely and does not return.
+*/
+func Listen(server *feather.Server, domain string, cacheDir string) error {
+	httpListener, err := net.Listen("tcp", ":80")
+	if err != nil {
+		return err
+	}
+	httpsListener, err := net.Listen("tcp", ":443")
+	if err != nil {
+		httpListener.Close()
+		return err
+	}
+
+	return serve(server, newManager(domain, cacheDir), httpListener, httpsListener)
+}
+
+// newManager returns the certificate manager of Listen, accepting the terms of service of Let's Encrypt.
+func newManager(domain string, cacheDir string) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domain),
+		Cache:      autocert.DirCache(cacheDir),
+	}
+}
+
+// serve serves handler over HTTPS on httpsListener with the certificates of manager, and answers the ACME challenges
+// and redirects the other requests to HTTPS on httpListener. Both servers are closed once one of them stops.
+func serve(handler http.Handler, manager *autocert.Manager, httpListener net.Listener, httpsListener net.Listener) error {
+	httpsServer := &http.Server{
+		Handler:   handler,
+		TLSConfig: manager.TLSConfig(),
+	}
+	// The fallback handler redirects the GET and HEAD requests to HTTPS, and rejects the others with a 400
+	httpServer := &http.Server{
+		Handler: manager.HTTPHandler(nil),
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- httpServer.Serve(httpListener) }()
+	go func() { errs <- httpsServer.ServeTLS(httpsListener, "", "") }()
+
+	err := <-errs
+	httpServer.Close()
+	httpsServer.Close()
+
+	return err
+}
@@ -0,0 +1,145 @@
+package autotls
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// startServe runs serve on free local ports with the manager of Listen for example.com, and returns the addresses of
+// the HTTP and HTTPS listeners. No certificate is ever requested: the tests never complete a handshake for example.com.
+func startServe(t *testing.T) (string, string) {
+	t.Helper()
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := feather.NewServer()
+	server.GET("/", func(c *feather.Context) {
+		c.String(http.StatusOK, "secure")
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- serve(server, newManager("example.com", t.TempDir()), httpListener, httpsListener)
+	}()
+	t.Cleanup(func() {
+		httpListener.Close()
+		<-result
+	})
+
+	return httpListener.Addr().String(), httpsListener.Addr().String()
+}
+
+// send sends a request to the HTTP listener for the host example.com, without following the redirects.
+func send(t *testing.T, method string, addr string, path string) *http.Response {
+	t.Helper()
+
+	request, err := http.NewRequest(method, "http://"+addr+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Host = "example.com"
+
+	client := &http.Client{
+		Timeout:       5 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	return response
+}
+
+func TestRedirectsToHTTPS(t *testing.T) {
+	httpAddr, _ := startServe(t)
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{"GET", "/", http.StatusFound, "https://example.com/"},
+		{"GET", "/users?page=2", http.StatusFound, "https://example.com/users?page=2"},
+		{"HEAD", "/users", http.StatusFound, "https://example.com/users"},
+		{"POST", "/users", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		response := send(t, test.method, httpAddr, test.path)
+
+		if response.StatusCode != test.status || response.Header.Get("Location") != test.location {
+			t.Errorf("%s %s = %d to %q, want %d to %q", test.method, test.path, response.StatusCode, response.Header.Get("Location"), test.status, test.location)
+		}
+	}
+}
+
+func TestUnknownChallenge(t *testing.T) {
+	httpAddr, _ := startServe(t)
+
+	// The challenges are answered from the tokens of the pending orders, there are none
+	if response := send(t, "GET", httpAddr, "/.well-known/acme-challenge/token"); response.StatusCode != http.StatusNotFound {
+		t.Errorf("challenge = %d, want 404", response.StatusCode)
+	}
+}
+
+func TestRejectsOtherHosts(t *testing.T) {
+	_, httpsAddr := startServe(t)
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", httpsAddr, &tls.Config{ServerName: "attacker.example.net", InsecureSkipVerify: true})
+	if err == nil {
+		conn.Close()
+		t.Fatal("the handshake for another host succeeded")
+	}
+	if !strings.Contains(err.Error(), "tls") {
+		t.Errorf("err = %v, want a TLS handshake error", err)
+	}
+}
+
+func TestServeStopsBothServers(t *testing.T) {
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- serve(feather.NewServer(), newManager("example.com", t.TempDir()), httpListener, httpsListener)
+	}()
+
+	// Once the HTTP server stops, serve returns its error and closes the HTTPS server
+	httpListener.Close()
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Error("serve = nil, want the error of the stopped server")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the HTTP listener was closed")
+	}
+
+	if conn, err := net.DialTimeout("tcp", httpsListener.Addr().String(), time.Second); err == nil {
+		conn.Close()
+		t.Error("the HTTPS listener is still open after serve returned")
+	}
+}
@@ -0,0 +1,63 @@
+package feather
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// The benchmarks below only go through ServeHTTP, so that they compare the router implementations (e.g. the
+// current regular expressions and a radix tree) without change.
+//
+// Reference numbers (go test -bench=ServeHTTP -benchmem, Intel Xeon, go1.27):
+//
+//	BenchmarkServeHTTP_StaticRoute          677 ns/op   360 B/op   5 allocs/op
+//	BenchmarkServeHTTP_DynamicRoute        1629 ns/op   744 B/op   7 allocs/op
+//	BenchmarkServeHTTP_100Routes          10332 ns/op   664 B/op   6 allocs/op
+//	BenchmarkServeHTTP_MiddlewareChain10   1367 ns/op   664 B/op   6 allocs/op
+
+// benchmarkServe serves requests for path on server.
+func benchmarkServe(b *testing.B, server *Server, method string, path string) {
+	request := httptest.NewRequest(method, path, nil)
+	recorder := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		server.ServeHTTP(recorder, request)
+	}
+}
+
+func BenchmarkServeHTTP_StaticRoute(b *testing.B) {
+	server := NewServer()
+	server.GET("/health", func(c *Context) {})
+
+	benchmarkServe(b, server, "GET", "/health")
+}
+
+func BenchmarkServeHTTP_DynamicRoute(b *testing.B) {
+	server := NewServer()
+	server.GET("/users/:id/posts/:post", func(c *Context) {})
+
+	benchmarkServe(b, server, "GET", "/users/42/posts/7")
+}
+
+func BenchmarkServeHTTP_100Routes(b *testing.B) {
+	server := NewServer()
+	for i := range 50 {
+		server.GET(fmt.Sprintf("/resource%d", i), func(c *Context) {})
+		server.GET(fmt.Sprintf("/resource%d/:id", i), func(c *Context) {})
+	}
+
+	// The last dynamic route, matched after every other route
+	benchmarkServe(b, server, "GET", "/resource49/42")
+}
+
+func BenchmarkServeHTTP_MiddlewareChain10(b *testing.B) {
+	server := NewServer()
+	for range 10 {
+		server.AddMiddleware(func(c *Context) {})
+	}
+	server.GET("/users/:id", func(c *Context) {})
+
+	benchmarkServe(b, server, "GET", "/users/42")
+}
@@ -0,0 +1,229 @@
+package feather
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ErrBindTarget is returned by the binding helpers when their argument is not a non-nil pointer to a struct.
+var ErrBindTarget = errors.New("feather: bind target must be a non-nil pointer to a struct")
+
+// BindError is the error returned by the binding helpers when a value cannot be converted to the type of its field.
+type BindError struct {
+	Source string // Source is the origin of the value: "param", "query" or "body".
+	Field  string // Field is the name of the struct field, or the JSON path of the value for the body.
+	Err    error  // Err is the conversion error.
+}
+
+// Error returns a message naming the source and the field of the value.
+func (e *BindError) Error() string {
+	return fmt.Sprintf("feather: cannot bind %s value to field %q: %v", e.Source, e.Field, e.Err)
+}
+
+// Unwrap returns the conversion error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindParams maps the path parameters of the request into the fields of v tagged `param:"name"`.
+//
+// Parameters:
+//   - v: A pointer to the struct to fill.
+//
+// Strings, booleans, integers, floats, time.Time (RFC 3339), time.Duration, the types implementing
+// encoding.TextUnmarshaler (e.g. UUID types) and pointers to them are supported.
+//
+// Returns:
+//   - A *BindError naming the parameter's field if a value cannot be converted, ErrBindTarget if
+//     v is not a pointer to a struct, nil otherwise.
+func (c *Context) BindParams(v any) error {
+	return bindValues(v, "param", func(name string) ([]string, bool) {
+		value, ok := c.Params[name]
+		return []string{value}, ok
+	})
+}
+
+// BindQuery maps the query parameters of the request into the fields of v tagged `query:"name"`.
+//
+// Parameters:
+//   - v: A pointer to the struct to fill.
+//
+// The types supported by BindParams are supported, along with slices of them, filled with every value
+// of a repeated query parameter (e.g. "?tag=a&tag=b").
+//
+// Returns:
+//   - A *BindError naming the parameter's field if a value cannot be converted, ErrBindTarget if
+//     v is not a pointer to a struct, nil otherwise.
+func (c *Context) BindQuery(v any) error {
+	query := c.Request.URL.Query()
+
+	return bindValues(v, "query", func(name string) ([]string, bool) {
+		values, ok := query[name]
+		return values, ok
+	})
+}
+
+// BindAll fills v from the JSON body, the query parameters and the path parameters of the request,
+// so that a handler can declare a single input struct combining `json`, `query` and `param` tags.
+//
+// Parameters:
+//   - v: A pointer to the struct to fill.
+//
+// The sources are applied in the order body, query, then path parameters: when a field is tagged for
+// several sources, the path parameter takes precedence over the query parameter, which takes precedence
+// over the body. An empty body is ignored.
+//
+// Returns:
+//   - A *BindError naming the source and the field of the value which cannot be converted,
+//     ErrBindTarget if v is not a pointer to a struct, nil otherwise.
+func (c *Context) BindAll(v any) error {
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return &BindError{Source: "body", Err: c.bodyError(err)}
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, v); err != nil {
+				var typeErr *json.UnmarshalTypeError
+				if errors.As(err, &typeErr) {
+					return &BindError{Source: "body", Field: typeErr.Field, Err: err}
+				}
+				return &BindError{Source: "body", Err: err}
+			}
+		}
+	}
+
+	if err := c.BindQuery(v); err != nil {
+		return err
+	}
+
+	return c.BindParams(v)
+}
+
+// bindValues sets the fields of v tagged with tag to the values returned by lookup.
+func bindValues(v any, tag string, lookup func(name string) ([]string, bool)) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return ErrBindTarget
+	}
+
+	return bindStruct(target.Elem(), tag, lookup)
+}
+
+// bindStruct sets the fields of the struct value, including the fields of its embedded structs.
+func bindStruct(value reflect.Value, tag string, lookup func(name string) ([]string, bool)) error {
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup(tag)
+		if !ok || name == "-" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindStruct(value.Field(i), tag, lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		values, ok := lookup(name)
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		if err := setField(value.Field(i), values); err != nil {
+			return &BindError{Source: tag, Field: field.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// setField converts values to the type of field and sets it. Only slices use more than the first value.
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !hasConverter(field.Type()) && !implementsTextUnmarshaler(field) && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, raw := range values {
+			if err := setValue(slice.Index(i), raw); err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+		return nil
+	}
+
+	return setValue(field, values[0])
+}
+
+// implementsTextUnmarshaler reports whether a pointer to field implements encoding.TextUnmarshaler.
+func implementsTextUnmarshaler(field reflect.Value) bool {
+	return field.CanAddr() && field.Addr().Type().Implements(reflect.TypeFor[encoding.TextUnmarshaler]())
+}
+
+// setValue converts raw to the type of field and sets it.
+func setValue(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		pointer := reflect.New(field.Type().Elem())
+		if err := setValue(pointer.Elem(), raw); err != nil {
+			return err
+		}
+
+		field.Set(pointer)
+		return nil
+	}
+
+	if converted, err := convert(field, raw); converted {
+		return err
+	}
+
+	if implementsTextUnmarshaler(field) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package feather
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testUUID is a minimal UUID type decoded through encoding.TextUnmarshaler.
+type testUUID [16]byte
+
+func (id *testUUID) UnmarshalText(text []byte) error {
+	raw := strings.ReplaceAll(string(text), "-", "")
+	if len(raw) != 32 {
+		return fmt.Errorf("invalid UUID %q", text)
+	}
+
+	_, err := hex.Decode(id[:], []byte(raw))
+	return err
+}
+
+// bindContext returns a Context for a request to url with the path parameters params.
+func bindContext(method string, url string, body string, params map[string]string) *Context {
+	request := httptest.NewRequest(method, url, strings.NewReader(body))
+	return &Context{Writer: httptest.NewRecorder(), Request: request, Params: params}
+}
+
+func TestBindParams(t *testing.T) {
+	var in struct {
+		ID      int       `param:"id"`
+		Account testUUID  `param:"account"`
+		Active  bool      `param:"active"`
+		Since   time.Time `param:"since"`
+		Ratio   *float64  `param:"ratio"`
+		Missing string    `param:"missing"`
+		Ignored string
+	}
+
+	c := bindContext("GET", "/", "", map[string]string{
+		"id":      "42",
+		"account": "123e4567-e89b-12d3-a456-426614174000",
+		"active":  "true",
+		"since":   "2024-03-01T12:00:00Z",
+		"ratio":   "0.5",
+	})
+	if err := c.BindParams(&in); err != nil {
+		t.Fatalf("BindParams: %v", err)
+	}
+
+	if in.ID != 42 || !in.Active || in.Ratio == nil || *in.Ratio != 0.5 || in.Missing != "" {
+		t.Errorf("bound %+v", in)
+	}
+	if hex.EncodeToString(in.Account[:]) != "123e4567e89b12d3a456426614174000" {
+		t.Errorf("account = %x", in.Account)
+	}
+	if !in.Since.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("since = %v", in.Since)
+	}
+}
+
+func TestBindParamsTarget(t *testing.T) {
+	c := bindContext("GET", "/", "", map[string]string{"id": "1"})
+
+	var notStruct int
+	var nilStruct *struct{}
+	for _, target := range []any{struct{}{}, &notStruct, nilStruct, nil} {
+		if err := c.BindParams(target); !errors.Is(err, ErrBindTarget) {
+			t.Errorf("BindParams(%T) = %v, want ErrBindTarget", target, err)
+		}
+	}
+}
+
+// updateInput combines the three sources bound by BindAll.
+type updateInput struct {
+	ID     int      `param:"id" query:"id" json:"id"`
+	Format string   `query:"format" json:"format"`
+	Name   string   `json:"name"`
+	Tags   []string `query:"tag" json:"tags"`
+	Draft  bool     `param:"draft" json:"draft"`
+}
+
+func TestBindAllPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		body   string
+		params map[string]string
+		want   updateInput
+	}{
+		{
+			name:   "param over query over body",
+			url:    "/?id=2&format=csv",
+			body:   `{"id": 3, "format": "json", "name": "report"}`,
+			params: map[string]string{"id": "1"},
+			want:   updateInput{ID: 1, Format: "csv", Name: "report"},
+		},
+		{
+			name:   "query over body",
+			url:    "/?id=2&tag=a&tag=b",
+			body:   `{"id": 3, "tags": ["c"]}`,
+			params: map[string]string{},
+			want:   updateInput{ID: 2, Tags: []string{"a", "b"}},
+		},
+		{
+			name:   "body only",
+			url:    "/",
+			body:   `{"id": 3, "format": "json", "tags": ["c"], "draft": true}`,
+			params: map[string]string{},
+			want:   updateInput{ID: 3, Format: "json", Tags: []string{"c"}, Draft: true},
+		},
+		{
+			name:   "empty body",
+			url:    "/?format=xml",
+			body:   "",
+			params: map[string]string{"id": "7", "draft": "false"},
+			want:   updateInput{ID: 7, Format: "xml"},
+		},
+	}
+
+	for _, test := range tests {
+		var in updateInput
+		c := bindContext("PUT", test.url, test.body, test.params)
+
+		if err := c.BindAll(&in); err != nil {
+			t.Errorf("%s: BindAll: %v", test.name, err)
+			continue
+		}
+		if fmt.Sprint(in) != fmt.Sprint(test.want) {
+			t.Errorf("%s: bound %+v, want %+v", test.name, in, test.want)
+		}
+	}
+}
+
+func TestBindAllKeepsBody(t *testing.T) {
+	var in updateInput
+	c := bindContext("PUT", "/", `{"name": "report"}`, map[string]string{})
+
+	if err := c.BindAll(&in); err != nil {
+		t.Fatalf("BindAll: %v", err)
+	}
+
+	var again struct{ Name string }
+	if err := c.JSONBody(&again); err != nil || again.Name != "report" {
+		t.Errorf("the body cannot be read again: %v, %+v", err, again)
+	}
+}
+
+func TestBindAllErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		body   string
+		params map[string]string
+		source string
+		field  string
+	}{
+		{"invalid param", "/", "", map[string]string{"id": "abc"}, "param", "ID"},
+		{"invalid query", "/?id=abc", "", map[string]string{}, "query", "ID"},
+		{"invalid body value", "/", `{"id": "abc"}`, map[string]string{}, "body", "id"},
+		{"malformed body", "/", `{"id":`, map[string]string{}, "body", ""},
+		{"invalid param bool", "/", "", map[string]string{"draft": "maybe"}, "param", "Draft"},
+	}
+
+	for _, test := range tests {
+		var in updateInput
+		err := bindContext("PUT", test.url, test.body, test.params).BindAll(&in)
+
+		var bindErr *BindError
+		if !errors.As(err, &bindErr) {
+			t.Errorf("%s: err = %v, want a *BindError", test.name, err)
+			continue
+		}
+		if bindErr.Source != test.source || bindErr.Field != test.field {
+			t.Errorf("%s: source = %q, field = %q, want %q and %q", test.name, bindErr.Source, bindErr.Field, test.source, test.field)
+		}
+		if !strings.Contains(err.Error(), test.source) {
+			t.Errorf("%s: the message %q does not name the source", test.name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package feather
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pipeContext returns a test Context whose body is read from a pipe, along with its recorder, the writer of the
+// pipe and the function cancelling the request.
+func pipeContext() (*Context, *httptest.ResponseRecorder, *io.PipeWriter, context.CancelFunc) {
+	reader, writer := io.Pipe()
+	c, recorder := NewTestContext("POST", "/upload", reader)
+
+	requestContext, cancel := context.WithCancel(context.Background())
+	c.Request = c.Request.WithContext(requestContext)
+
+	return c, recorder, writer, cancel
+}
+
+func TestJSONBodyClientAborted(t *testing.T) {
+	c, recorder, writer, cancel := pipeContext()
+
+	go func() {
+		writer.Write([]byte(`{"name":`))
+		// The client disconnects in the middle of the upload
+		cancel()
+		writer.CloseWithError(io.ErrUnexpectedEOF)
+	}()
+
+	var payload map[string]any
+	err := c.JSONBody(&payload)
+	if !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("err = %v, want ErrClientAborted", err)
+	}
+
+	c.AbortWithError(http.StatusBadRequest, err)
+	if recorder.Body.Len() != 0 || !c.IsAborted() || len(c.Errors) != 1 {
+		t.Errorf("an aborted upload must only be recorded, got body %q and errors %v", recorder.Body.String(), c.Errors)
+	}
+}
+
+func TestJSONBodyTruncatedIsBadRequest(t *testing.T) {
+	c, recorder, writer, cancel := pipeContext()
+	defer cancel()
+
+	go func() {
+		writer.Write([]byte(`{"name":`))
+		writer.CloseWithError(io.ErrUnexpectedEOF)
+	}()
+
+	var payload map[string]any
+	err := c.JSONBody(&payload)
+	if err == nil || errors.Is(err, ErrClientAborted) {
+		t.Fatalf("err = %v, want a read error not wrapping ErrClientAborted", err)
+	}
+
+	c.AbortWithError(http.StatusBadRequest, err)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("got %d, want 400", recorder.Code)
+	}
+}
+
+func TestParseFormClientAborted(t *testing.T) {
+	c, _, writer, cancel := pipeContext()
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	go func() {
+		writer.Write([]byte("name=Ana&"))
+		cancel()
+		writer.CloseWithError(context.Canceled)
+	}()
+
+	var formErr *FormError
+	if err := c.ParseForm(); !errors.As(err, &formErr) || formErr.Status != StatusClientClosedRequest {
+		t.Errorf("err = %v, want a FormError with the StatusClientClosedRequest status", err)
+	}
+}
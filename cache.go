@@ -0,0 +1,92 @@
+package feather
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl describes the directives of a Cache-Control response header, see Context.CacheControl.
+// Durations are rounded down to the second, and a zero duration omits its directive.
+type CacheControl struct {
+	Public               bool          // Public allows shared caches (CDNs, proxies) to store the response.
+	Private              bool          // Private restricts the storage of the response to the browser cache.
+	NoCache              bool          // NoCache requires caches to revalidate the response before each use.
+	NoStore              bool          // NoStore forbids any cache from storing the response.
+	NoTransform          bool          // NoTransform forbids intermediaries from transforming the response.
+	MustRevalidate       bool          // MustRevalidate forbids caches from using the response once stale.
+	ProxyRevalidate      bool          // ProxyRevalidate is MustRevalidate for shared caches only.
+	Immutable            bool          // Immutable tells the browsers the response never changes while fresh.
+	MaxAge               time.Duration // MaxAge is how long the response stays fresh.
+	SMaxAge              time.Duration // SMaxAge is how long the response stays fresh in shared caches.
+	StaleWhileRevalidate time.Duration // StaleWhileRevalidate is how long a stale response can be used while revalidated.
+	StaleIfError         time.Duration // StaleIfError is how long a stale response can be used when revalidation fails.
+}
+
+// String builds the value of the Cache-Control header.
+//
+// Returns:
+//   - The directives, separated by ", " (e.g. "public, max-age=31536000, immutable").
+func (cache CacheControl) String() string {
+	directives := make([]string, 0, 4)
+
+	flags := []struct {
+		enabled   bool
+		directive string
+	}{
+		{cache.Public, "public"},
+		{cache.Private, "private"},
+		{cache.NoCache, "no-cache"},
+		{cache.NoStore, "no-store"},
+		{cache.NoTransform, "no-transform"},
+		{cache.MustRevalidate, "must-revalidate"},
+		{cache.ProxyRevalidate, "proxy-revalidate"},
+	}
+	for _, flag := range flags {
+		if flag.enabled {
+			directives = append(directives, flag.directive)
+		}
+	}
+
+	durations := []struct {
+		duration  time.Duration
+		directive string
+	}{
+		{cache.MaxAge, "max-age"},
+		{cache.SMaxAge, "s-maxage"},
+		{cache.StaleWhileRevalidate, "stale-while-revalidate"},
+		{cache.StaleIfError, "stale-if-error"},
+	}
+	for _, duration := range durations {
+		if duration.duration > 0 {
+			directives = append(directives, duration.directive + "=" + strconv.FormatInt(int64(duration.duration / time.Second), 10))
+		}
+	}
+
+	if cache.Immutable {
+		directives = append(directives, "immutable")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// CacheControl sets the Cache-Control header of the response from the given directives.
+//
+// Parameters:
+//   - opts: The directives of the header, e.g. CacheControl{Public: true, MaxAge: time.Hour}.
+//
+// This function replaces any existing Cache-Control header. It does not return any value.
+func (c *Context) CacheControl(opts CacheControl) {
+	c.Writer.Header().Set("Cache-Control", opts.String())
+}
+
+// NoCache sets the headers preventing any cache from storing the response:
+// "Cache-Control: no-cache, no-store, must-revalidate", along with "Pragma: no-cache"
+// and "Expires: 0" for HTTP/1.0 caches.
+//
+// This function does not take any parameters and does not return any value.
+func (c *Context) NoCache() {
+	c.CacheControl(CacheControl{NoStore: true, NoCache: true, MustRevalidate: true})
+	c.Writer.Header().Set("Pragma", "no-cache")
+	c.Writer.Header().Set("Expires", "0")
+}
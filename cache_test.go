@@ -0,0 +1,106 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCacheControlString(t *testing.T) {
+	tests := []struct {
+		name  string
+		cache CacheControl
+		want  string
+	}{
+		{"empty", CacheControl{}, ""},
+		{"immutable", CacheControl{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true}, "public, max-age=31536000, immutable"},
+		{"shared", CacheControl{Public: true, MaxAge: time.Minute, SMaxAge: time.Hour}, "public, max-age=60, s-maxage=3600"},
+		{"no store", CacheControl{NoStore: true}, "no-store"},
+		{"private", CacheControl{Private: true, NoCache: true, MustRevalidate: true}, "private, no-cache, must-revalidate"},
+		{"stale", CacheControl{MaxAge: 1500 * time.Millisecond, StaleWhileRevalidate: 30 * time.Second, StaleIfError: time.Hour}, "max-age=1, stale-while-revalidate=30, stale-if-error=3600"},
+		{"proxy", CacheControl{NoTransform: true, ProxyRevalidate: true, SMaxAge: 10 * time.Second}, "no-transform, proxy-revalidate, s-maxage=10"},
+	}
+
+	for _, test := range tests {
+		if got := test.cache.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestContextCacheControl(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/", nil)
+	c.Writer.Header().Set("Cache-Control", "no-store")
+
+	c.CacheControl(CacheControl{Public: true, MaxAge: time.Hour})
+	c.String(http.StatusOK, "ok")
+
+	if got := recorder.Header().Values("Cache-Control"); len(got) != 1 || got[0] != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+}
+
+func TestContextNoCache(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/", nil)
+
+	c.NoCache()
+	c.String(http.StatusOK, "ok")
+
+	want := map[string]string{
+		"Cache-Control": "no-cache, no-store, must-revalidate",
+		"Pragma":        "no-cache",
+		"Expires":       "0",
+	}
+	for name, value := range want {
+		if got := recorder.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestStaticFingerprintCaching(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.3f2a9c1e.js": {Data: []byte("fingerprinted")},
+		"app.js":          {Data: []byte("plain")},
+		"v1-app.js":       {Data: []byte("custom")},
+	}
+
+	tests := []struct {
+		name    string
+		pattern *regexp.Regexp
+		url     string
+		want    string
+	}{
+		{"fingerprinted", nil, "/assets/app.3f2a9c1e.js", "public, max-age=31536000, immutable"},
+		{"plain", nil, "/assets/app.js", "public, max-age=300"},
+		{"custom pattern", regexp.MustCompile(`^v\d+-`), "/assets/v1-app.js", "public, max-age=31536000, immutable"},
+		{"custom pattern plain", regexp.MustCompile(`^v\d+-`), "/assets/app.3f2a9c1e.js", "public, max-age=300"},
+	}
+
+	for _, test := range tests {
+		server := NewServer()
+		server.StaticFS("/assets", fsys, WithFingerprintCaching(test.pattern, 5*time.Minute))
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", test.url, nil))
+
+		if got := recorder.Header().Get("Cache-Control"); recorder.Code != http.StatusOK || got != test.want {
+			t.Errorf("%s: got %d with %q, want %q", test.name, recorder.Code, got, test.want)
+		}
+	}
+}
+
+func TestStaticWithoutFingerprintCaching(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/assets", fstest.MapFS{"app.3f2a9c1e.js": {Data: []byte("x")}})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/assets/app.3f2a9c1e.js", nil))
+
+	if got := recorder.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control = %q, want none", got)
+	}
+}
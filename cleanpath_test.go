@@ -0,0 +1,160 @@
+package feather
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// cleanPathServer returns a server with path cleaning enabled and routes on /a/b and /admin for GET and POST.
+func cleanPathServer() *Server {
+	server := NewServer()
+	server.SetCleanPath(true)
+
+	for _, pattern := range []string{"/a/b", "/admin"} {
+		handler := func(c *Context) {
+			c.String(http.StatusOK, c.Request.Method+" "+pattern)
+		}
+		server.GET(pattern, handler)
+		server.POST(pattern, handler)
+	}
+
+	return server
+}
+
+func TestCleanPathRedirectsSafeMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		location string
+	}{
+		{"duplicate slashes", "/a//b", "/a/b"},
+		{"leading duplicate slashes", "//a///b", "/a/b"},
+		{"dot segment", "/a/./b", "/a/b"},
+		{"dot-dot segment", "/a/../admin", "/admin"},
+		{"traversal above the root", "/../../admin", "/admin"},
+		{"encoded dots", "/a/%2e%2e/admin", "/admin"},
+		{"uppercase encoded dots", "/a/%2E/b", "/a/b"},
+		{"encoded slash", "/a/..%2Fadmin", "/admin"},
+		{"trailing slash kept", "/a/./b/", "/a/b/"},
+		{"query kept", "/a//b?page=2&sort=name", "/a/b?page=2&sort=name"},
+		{"protocol-relative path", "//evil.example.com/", "/evil.example.com/"},
+		{"backslash after traversal", "/a/..%2F%5Cevil.example.com", "/%5Cevil.example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, method := range []string{"GET", "HEAD"} {
+				recorder := cleanPathServer().TestRequest(method, test.target, nil, nil)
+
+				if recorder.Code != http.StatusMovedPermanently {
+					t.Fatalf("%s %s = %d, want 301", method, test.target, recorder.Code)
+				}
+				if location := recorder.Header().Get("Location"); location != test.location {
+					t.Errorf("%s %s: Location = %q, want %q", method, test.target, location, test.location)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanPathMatchesUnsafeMethods(t *testing.T) {
+	tests := []struct {
+		target string
+		body   string
+	}{
+		{"/a//b", "POST /a/b"},
+		{"/a/../admin", "POST /admin"},
+		{"/a/%2e%2e/admin", "POST /admin"},
+	}
+
+	for _, test := range tests {
+		recorder := cleanPathServer().TestRequest("POST", test.target, nil, nil)
+
+		if recorder.Code != http.StatusOK || recorder.Body.String() != test.body {
+			t.Errorf("POST %s = %d %q, want 200 %q", test.target, recorder.Code, recorder.Body.String(), test.body)
+		}
+	}
+}
+
+func TestCleanPathCanonicalPaths(t *testing.T) {
+	for _, target := range []string{"/a/b", "/admin", "/a/b?x=1"} {
+		recorder := cleanPathServer().TestRequest("GET", target, nil, nil)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", target, recorder.Code)
+		}
+	}
+}
+
+func TestCleanPathDisabled(t *testing.T) {
+	server := NewServer()
+	server.GET("/a/b", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, target := range []string{"/a//b", "/a/./b", "/x/../a/b"} {
+		recorder := server.TestRequest("GET", target, nil, nil)
+
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404 without SetCleanPath", target, recorder.Code)
+		}
+	}
+}
+
+func TestStaticAlwaysCleansPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.css":     {Data: []byte("body{}")},
+		"css/app.css": {Data: []byte("nested{}")},
+	}
+
+	// secret.txt lies next to the served directory, and must never be reachable
+	dir := t.TempDir()
+	public := filepath.Join(dir, "public")
+	if err := os.Mkdir(public, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(public, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	servers := map[string]*Server{"StaticFS": NewServer(), "Static": NewServer()}
+	servers["StaticFS"].StaticFS("/static", fsys)
+	servers["Static"].Static("/static", public)
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/static/app.css", http.StatusOK},
+		{"/static/./app.css", http.StatusOK},
+		{"/static//app.css", http.StatusOK},
+		{"/static/css/../app.css", http.StatusOK},
+		{"/static/../secret.txt", http.StatusNotFound},
+		{"/static/../../secret.txt", http.StatusNotFound},
+		{"/static/css/../../secret.txt", http.StatusNotFound},
+		{"/static/%2e%2e/secret.txt", http.StatusNotFound},
+		{"/static/%2E%2E/secret.txt", http.StatusNotFound},
+		{"/static/..%2fsecret.txt", http.StatusNotFound},
+		{"/static/..%5csecret.txt", http.StatusNotFound},
+		{"/static/%2e%2e%2f%2e%2e%2fsecret.txt", http.StatusNotFound},
+	}
+
+	for name, server := range servers {
+		for _, test := range tests {
+			recorder := server.TestRequest("GET", test.target, nil, nil)
+
+			if recorder.Code != test.status {
+				t.Errorf("%s: GET %s = %d, want %d", name, test.target, recorder.Code, test.status)
+			}
+			if recorder.Body.String() == "secret" {
+				t.Errorf("%s: GET %s served the file outside of the directory", name, test.target)
+			}
+		}
+	}
+}
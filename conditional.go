@@ -0,0 +1,142 @@
+package feather
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckPreconditions evaluates the conditional headers of the request (If-Match, If-Unmodified-Since,
+// If-None-Match and If-Modified-Since) against the current state of the resource, following RFC 9110
+// section 13.2.2, e.g. to implement optimistic concurrency on update endpoints.
+//
+// Parameters:
+//   - etag: The current entity tag of the resource, quoted or not (e.g. `"v42"` or `W/"v42"`).
+//     An empty etag means the resource has no entity tag.
+//   - lastModified: The last modification date of the resource. A zero time means it is unknown.
+//
+// If-Match uses the strong comparison and If-None-Match the weak one. When a precondition fails,
+// the response is written: 412 Precondition Failed, or 304 Not Modified for the GET and HEAD requests
+// whose cached representation is still valid. The ETag and Last-Modified headers are set in every
+// case but the 412 one.
+//
+// Returns:
+//   - true if the handler should proceed with the request, false if a response has been written.
+func (c *Context) CheckPreconditions(etag string, lastModified time.Time) bool {
+	if etag != "" && !strings.HasSuffix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	lastModified = lastModified.Truncate(time.Second)
+	exists := etag != "" || !lastModified.IsZero()
+	safe := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+
+	// Step 1 and 2: the state-changing preconditions
+	if ifMatch := c.Request.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, exists, true) {
+			c.Error(http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed))
+			return false
+		}
+	} else if since, ok := parseHTTPDate(c.Request.Header.Get("If-Unmodified-Since")); ok && !lastModified.IsZero() {
+		if lastModified.After(since) {
+			c.Error(http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed))
+			return false
+		}
+	}
+
+	header := c.Writer.Header()
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// Step 3 and 4: the cache validation preconditions
+	if ifNoneMatch := c.Request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, exists, false) {
+			if safe {
+				c.notModified()
+			} else {
+				header.Del("ETag")
+				header.Del("Last-Modified")
+				c.Error(http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed))
+			}
+			return false
+		}
+	} else if since, ok := parseHTTPDate(c.Request.Header.Get("If-Modified-Since")); ok && safe && !lastModified.IsZero() {
+		if !lastModified.After(since) {
+			c.notModified()
+			return false
+		}
+	}
+
+	return true
+}
+
+// notModified writes a 304 Not Modified response, dropping the headers describing a body.
+func (c *Context) notModified() {
+	header := c.Writer.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+
+	c.Writer.WriteHeader(http.StatusNotModified)
+}
+
+// matchETag reports whether the entity tag list of a If-Match or If-None-Match header matches etag,
+// using the strong or the weak comparison. "*" matches any existing representation.
+func matchETag(list string, etag string, exists bool, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return exists
+	}
+	if etag == "" {
+		return false
+	}
+
+	for _, candidate := range splitETags(list) {
+		if strong {
+			if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+				return true
+			}
+		} else if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitETags splits a comma-separated list of entity tags, the commas inside the quoted tags being kept.
+func splitETags(list string) []string {
+	tags := make([]string, 0, 1)
+	quoted := false
+	start := 0
+
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				if tag := strings.TrimSpace(list[start:i]); tag != "" {
+					tags = append(tags, tag)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if tag := strings.TrimSpace(list[start:]); tag != "" {
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// parseHTTPDate parses the date of a conditional header, reporting false when it is absent or invalid.
+func parseHTTPDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	date, err := http.ParseTime(value)
+	return date, err == nil
+}
@@ -0,0 +1,162 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchETagComparison(t *testing.T) {
+	// The examples of the comparison functions, RFC 9110 section 8.8.3.2
+	tests := []struct {
+		a, b   string
+		strong bool
+		weak   bool
+	}{
+		{`W/"1"`, `W/"1"`, false, true},
+		{`W/"1"`, `W/"2"`, false, false},
+		{`W/"1"`, `"1"`, false, true},
+		{`"1"`, `"1"`, true, true},
+	}
+
+	for _, test := range tests {
+		if got := matchETag(test.a, test.b, true, true); got != test.strong {
+			t.Errorf("strong comparison of %s and %s = %v, want %v", test.a, test.b, got, test.strong)
+		}
+		if got := matchETag(test.a, test.b, true, false); got != test.weak {
+			t.Errorf("weak comparison of %s and %s = %v, want %v", test.a, test.b, got, test.weak)
+		}
+	}
+}
+
+func TestSplitETags(t *testing.T) {
+	got := splitETags(` "a", W/"b,c" ,, "d" `)
+	want := []string{`"a"`, `W/"b,c"`, `"d"`}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+	exact := modified.Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		etag         string
+		lastModified time.Time
+		proceed      bool
+		status       int
+	}{
+		{"no precondition", "PUT", nil, `"v1"`, modified, true, 0},
+
+		// If-Match, strong comparison
+		{"if-match matches", "PUT", map[string]string{"If-Match": `"v0", "v1"`}, `"v1"`, modified, true, 0},
+		{"if-match differs", "PUT", map[string]string{"If-Match": `"v0"`}, `"v1"`, modified, false, http.StatusPreconditionFailed},
+		{"if-match weak tag", "PUT", map[string]string{"If-Match": `W/"v1"`}, `"v1"`, modified, false, http.StatusPreconditionFailed},
+		{"if-match weak resource", "PUT", map[string]string{"If-Match": `"v1"`}, `W/"v1"`, modified, false, http.StatusPreconditionFailed},
+		{"if-match unquoted etag", "PUT", map[string]string{"If-Match": `"v1"`}, "v1", modified, true, 0},
+		{"if-match star", "PUT", map[string]string{"If-Match": "*"}, `"v1"`, time.Time{}, true, 0},
+		{"if-match star missing resource", "PUT", map[string]string{"If-Match": "*"}, "", time.Time{}, false, http.StatusPreconditionFailed},
+
+		// If-Unmodified-Since, ignored when If-Match is present
+		{"unmodified since", "PUT", map[string]string{"If-Unmodified-Since": exact}, `"v1"`, modified, true, 0},
+		{"modified since", "PUT", map[string]string{"If-Unmodified-Since": before}, `"v1"`, modified, false, http.StatusPreconditionFailed},
+		{"if-match over if-unmodified-since", "PUT", map[string]string{"If-Match": `"v1"`, "If-Unmodified-Since": before}, `"v1"`, modified, true, 0},
+		{"invalid if-unmodified-since", "PUT", map[string]string{"If-Unmodified-Since": "yesterday"}, `"v1"`, modified, true, 0},
+
+		// If-None-Match, weak comparison
+		{"if-none-match matches on get", "GET", map[string]string{"If-None-Match": `W/"v1"`}, `"v1"`, modified, false, http.StatusNotModified},
+		{"if-none-match matches on head", "HEAD", map[string]string{"If-None-Match": `"v1"`}, `"v1"`, modified, false, http.StatusNotModified},
+		{"if-none-match differs", "GET", map[string]string{"If-None-Match": `"v0"`}, `"v1"`, modified, true, 0},
+		{"if-none-match matches on put", "PUT", map[string]string{"If-None-Match": "*"}, `"v1"`, modified, false, http.StatusPreconditionFailed},
+		{"if-none-match star creates", "PUT", map[string]string{"If-None-Match": "*"}, "", time.Time{}, true, 0},
+
+		// If-Modified-Since, ignored when If-None-Match is present and for the unsafe methods
+		{"not modified since", "GET", map[string]string{"If-Modified-Since": after}, `"v1"`, modified, false, http.StatusNotModified},
+		{"not modified since exact", "GET", map[string]string{"If-Modified-Since": exact}, `"v1"`, modified.Add(500 * time.Millisecond), false, http.StatusNotModified},
+		{"modified since get", "GET", map[string]string{"If-Modified-Since": before}, `"v1"`, modified, true, 0},
+		{"if-none-match over if-modified-since", "GET", map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": after}, `"v1"`, modified, true, 0},
+		{"if-modified-since on post", "POST", map[string]string{"If-Modified-Since": after}, `"v1"`, modified, true, 0},
+	}
+
+	for _, test := range tests {
+		c, recorder := NewTestContext(test.method, "/", nil)
+		for name, value := range test.headers {
+			c.Request.Header.Set(name, value)
+		}
+
+		if proceed := c.CheckPreconditions(test.etag, test.lastModified); proceed != test.proceed {
+			t.Errorf("%s: proceed = %v, want %v", test.name, proceed, test.proceed)
+		}
+		if !test.proceed && recorder.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.status)
+		}
+	}
+}
+
+func TestCheckPreconditionsHeaders(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// The validators are sent on success and on 304, not on 412
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		etag    string
+	}{
+		{"success", "GET", nil, `"v1"`},
+		{"not modified", "GET", map[string]string{"If-None-Match": `"v1"`}, `"v1"`},
+		{"precondition failed", "PUT", map[string]string{"If-Match": `"v0"`}, ""},
+	}
+
+	for _, test := range tests {
+		c, recorder := NewTestContext(test.method, "/", nil)
+		for name, value := range test.headers {
+			c.Request.Header.Set(name, value)
+		}
+
+		c.CheckPreconditions("v1", modified)
+		if got := recorder.Header().Get("ETag"); got != test.etag {
+			t.Errorf("%s: ETag = %q, want %q", test.name, got, test.etag)
+		}
+
+		wantModified := ""
+		if test.etag != "" {
+			wantModified = "Fri, 01 Mar 2024 12:00:00 GMT"
+		}
+		if got := recorder.Header().Get("Last-Modified"); got != wantModified {
+			t.Errorf("%s: Last-Modified = %q, want %q", test.name, got, wantModified)
+		}
+	}
+}
+
+func TestCheckPreconditionsInHandler(t *testing.T) {
+	server := NewServer()
+	server.GET("/doc", func(c *Context) {
+		if !c.CheckPreconditions(`"v1"`, time.Time{}) {
+			return
+		}
+		c.String(http.StatusOK, "content")
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/doc", nil)
+	request.Header.Set("If-None-Match", `"v1"`)
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Errorf("got %d with %q, want an empty 304", recorder.Code, recorder.Body.String())
+	}
+}
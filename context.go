@@ -1,23 +1,99 @@
 package feather
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"html/template"
+	"slices"
+	"sync"
+	"time"
 )
 
+// ErrPushNotSupported is returned by Context.Push when the underlying connection does not
+// support HTTP/2 server push, e.g. because it is an HTTP/1.1 connection.
+var ErrPushNotSupported = errors.New("feather: HTTP/2 server push is not supported by this connection")
+
+// ErrFileTooLarge is returned by Context.FormFile, wrapped in a *FormError, when the uploaded
+// file exceeds the maximum size configured with Server.SetMaxFileSize.
+var ErrFileTooLarge = errors.New("feather: uploaded file is too large")
+
+// ErrClientAborted is returned, wrapping the read error, by the helpers reading the body of the request
+// (JSONBody, BindAll, ParseForm, EachPart, ...) when the client disconnected before sending the whole body.
+// It is a client-side event rather than a bad request: AbortWithError does not answer it, and the Logging
+// middleware reports such requests with the StatusClientClosedRequest status.
+var ErrClientAborted = errors.New("feather: client aborted the request")
+
+// StatusClientClosedRequest is the non-standard status (popularized by nginx) of the requests whose client
+// disconnected before the response was sent, see ErrClientAborted. It is never sent to the client.
+const StatusClientClosedRequest = 499
+
+// RequestIDKey is the key of the Context's Data map under which the request ID is stored,
+// see Context.RequestID and the RequestID middleware.
+const RequestIDKey = "request_id"
+
+// TraceIDKey and SpanIDKey are the keys of the Context's Data map under which the W3C trace context of the
+// request is stored, see Context.TraceID, Context.SpanID and the TraceContext middleware.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TranslatorKey is the key of the Context's Data map under which the Translator of the request
+// is stored by the i18n middleware, see Context.T.
+const TranslatorKey = "translator"
+
+// Translator translates message keys into the locale of a request.
+// It is implemented by the i18n package and used by Context.T and the "t" template function.
+type Translator interface {
+	// T returns the message of key, formatted with args. The first integer argument, if any,
+	// selects the plural form of the message.
+	T(key string, args ...any) string
+}
+
+// Context must satisfy the context.Context interface.
+var _ context.Context = (*Context)(nil)
+
 // Context represents the state and data associated with an HTTP request and response.
 // It provides methods for handling requests, sending responses, and storing data
 // for middleware and handlers.
+//
+// Context implements context.Context by delegating to the context of its request, so it
+// can be passed directly to functions such as db.QueryContext(c, ...).
+//
+// The Contexts built by the router are recycled once the request has been handled: a Context, and the copies
+// returned by WithValue, must not be used after the handler returns (e.g. from a goroutine) unless Retain is called.
 type Context struct {
     Writer  http.ResponseWriter // Writer is the HTTP response writer used to construct the HTTP response.
     Request *http.Request       // Request is the HTTP request object containing details about the client's request.
     Params  map[string]string   // Params is a map that stores dynamic route parameters extracted from the URL.
-    Data    map[string]any      // Data is a map for storing arbitrary key-value pairs, typically used by middleware.
+    Data    map[string]any      // Data is a map for storing arbitrary key-value pairs, typically used by middleware. Use Set and Get to access it from several goroutines.
+    Route   *Route              // Route is the route matched by the request, nil when the Context is not built by the router.
+    Errors  []error             // Errors holds the errors recorded by AbortWithError and PushError, in order.
+
+    server     *Server          // server is the Server which dispatched the request, used to read its configuration.
+    aborted    bool             // aborted is set by Abort to skip the remaining middlewares and the handler.
+    postFuncs  []HandlerFunc    // postFuncs holds the functions registered by Post, run after the handler.
+    postRun    int              // postRun is the number of post functions already started, so that none runs twice after a panic.
+    background []func()         // background holds the functions registered by Background, run once the request is handled.
+    logger     *slog.Logger     // logger is the request logger returned by Logger, built on first use.
+    scoped     map[any]any      // scoped memoizes the per-request dependencies created by the factories of ProvideRequest.
+    formParsed bool             // formParsed is set once ParseForm has parsed the form of the request.
+    formErr    error            // formErr is the error returned by the first call to ParseForm.
+    handlingError bool          // handlingError is set while the server's error handler runs, so that it can call Error itself.
+    flashes    *flashes         // flashes holds the flash messages of the request, see Flash. It is loaded on first use.
+    dataMutex  *sync.RWMutex    // dataMutex protects Data in Set and Get. It is shared with the copies made by WithValue.
+    retained   bool             // retained is set by Retain to keep the Context out of the server's pool.
+    body       *countingBody    // body wraps the body of the request to count the bytes read from the wire, see RequestSize.
 }
 
 //==================================================== Helper for the response ==========================================================================================
@@ -30,12 +106,18 @@ type Context struct {
 //
 // This function sets the "Content-Type" header to "application/json",
 // writes the HTTP status code to the response, and encodes the provided
-// object as JSON into the response body.
+// object as JSON into the response body. In development mode the JSON
+// is pretty-printed with a two-space indentation.
 func (c *Context) JSON(status int, obj any) {
     c.Writer.Header().Set("Content-Type", "application/json")
     c.Writer.WriteHeader(status)
 
-    json.NewEncoder(c.Writer).Encode(obj)
+    encoder := json.NewEncoder(c.Writer)
+    if c.IsDevelopmentMode() {
+        encoder.SetIndent("", "  ")
+    }
+
+    encoder.Encode(obj)
 }
 
 // String sends a plain text response with the specified HTTP status code.
@@ -130,22 +212,42 @@ func (c *Context) Redirect(status int, url string) {
 //   - status: The HTTP status code to set for the error response.
 //   - message: The error message to be sent in the response body.
 //
-// This function uses the http.Error method to send an error response
+// When the server has an error handler (see Server.SetErrorHandler), the error is routed
+// through it. Otherwise, this function uses the http.Error method to send an error response
 // with the provided status code and message.
 func (c *Context) Error(status int, message string) {
+	if c.server != nil && c.server.errorHandler != nil && !c.handlingError {
+		c.handlingError = true
+		defer func() { c.handlingError = false }()
+
+		c.server.errorHandler(c, status, message)
+		return
+	}
+
 	http.Error(c.Writer, message, status)
 }
 
-// SetHeader adds a header to the HTTP response.
+// SetHeader sets a header of the HTTP response.
 //
 // Parameters:
 //   - key: The name of the header to set.
 //   - value: The value to associate with the header.
 //
-// This function adds the specified header and its value to the HTTP response.
-// If the header already exists, the new value is appended to the existing values.
+// This function sets the specified header to the given value, replacing any existing values.
+// Use AddHeader to add a value to a header which can be repeated.
 func (c *Context) SetHeader(key string, value string) {
-	
+	c.Writer.Header().Set(key, value)
+}
+
+// AddHeader adds a value to a header of the HTTP response.
+//
+// Parameters:
+//   - key: The name of the header.
+//   - value: The value to append to the header.
+//
+// This function appends the value to the existing values of the header, for the headers which
+// can be repeated (e.g. "Link" or "Vary"). Cookies are better set with SetCookie.
+func (c *Context) AddHeader(key string, value string) {
 	c.Writer.Header().Add(key, value)
 }
 
@@ -168,7 +270,8 @@ func (c *Context) ContentType(value string) {
 //             expiration, path, domain, etc.
 //
 // This function uses the http.SetCookie method to add the specified
-// cookie to the HTTP response. It does not return any value.
+// cookie to the HTTP response. NewCookie creates cookies with safe defaults.
+// It does not return any value.
 func (c *Context) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.Writer, cookie)
 }
@@ -183,22 +286,102 @@ func (c *Context) SetCookie(cookie *http.Cookie) {
 //   - funcs: A template.FuncMap containing custom functions that can be used
 //           within the template. Can be nil if no custom functions are needed.
 //
-// This function creates a new template, parses the specified files, and executes
-// the template using the provided data. The rendered output is written to the
-// HTTP response. If any error occurs during template parsing or execution,
-// it sends a 500 Internal Server Error response with the error message.
+// This function parses the specified files and executes the template using
+// the provided data. The rendered output is written to the HTTP response.
+// In production mode, the parsed template is cached by the server after its
+// first use, so the functions given on the first call are the ones kept.
+// In development mode the cache is bypassed and the files are parsed again
+// on every request, so template changes show up without a restart.
+// A "t" function bound to Context.T, a "flashes" function bound to
+// Context.Flashes and an "assetPath" function resolving fingerprinted asset
+// names (see AssetPath) are always available to the template, unless funcs
+// provides its own.
+// If any error occurs during template parsing or execution, it sends a
+// 500 Internal Server Error response with the error message.
 func (c *Context) Template(files []string, data any, funcs template.FuncMap) {
-	tmpl := template.New("root").Funcs(funcs)
-	tmpl = template.Must(
-		tmpl.ParseFiles(files...),
-	)
+	tmpl, err := c.parseTemplate(files, funcs)
+	if err == nil {
+		// The cached template is never executed itself, so that each request can bind its own translator
+		tmpl, err = tmpl.Clone()
+	}
+	if err != nil {
+		c.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	err := tmpl.ExecuteTemplate(c.Writer, filepath.Base(files[0]), data)
+	if _, ok := funcs["t"]; !ok {
+		tmpl.Funcs(template.FuncMap{"t": c.T})
+	}
+	if _, ok := funcs["flashes"]; !ok {
+		// The flash cookie is cleared before the page is written, the headers cannot change afterwards
+		c.loadFlashes()
+		tmpl.Funcs(template.FuncMap{"flashes": c.Flashes})
+	}
+
+	err = tmpl.ExecuteTemplate(c.Writer, filepath.Base(files[0]), data)
 	if err != nil {
 		c.Error(http.StatusInternalServerError, err.Error())
 	}
 }
 
+// parseTemplate returns the template built from files, reading it from the server's
+// template cache when possible and storing it there once parsed.
+//
+// In development mode, the cached entry is invalidated first so the files are always
+// parsed again from disk.
+func (c *Context) parseTemplate(files []string, funcs template.FuncMap) (*template.Template, error) {
+	parse := func() (*template.Template, error) {
+		return template.New("root").
+			Funcs(template.FuncMap{
+				"t":         func(key string, args ...any) string { return key },
+				"flashes":   func(key string) []any { return nil },
+				"assetPath": AssetPath,
+			}).
+			Funcs(funcs).
+			ParseFiles(files...)
+	}
+
+	if c.server == nil {
+		return parse()
+	}
+
+	key := templateKey(files)
+	if c.IsDevelopmentMode() {
+		c.server.templates.invalidate(key)
+	}
+
+	if tmpl, ok := c.server.templates.get(key); ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c.server.templates.set(key, tmpl)
+	return tmpl, nil
+}
+
+// Push initiates an HTTP/2 server push of the target resource to the client.
+//
+// Parameters:
+//   - target: The absolute path (e.g. "/static/app.css") or absolute URL of the resource to push.
+//   - opts: The options of the push (method and headers of the promised request). Can be nil.
+//
+// Returns:
+//   - ErrPushNotSupported if the response writer does not implement http.Pusher, which is the
+//     case for HTTP/1.1 connections. HTTP/2 is only available over TLS, see Server.ListenTLS.
+//   - Otherwise, the error returned by the push itself, or nil on success.
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := c.Writer.(http.Pusher)
+	if !ok {
+		return ErrPushNotSupported
+	}
+
+	return pusher.Push(target, opts)
+}
+
 //==================================================== Helper for the request ===========================================================================================
 
 // Query retrieves the value of a query parameter from the URL.
@@ -219,11 +402,12 @@ func (c *Context) Query(key string) string {
 //   - v: A pointer to the structure where the JSON data will be unmarshaled.
 //
 // Returns:
-//   - An error if reading the body or unmarshaling the JSON fails.
+//   - An error if reading the body or unmarshaling the JSON fails, wrapping ErrClientAborted when
+//     the client disconnected during the upload.
 //     If successful, the provided structure is populated with the request data.
 func (c *Context) JSONBody(v any) error {
 	body, err := io.ReadAll(c.Request.Body)
-	if err != nil { return err }
+	if err != nil { return c.bodyError(err) }
 
 	err = json.Unmarshal(body, v)
 	if err != nil { return err }
@@ -231,6 +415,22 @@ func (c *Context) JSONBody(v any) error {
 	return nil
 }
 
+// bodyError wraps the error of a read of the request body with ErrClientAborted when it is caused by the
+// client disconnecting: a cancelled request or an aborted handler. The other errors, such as a body shorter
+// than announced (io.ErrUnexpectedEOF) while the request is still alive, are bad requests and returned as is.
+func (c *Context) bodyError(err error) error {
+	if err == nil || errors.Is(err, ErrClientAborted) {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, http.ErrAbortHandler) ||
+		errors.Is(c.Request.Context().Err(), context.Canceled) {
+		return fmt.Errorf("%w: %w", ErrClientAborted, err)
+	}
+
+	return err
+}
+
 // Header retrieves the value of a specific request header.
 //
 // Parameters:
@@ -263,17 +463,166 @@ func (c *Context) Cookie(name string) (*http.Cookie, error) {
 // Returns:
 //   - The value of the specified form field as a string.
 //     If the form field is not present, it returns an empty string.
-//     If there is an error parsing the form data, it sends an HTTP 400 Bad Request response
-//     and does not return a value.
+//     If the form cannot be parsed or exceeds the limits of the FormConfig (see ParseForm), it sends
+//     an error response with the matching status (400, 413 or 415) and returns an empty string.
+//     No response is sent when the client disconnected during the upload (see ErrClientAborted).
 func (c *Context) FormValue(key string) string {
-	err := c.Request.ParseForm()
-	if err != nil {
-		http.Error(c.Writer, err.Error(), http.StatusBadRequest)
+	if err := c.ParseForm(); err != nil {
+		var formErr *FormError
+		if errors.Is(err, ErrClientAborted) {
+			c.PushError(err)
+		} else if errors.As(err, &formErr) {
+			c.Error(formErr.Status, err.Error())
+		} else {
+			c.Error(http.StatusBadRequest, err.Error())
+		}
+		return ""
 	}
 
 	return c.Request.FormValue(key)
 }
 
+// FormFile reads the file uploaded in a multipart form field entirely into memory.
+//
+// It is meant for small single-file uploads (avatars, CSV imports, ...): the file is read
+// into a []byte, up to the maximum size configured with Server.SetMaxFileSize (10 MB by default).
+// For large files, use SaveUploadedFile to stream the upload to disk instead.
+//
+// Parameters:
+//   - field: The name of the file field of the multipart form.
+//
+// Returns:
+//   - The content of the uploaded file.
+//   - The header of the file, holding its filename, size and content type.
+//   - A *FormError wrapping ErrFileTooLarge (status 413) if the file exceeds the maximum size,
+//     the *FormError returned by ParseForm, or the error returned while opening or reading
+//     the file (http.ErrMissingFile if the field is absent).
+func (c *Context) FormFile(field string) ([]byte, *multipart.FileHeader, error) {
+	if err := c.ParseForm(); err != nil {
+		return nil, nil, err
+	}
+
+	file, header, err := c.Request.FormFile(field)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	maxSize := c.formConfig().MaxFileSize
+	tooLarge := &FormError{Status: http.StatusRequestEntityTooLarge, Err: ErrFileTooLarge}
+	if header.Size > maxSize {
+		return nil, header, tooLarge
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, header, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, header, tooLarge
+	}
+
+	return content, header, nil
+}
+
+// SaveUploadedFile copies an uploaded file to dst on the server's filesystem, without
+// loading it into memory.
+//
+// Parameters:
+//   - header: The header of the uploaded file, as returned by FormFile or c.Request.FormFile.
+//   - dst: The path of the destination file. It is created or truncated.
+//
+// Returns:
+//   - An error if the uploaded file cannot be opened or the destination cannot be written.
+func (c *Context) SaveUploadedFile(header *multipart.FileHeader, dst string) error {
+	src, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// Deadline returns the time when the request's context will be cancelled, if any.
+// It delegates to c.Request.Context().Deadline().
+//
+// Returns:
+//   - The deadline of the request's context.
+//   - false if no deadline is set.
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done returns a channel closed when the request's context is cancelled, e.g. when the
+// client disconnects. It delegates to c.Request.Context().Done(), so handlers can use
+// c directly in a select statement.
+//
+// Returns:
+//   - A channel closed when the request is cancelled.
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err returns the reason why the request's context has been cancelled.
+// It delegates to c.Request.Context().Err().
+//
+// Returns:
+//   - nil if the request's context is not cancelled yet, otherwise context.Canceled
+//     or context.DeadlineExceeded.
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value returns the value associated with key in the request's context.
+// It delegates to c.Request.Context().Value(key). Use Get for the values of the Data map.
+//
+// Parameters:
+//   - key: The key of the value, as given to context.WithValue.
+//
+// Returns:
+//   - The value associated with key, or nil if there is none.
+func (c *Context) Value(key any) any {
+	return c.requestContext().Value(key)
+}
+
+// WithValue returns a copy of the Context whose request carries key and val in its context.Context,
+// for interoperability with libraries reading values from the standard context chain.
+//
+// Parameters:
+//   - key: The key of the value. It should be of a custom unexported type to avoid collisions.
+//   - val: The value to associate with key.
+//
+// Returns:
+//   - A new *Context whose Request is c.Request.WithContext(context.WithValue(...)).
+//     It shares the Writer, Params and Data of the original Context. Since the copy is usually handed to
+//     another function or goroutine, the original Context is retained (see Retain).
+func (c *Context) WithValue(key, val any) *Context {
+	c.retained = true
+
+	copied := *c
+	copied.Request = c.Request.WithContext(context.WithValue(c.requestContext(), key, val))
+
+	return &copied
+}
+
+// requestContext returns the context of the request, or context.Background() when the
+// Context has no request (e.g. a Context built by hand in a test).
+func (c *Context) requestContext() context.Context {
+	if c == nil || c.Request == nil {
+		return context.Background()
+	}
+
+	return c.Request.Context()
+}
+
 //==================================================== Helper for middlewares ===========================================================================================
 
 // Set stores a key-value pair in the Context's Data map. This method should only be used by middlewares.
@@ -284,7 +633,13 @@ func (c *Context) FormValue(key string) string {
 //
 // This function does not return any value. It updates the Context's Data map
 // by associating the specified key with the provided value.
+// It is safe to call concurrently with Get, e.g. from goroutines started by the handler.
 func (c *Context) Set(key string, value any) {
+	if c.dataMutex != nil {
+		c.dataMutex.Lock()
+		defer c.dataMutex.Unlock()
+	}
+
 	c.Data[key] = value
 }
 
@@ -296,10 +651,31 @@ func (c *Context) Set(key string, value any) {
 // Returns:
 //   - The value associated with the specified key, which can be of any type.
 //     If the key does not exist in the Data map, it returns nil.
+//
+// It is safe to call concurrently with Set, e.g. from goroutines started by the handler.
 func (c *Context) Get(key string) any {
+	if c.dataMutex != nil {
+		c.dataMutex.RLock()
+		defer c.dataMutex.RUnlock()
+	}
+
 	return c.Data[key]
 }
 
+// GetString retrieves the string associated with the specified key from the Context's Data map,
+// e.g. the API version stored by the AcceptVersion middleware.
+//
+// Parameters:
+//   - key: A string representing the key whose associated value is to be retrieved.
+//
+// Returns:
+//   - The string associated with the key. If the key does not exist or its value is not a string,
+//     it returns an empty string.
+func (c *Context) GetString(key string) string {
+	value, _ := c.Get(key).(string)
+	return value
+}
+
 // ClientIP retrieves the IP address of the client making the request.
 //
 // This function does not take any parameters.
@@ -311,25 +687,312 @@ func (c *Context) ClientIP() string {
 	return c.Request.RemoteAddr
 }
 
+// TraceID retrieves the W3C trace ID of the request stored under TraceIDKey by the TraceContext middleware.
+//
+// Returns:
+//   - The 32-character hexadecimal trace ID. If no trace context has been set, it returns an empty string.
+func (c *Context) TraceID() string {
+	id, _ := c.Get(TraceIDKey).(string)
+	return id
+}
+
+// SpanID retrieves the ID of the span of the request stored under SpanIDKey by the TraceContext middleware.
+//
+// Returns:
+//   - The 16-character hexadecimal span ID. If no trace context has been set, it returns an empty string.
+func (c *Context) SpanID() string {
+	id, _ := c.Get(SpanIDKey).(string)
+	return id
+}
+
+// RequestID retrieves the ID of the request stored under RequestIDKey by the RequestID middleware.
+//
+// Returns:
+//   - The request ID as a string. If no request ID has been set, it returns an empty string.
+func (c *Context) RequestID() string {
+	id, _ := c.Get(RequestIDKey).(string)
+	return id
+}
+
+// Logger returns the logger of the request, to write application logs correlated with the access logs.
+//
+// The logger is derived from Server.BaseLogger, or slog.Default() when it is unset, and carries the
+// "client_ip", "route", "request_id" and "trace_id" attributes of the request (the last two only when a
+// request ID or a trace context has been assigned). It is built on first use, unless a middleware has replaced it with SetLogger.
+//
+// Returns:
+//   - The *slog.Logger of the request.
+func (c *Context) Logger() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+
+	base := slog.Default()
+	if c.server != nil && c.server.BaseLogger != nil {
+		base = c.server.BaseLogger
+	}
+
+	attributes := make([]any, 0, 8)
+	if c.Request != nil {
+		attributes = append(attributes, "client_ip", c.ClientIP())
+	}
+	if c.Route != nil {
+		attributes = append(attributes, "route", c.Route.Pattern)
+	}
+	if id := c.RequestID(); id != "" {
+		attributes = append(attributes, "request_id", id)
+	}
+	if id := c.TraceID(); id != "" {
+		attributes = append(attributes, "trace_id", id)
+	}
+
+	c.logger = base.With(attributes...)
+	return c.logger
+}
+
+// SetLogger replaces the logger of the request returned by Logger. This method should only be used by middlewares.
+//
+// Parameters:
+//   - logger: The new logger, usually derived from c.Logger() with additional attributes
+//     (e.g. c.SetLogger(c.Logger().With("trace_id", traceID))).
+//
+// It does not return any value.
+func (c *Context) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// T translates key into the locale of the request, using the Translator stored under
+// TranslatorKey by the i18n middleware.
+//
+// Parameters:
+//   - key: The key of the message in the message catalogs.
+//   - args: The values used to format the message. The first integer argument, if any,
+//     also selects the plural form of the message.
+//
+// Returns:
+//   - The translated message. If no Translator has been set for the request, it returns the key itself.
+func (c *Context) T(key string, args ...any) string {
+	translator, ok := c.Get(TranslatorKey).(Translator)
+	if !ok {
+		return key
+	}
+
+	return translator.T(key, args...)
+}
+
+// UserID retrieves the ID of the authenticated user, stored by an authentication middleware
+// under the key configured with Server.SetUserIDKey ("user_id" by default).
+//
+// Returns:
+//   - The user ID as a string. Non-string IDs are formatted with fmt.Sprint.
+//     If no user ID has been set, it returns an empty string.
+func (c *Context) UserID() string {
+	value := c.Get(c.userIDKey())
+	if value == nil {
+		return ""
+	}
+
+	if id, ok := value.(string); ok {
+		return id
+	}
+	return fmt.Sprint(value)
+}
+
+// SetUserID stores the ID of the authenticated user under the key configured with
+// Server.SetUserIDKey. This method should only be used by authentication middlewares.
+//
+// Parameters:
+//   - id: The ID of the authenticated user.
+//
+// This function does not return any value.
+func (c *Context) SetUserID(id string) {
+	c.Set(c.userIDKey(), id)
+}
+
+// userIDKey returns the key holding the user ID, falling back to "user_id" when the
+// Context is not attached to a Server.
+func (c *Context) userIDKey() string {
+	if c.server == nil || c.server.userIDKey == "" {
+		return "user_id"
+	}
+
+	return c.server.userIDKey
+}
+
+// RouteMeta retrieves the metadata attached to the matched route with RouteBuilder.Meta.
+//
+// Middlewares use it to make per-route decisions declaratively, e.g. an authorization
+// middleware checking c.RouteMeta("role") against the role of the user.
+//
+// Parameters:
+//   - key: The key of the metadata to retrieve.
+//
+// Returns:
+//   - The value associated with key on the matched route, or nil if there is none
+//     (or if no route is attached to the Context).
+func (c *Context) RouteMeta(key string) any {
+	if c.Route == nil {
+		return nil
+	}
+
+	return c.Route.Meta[key]
+}
+
+// IsDevelopmentMode reports whether the server handling the request runs in development mode.
+//
+// Middlewares use it to switch to their development behaviour, e.g. a recovery middleware
+// including the output of runtime/debug.Stack() in the response body.
+//
+// Returns:
+//   - true if the server has been put in development mode with SetDevelopmentMode(true),
+//     false otherwise (including when the Context is not attached to a Server).
+func (c *Context) IsDevelopmentMode() bool {
+	return c.server != nil && c.server.developmentMode
+}
+
 // Abort halts the execution of any subsequent middleware or handlers. This method should only be used by middlewares.
 //
-// This function sets the "Abort" key in the Context's Data map to true,
-// signaling that the request processing should be stopped immediately.
+// This function marks the Context as aborted, signaling that the request processing should be stopped immediately.
+// The functions registered with Post still run.
 // It does not take any parameters and does not return any value.
 func (c *Context) Abort() {
-	c.Data["Abort"] = true
+	c.aborted = true
+}
+
+// Retain keeps the Context from being recycled by the server once the request has been handled,
+// when pooling is enabled (see Server.SetContextPooling).
+//
+// It must be called, from the request goroutine, when the Context is still used after the handler returns,
+// e.g. by a goroutine started by the handler. WithValue and Background retain the Context automatically.
+// It does not take any parameters and does not return any value.
+func (c *Context) Retain() {
+	c.retained = true
 }
 
-// Post appends a new handler function to the "PostFunc" middleware chain stored in the Context's Data map. This method should only be used by middlewares.
+// Detach returns a copy of the Context which can be used once the request has been handled,
+// e.g. by a worker processing a job enqueued by the handler (see Async).
+//
+// The copy has its own Params and Data maps, copied from the Context, and keeps its Route and Logger.
+// Its request is not cancelled when the client disconnects (see context.WithoutCancel), and its Writer
+// discards the response, which has already been sent. The post and background functions are not copied.
+//
+// Returns:
+//   - A new *Context, never recycled by the server.
+func (c *Context) Detach() *Context {
+	detached := &Context{
+		Writer:    &discardWriter{header: make(http.Header)},
+		Request:   c.Request.WithContext(context.WithoutCancel(c.requestContext())),
+		Params:    maps.Clone(c.Params),
+		Route:     c.Route,
+		Errors:    slices.Clone(c.Errors),
+		server:    c.server,
+		logger:    c.Logger(),
+		dataMutex: new(sync.RWMutex),
+	}
+
+	if c.dataMutex != nil {
+		c.dataMutex.RLock()
+		defer c.dataMutex.RUnlock()
+	}
+	detached.Data = maps.Clone(c.Data)
+
+	return detached
+}
+
+// discardWriter is the http.ResponseWriter of the Contexts returned by Detach, which discards the response.
+type discardWriter struct {
+	header http.Header // header holds the headers set through the Context, never sent.
+}
+
+// Header returns the headers of the discarded response.
+func (writer *discardWriter) Header() http.Header {
+	return writer.header
+}
+
+// WriteHeader discards the status code.
+func (writer *discardWriter) WriteHeader(code int) {
+}
+
+// Write discards the bytes.
+func (writer *discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// AbortWithError records an error, sends an error response and halts the execution of any subsequent
+// middleware or handlers.
+//
+// Parameters:
+//   - status: The HTTP status code of the error response.
+//   - err: The error, appended to c.Errors so that the post functions (logging, error reporting, ...) can read it.
+//
+// The response is sent by the server's error handler when one is set (see Server.SetErrorHandler).
+// Otherwise, it is a JSON object {"error": "..."} when the client prefers JSON (Accept header),
+// or the plain text message of err. No response is sent when err wraps ErrClientAborted, since
+// the client has disconnected: the error is only recorded.
+// It does not return any value.
+func (c *Context) AbortWithError(status int, err error) {
+	c.Errors = append(c.Errors, err)
+
+	if errors.Is(err, ErrClientAborted) {
+		c.Abort()
+		return
+	}
+
+	if (c.server == nil || c.server.errorHandler == nil) && c.Request != nil && prefersJSON(c.Request.Header.Get("Accept")) {
+		c.JSON(status, map[string]string{"error": err.Error()})
+	} else {
+		c.Error(status, err.Error())
+	}
+
+	c.Abort()
+}
+
+// PushError records a non-fatal error without altering the response, for the post functions
+// of the middlewares (logging, error reporting, ...) to pick it up from c.Errors.
+//
+// Parameters:
+//   - err: The error to record. A nil error is ignored.
+//
+// It does not return any value.
+func (c *Context) PushError(err error) {
+	if err != nil {
+		c.Errors = append(c.Errors, err)
+	}
+}
+
+// IsAborted reports whether the request processing has been halted by Abort.
+//
+// Returns:
+//   - true if Abort has been called on the Context, false otherwise.
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// Post appends a new handler function to the chain of functions executed after the route handler. This method should only be used by middlewares.
 //
 // Parameters:
-//   - function: A HandlerFunc representing the middleware or handler function to be added to the "PostFunc" chain.
+//   - function: A HandlerFunc representing the middleware or handler function to be added to the post chain.
 //
-// This function retrieves the existing "PostFunc" middleware chain from the Context's Data map,
-// appends the provided handler function to the chain, and updates the "PostFunc" entry in the Data map.
+// The post functions run in the order they are added, once the handler has returned, the request has been aborted
+// or a panic has been recovered.
 // It does not return any value.
 func (c *Context) Post(function HandlerFunc) {
-	postMw := c.Data["PostFunc"]
+	c.postFuncs = append(c.postFuncs, function)
+}
 
-	c.Data["PostFunc"] = append(postMw.([]HandlerFunc), function)
+// Background registers a function to be executed in a new goroutine once the request has been handled.
+//
+// Parameters:
+//   - fn: The function to execute, e.g. sending an email or updating statistics.
+//
+// Unlike the functions registered with Post, which run before the response is completed, the background
+// functions are started after the handler and the post functions have returned, and do not delay the response.
+// They must not use the Context nor the request's context, which are cancelled with the request: use
+// context.Background() instead. A panic in a background function is recovered and logged, and does not
+// crash the server. Server.BackgroundTasks tracks the running functions, so that a graceful shutdown can wait for them.
+// It does not return any value.
+func (c *Context) Background(fn func()) {
+	// The function may have captured the Context despite the recommendation above
+	c.retained = true
+	c.background = append(c.background, fn)
 }
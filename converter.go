@@ -0,0 +1,142 @@
+package feather
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ConverterFunc converts the raw string of a parameter, query value or form field into a value of a given type.
+type ConverterFunc func(raw string) (any, error)
+
+// converters holds the converters registered with RegisterConverter, indexed by the type they convert to.
+var (
+	convertersMutex sync.RWMutex
+	converters      = map[reflect.Type]ConverterFunc{
+		reflect.TypeFor[time.Duration](): convertDuration,
+		reflect.TypeFor[time.Time]():     convertTime,
+		reflect.TypeFor[net.IP]():        convertIP,
+		reflect.TypeFor[url.URL]():       convertURL,
+	}
+)
+
+// TimeLayouts are the layouts tried in order by the built-in time.Time converter.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+/*
+	RegisterConverter registers the function converting the raw strings into values of type t, used by BindParams,
+	BindQuery and BindAll for the fields of this type (and the pointers to it), e.g. for the identifiers of a domain:
+
+		feather.RegisterConverter(reflect.TypeFor[OrderID](), func(raw string) (any, error) {
+			return ParseOrderID(raw)
+		})
+
+	The registered converters take precedence over the encoding.TextUnmarshaler implementations and the built-in
+	conversions of the basic kinds. Converters are built in for time.Duration, time.Time (see TimeLayouts), net.IP
+	and url.URL; the other types implementing encoding.TextUnmarshaler, such as uuid.UUID or big.Rat for decimal
+	strings, are bound without a converter.
+
+	Registering a converter for a type already having one replaces it, including the built-in ones, and a nil
+	function removes it. Converters are meant to be registered at startup, before the server handles requests.
+
+	Parameters:
+		- t (reflect.Type): The type of the converted values.
+		- fn (ConverterFunc): The function converting a raw string. Its result must be assignable or convertible to t.
+
+	Returns:
+		- This function does not return any value.
+*/
+func RegisterConverter(t reflect.Type, fn ConverterFunc) {
+	convertersMutex.Lock()
+	defer convertersMutex.Unlock()
+
+	if fn == nil {
+		delete(converters, t)
+		return
+	}
+	converters[t] = fn
+}
+
+// hasConverter reports whether a converter is registered for t.
+func hasConverter(t reflect.Type) bool {
+	convertersMutex.RLock()
+	defer convertersMutex.RUnlock()
+
+	_, ok := converters[t]
+	return ok
+}
+
+// convert converts raw with the converter registered for the type of field, and reports whether one is registered.
+func convert(field reflect.Value, raw string) (bool, error) {
+	convertersMutex.RLock()
+	fn, ok := converters[field.Type()]
+	convertersMutex.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	converted, err := fn(raw)
+	if err != nil {
+		return true, err
+	}
+
+	value := reflect.ValueOf(converted)
+	switch {
+	case !value.IsValid():
+		field.SetZero()
+	case value.Type().AssignableTo(field.Type()):
+		field.Set(value)
+	case value.Type().ConvertibleTo(field.Type()):
+		field.Set(value.Convert(field.Type()))
+	default:
+		return true, fmt.Errorf("converter for %s returned a %s", field.Type(), value.Type())
+	}
+
+	return true, nil
+}
+
+// convertDuration is the built-in converter of time.Duration, e.g. "1h30m".
+func convertDuration(raw string) (any, error) {
+	return time.ParseDuration(raw)
+}
+
+// convertTime is the built-in converter of time.Time, trying each of TimeLayouts.
+func convertTime(raw string) (any, error) {
+	for _, layout := range TimeLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot parse %q as a time", raw)
+}
+
+// convertIP is the built-in converter of net.IP.
+func convertIP(raw string) (any, error) {
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", raw)
+	}
+
+	return ip, nil
+}
+
+// convertURL is the built-in converter of url.URL.
+func convertURL(raw string) (any, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return *parsed, nil
+}
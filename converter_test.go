@@ -0,0 +1,199 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// orderID is a domain identifier of the form "ORD-<number>", bound through a registered converter.
+type orderID int64
+
+// cents is an amount of money parsed from a decimal string (e.g. "12.50"), bound through a registered converter.
+type cents int64
+
+// registerTestConverter registers fn for the type T for the duration of the test.
+func registerTestConverter[T any](t *testing.T, fn ConverterFunc) {
+	t.Helper()
+
+	target := reflect.TypeFor[T]()
+
+	convertersMutex.RLock()
+	previous, existed := converters[target]
+	convertersMutex.RUnlock()
+
+	RegisterConverter(target, fn)
+	t.Cleanup(func() {
+		if existed {
+			RegisterConverter(target, previous)
+		} else {
+			RegisterConverter(target, nil)
+		}
+	})
+}
+
+func parseOrderID(raw string) (any, error) {
+	number, ok := strings.CutPrefix(raw, "ORD-")
+	if !ok {
+		return nil, fmt.Errorf("invalid order ID %q", raw)
+	}
+
+	id, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderID(id), nil
+}
+
+func parseCents(raw string) (any, error) {
+	units, fraction, _ := strings.Cut(raw, ".")
+	if len(fraction) > 2 {
+		return nil, fmt.Errorf("too many decimals in %q", raw)
+	}
+
+	value, err := strconv.ParseInt(units+(fraction + "00")[:2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// The converter returns an int64, converted to cents by the binding
+	return value, nil
+}
+
+func TestBindRegisteredConverters(t *testing.T) {
+	registerTestConverter[orderID](t, parseOrderID)
+	registerTestConverter[cents](t, parseCents)
+
+	var in struct {
+		Order    orderID       `param:"order"`
+		Parent   *orderID      `query:"parent"`
+		Related  []orderID     `query:"related"`
+		Amount   cents         `query:"amount"`
+		Timeout  time.Duration `query:"timeout"`
+		Since    time.Time     `query:"since"`
+		Client   net.IP        `query:"client"`
+		Callback url.URL       `query:"callback"`
+	}
+
+	c := bindContext("GET", "/?parent=ORD-7&related=ORD-1&related=ORD-2&amount=12.5&timeout=1m30s"+
+		"&since=2024-03-01&client=10.0.0.1&callback=https%3A%2F%2Fexample.com%2Fhook", "",
+		map[string]string{"order": "ORD-42"})
+	if err := c.BindAll(&in); err != nil {
+		t.Fatalf("BindAll: %v", err)
+	}
+
+	if in.Order != 42 {
+		t.Errorf("order = %d, want 42", in.Order)
+	}
+	if in.Parent == nil || *in.Parent != 7 {
+		t.Errorf("parent = %v, want 7", in.Parent)
+	}
+	if !reflect.DeepEqual(in.Related, []orderID{1, 2}) {
+		t.Errorf("related = %v, want [1 2]", in.Related)
+	}
+	if in.Amount != 1250 {
+		t.Errorf("amount = %d, want 1250", in.Amount)
+	}
+	if in.Timeout != 90*time.Second {
+		t.Errorf("timeout = %v, want 1m30s", in.Timeout)
+	}
+	if !in.Since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("since = %v", in.Since)
+	}
+	if !in.Client.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("client = %v", in.Client)
+	}
+	if in.Callback.Host != "example.com" || in.Callback.Path != "/hook" {
+		t.Errorf("callback = %v", in.Callback)
+	}
+}
+
+func TestBindConverterError(t *testing.T) {
+	registerTestConverter[orderID](t, parseOrderID)
+
+	var in struct {
+		Order orderID `param:"order"`
+	}
+
+	c := bindContext("GET", "/", "", map[string]string{"order": "42"})
+	err := c.BindParams(&in)
+
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || bindErr.Field != "Order" || bindErr.Source != "param" {
+		t.Fatalf("err = %v, want a *BindError for Order", err)
+	}
+}
+
+func TestBindConverterWrongType(t *testing.T) {
+	registerTestConverter[orderID](t, func(raw string) (any, error) {
+		return []string{raw}, nil
+	})
+
+	var in struct {
+		Order orderID `param:"order"`
+	}
+
+	c := bindContext("GET", "/", "", map[string]string{"order": "ORD-1"})
+	if err := c.BindParams(&in); err == nil {
+		t.Fatal("BindParams succeeded with a converter returning a []string")
+	}
+}
+
+func TestRegisterConverterOverrides(t *testing.T) {
+	// A registered converter replaces the built-in one of time.Duration, here accepting bare seconds
+	registerTestConverter[time.Duration](t, func(raw string) (any, error) {
+		seconds, err := strconv.Atoi(raw)
+		return time.Duration(seconds) * time.Second, err
+	})
+
+	// It also takes precedence over encoding.TextUnmarshaler
+	registerTestConverter[testUUID](t, func(raw string) (any, error) {
+		return testUUID{0: 1}, nil
+	})
+
+	var in struct {
+		Timeout time.Duration `query:"timeout"`
+		Account testUUID      `query:"account"`
+	}
+
+	c := bindContext("GET", "/?timeout=30&account=not-a-uuid", "", nil)
+	if err := c.BindQuery(&in); err != nil {
+		t.Fatalf("BindQuery: %v", err)
+	}
+
+	if in.Timeout != 30*time.Second {
+		t.Errorf("timeout = %v, want 30s", in.Timeout)
+	}
+	if in.Account != (testUUID{0: 1}) {
+		t.Errorf("account = %x", in.Account)
+	}
+}
+
+func TestRegisterConverterNilRemoves(t *testing.T) {
+	registerTestConverter[time.Duration](t, nil)
+
+	var in struct {
+		Timeout time.Duration `query:"timeout"`
+	}
+
+	// Without its converter, time.Duration is bound as an int64 of nanoseconds
+	c := bindContext("GET", "/?timeout=1500", "", nil)
+	if err := c.BindQuery(&in); err != nil {
+		t.Fatalf("BindQuery: %v", err)
+	}
+	if in.Timeout != 1500 {
+		t.Errorf("timeout = %d, want 1500", in.Timeout)
+	}
+
+	c = bindContext("GET", "/?timeout=1m", "", nil)
+	if err := c.BindQuery(&in); err == nil {
+		t.Error("BindQuery accepted \"1m\" without the converter of time.Duration")
+	}
+}
@@ -0,0 +1,229 @@
+package feather
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNoCookieCipher is returned by Context.SetEncryptedCookie and Context.EncryptedCookie when no CookieCipher
+// has been stored under CookieCipherKey, i.e. when the EncryptedCookie middleware does not run for the route.
+var ErrNoCookieCipher = errors.New("feather: no cookie cipher, add the EncryptedCookie middleware")
+
+// ErrInvalidCookie is returned by Context.EncryptedCookie when the value of the cookie cannot be decrypted,
+// e.g. because it has been tampered with or encrypted with another key.
+var ErrInvalidCookie = errors.New("feather: invalid encrypted cookie")
+
+// CookieCipherKey is the key of the Context's Data map under which the CookieCipher of the request
+// is stored by the EncryptedCookie middleware, see Context.SetEncryptedCookie.
+const CookieCipherKey = "cookie_cipher"
+
+// CookieCipher encrypts and decrypts the values of the cookies. It is implemented by the EncryptedCookie
+// middleware and used by Context.SetEncryptedCookie and Context.EncryptedCookie.
+type CookieCipher interface {
+	// Encrypt returns the encrypted value of the cookie name, safe to use as a cookie value.
+	Encrypt(name string, value string) (string, error)
+
+	// Decrypt returns the value of the cookie name from its encrypted value, or an error wrapping
+	// ErrInvalidCookie when it cannot be decrypted.
+	Decrypt(name string, encrypted string) (string, error)
+}
+
+// CookieOption configures the cookies created by NewCookie.
+type CookieOption func(cookie *http.Cookie)
+
+/*
+	NewCookie creates a cookie to send with Context.SetCookie, with safe defaults instead of the zero values
+	of an http.Cookie literal:
+
+		c.SetCookie(feather.NewCookie("session", token, feather.CookieMaxAge(24*time.Hour), feather.CookieSecure(true)))
+
+	By default, the cookie is sent for every path ("/"), is not readable from JavaScript (HttpOnly), and is not
+	sent with the cross-site requests except the top-level navigations (SameSite=Lax). It is a session cookie
+	unless CookieMaxAge is given. A cookie with SameSite=None is always marked Secure, as the browsers reject it otherwise.
+
+	Parameters:
+		- name (string): The name of the cookie.
+		- value (string): The value of the cookie.
+		- opts (...CookieOption): The options overriding the defaults, applied in order.
+
+	Returns:
+		- *http.Cookie: The cookie.
+*/
+func NewCookie(name string, value string, opts ...CookieOption) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	for _, opt := range opts {
+		opt(cookie)
+	}
+
+	if cookie.SameSite == http.SameSiteNoneMode {
+		cookie.Secure = true
+	}
+
+	return cookie
+}
+
+/*
+	CookieMaxAge sets the lifetime of the cookie (Max-Age attribute).
+
+	Parameters:
+		- maxAge (time.Duration): The lifetime of the cookie, rounded down to the second. A negative duration
+				deletes the cookie, and zero keeps it a session cookie.
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookieMaxAge(maxAge time.Duration) CookieOption {
+	return func(cookie *http.Cookie) {
+		if maxAge < 0 {
+			cookie.MaxAge = -1
+			return
+		}
+		cookie.MaxAge = int(maxAge / time.Second)
+	}
+}
+
+/*
+	CookiePath sets the path the cookie is sent for (Path attribute), "/" by default.
+
+	Parameters:
+		- path (string): The path of the cookie.
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookiePath(path string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Path = path
+	}
+}
+
+/*
+	CookieDomain sets the domain the cookie is sent to (Domain attribute), including its subdomains.
+	Without it, the cookie is only sent to the host which set it.
+
+	Parameters:
+		- domain (string): The domain of the cookie (e.g. "example.com").
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookieDomain(domain string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Domain = domain
+	}
+}
+
+/*
+	CookieSecure sets whether the cookie is only sent over HTTPS (Secure attribute), false by default.
+
+	Parameters:
+		- secure (bool): true to send the cookie over HTTPS only.
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookieSecure(secure bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Secure = secure
+	}
+}
+
+/*
+	CookieHTTPOnly sets whether the cookie is hidden from JavaScript (HttpOnly attribute), true by default.
+
+	Parameters:
+		- httpOnly (bool): false to let the scripts of the page read the cookie.
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookieHTTPOnly(httpOnly bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.HttpOnly = httpOnly
+	}
+}
+
+/*
+	CookieSameSite sets when the cookie is sent with the cross-site requests (SameSite attribute),
+	http.SameSiteLaxMode by default. http.SameSiteNoneMode also makes the cookie Secure.
+
+	Parameters:
+		- sameSite (http.SameSite): The SameSite mode of the cookie.
+
+	Returns:
+		- CookieOption: The option to pass to NewCookie.
+*/
+func CookieSameSite(sameSite http.SameSite) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.SameSite = sameSite
+	}
+}
+
+// cookieCipher returns the CookieCipher stored under CookieCipherKey by the EncryptedCookie middleware.
+func (c *Context) cookieCipher() (CookieCipher, error) {
+	cookieCipher, ok := c.Get(CookieCipherKey).(CookieCipher)
+	if !ok {
+		return nil, ErrNoCookieCipher
+	}
+
+	return cookieCipher, nil
+}
+
+// SetEncryptedCookie adds a Set-Cookie header to the HTTP response, with the value of the cookie encrypted
+// by the EncryptedCookie middleware, so that the client can neither read nor modify it.
+//
+// Parameters:
+//   - cookie: The cookie to send, e.g. created with NewCookie. Its value is the plain value; the cookie
+//     itself is not modified.
+//
+// Returns:
+//   - ErrNoCookieCipher if the EncryptedCookie middleware does not run for the route, or the error of
+//     the encryption. nil once the cookie is set.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	cookieCipher, err := c.cookieCipher()
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := cookieCipher.Encrypt(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	sealed := *cookie
+	sealed.Value = encrypted
+	c.SetCookie(&sealed)
+
+	return nil
+}
+
+// EncryptedCookie retrieves the plain value of a cookie set with SetEncryptedCookie.
+//
+// Parameters:
+//   - name: The name of the cookie to retrieve.
+//
+// Returns:
+//   - The decrypted value of the cookie.
+//   - http.ErrNoCookie if the request has no such cookie, ErrNoCookieCipher if the EncryptedCookie middleware
+//     does not run for the route, or an error wrapping ErrInvalidCookie if the value cannot be decrypted.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	cookieCipher, err := c.cookieCipher()
+	if err != nil {
+		return "", err
+	}
+
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	return cookieCipher.Decrypt(name, cookie.Value)
+}
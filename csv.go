@@ -0,0 +1,191 @@
+package feather
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+)
+
+// ErrTooManyRecords is the error returned by Context.CSVBody when the body has more records than CSVOptions.MaxRecords.
+var ErrTooManyRecords = errors.New("feather: CSV body has too many records")
+
+// utf8BOM is the byte order mark prepended to the CSV responses when CSVOptions.BOM is set.
+const utf8BOM = "\uFEFF"
+
+// CSVOptions configures the CSV helpers of the Context.
+type CSVOptions struct {
+	// Comma is the field delimiter. A comma is used when it is zero.
+	Comma rune
+
+	// BOM prepends a UTF-8 byte order mark to the responses, so that Excel detects their encoding.
+	BOM bool
+
+	// MaxRecords is the maximum number of records read by CSVBody, header included. Zero means no limit.
+	MaxRecords int
+}
+
+// csvOptions returns the first options of a variadic parameter, or the zero options.
+func csvOptions(options []CSVOptions) CSVOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+
+	return CSVOptions{}
+}
+
+// prepareCSV sets the headers of a CSV response: its Content-Type and, when filename is not empty,
+// a Content-Disposition header making the client download it.
+func (c *Context) prepareCSV(filename string) {
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if filename != "" {
+		c.Writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+}
+
+// newCSVWriter creates the CSV writer of a response, writing the byte order mark first when enabled.
+func newCSVWriter(w io.Writer, opts CSVOptions) (*csv.Writer, error) {
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+
+	return writer, nil
+}
+
+// CSV sends a CSV response with the specified HTTP status code.
+//
+// Parameters:
+//   - status: The HTTP status code to set for the response.
+//   - filename: The name of the file downloaded by the client (Content-Disposition: attachment).
+//     The response is displayed inline when it is empty.
+//   - header: The header record, omitted when nil.
+//   - rows: The records of the response. The fields containing the delimiter, quotes or newlines are quoted.
+//   - options: Optional settings, to change the delimiter or to prepend a UTF-8 byte order mark for Excel.
+//
+// This function sets the "Content-Type" header to "text/csv; charset=utf-8".
+// It does not return any value.
+func (c *Context) CSV(status int, filename string, header []string, rows [][]string, options ...CSVOptions) {
+	c.prepareCSV(filename)
+	c.Writer.WriteHeader(status)
+
+	writer, err := newCSVWriter(c.Writer, csvOptions(options))
+	if err != nil {
+		return
+	}
+
+	if header != nil {
+		writer.Write(header)
+	}
+	writer.WriteAll(rows)
+}
+
+// CSVStream streams a CSV response, for exports too large to be buffered.
+//
+// Parameters:
+//   - filename: The name of the file downloaded by the client, see CSV.
+//   - header: The header record, omitted when nil.
+//   - next: The function returning the next record. It returns false once the export is complete.
+//   - options: Optional settings, see CSV.
+//
+// The records are flushed to the client every 100 records and at the end of the export. The streaming
+// stops when the request's context is cancelled (the client disconnected).
+//
+// Returns:
+//   - An error if a record cannot be written, or the error of the request's context.
+func (c *Context) CSVStream(filename string, header []string, next func() ([]string, bool), options ...CSVOptions) error {
+	c.prepareCSV(filename)
+	c.prepareStream("text/csv; charset=utf-8")
+
+	writer, err := newCSVWriter(c.Writer, csvOptions(options))
+	if err != nil {
+		return err
+	}
+
+	if header != nil {
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for count := 1; ; count++ {
+		if err := c.Err(); err != nil {
+			return err
+		}
+
+		record, ok := next()
+		if !ok {
+			break
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		if count%100 == 0 {
+			writer.Flush()
+			c.flush()
+		}
+	}
+
+	writer.Flush()
+	c.flush()
+
+	return writer.Error()
+}
+
+// CSVBody reads the body of the request as CSV, calling fn with each record without buffering the whole body.
+//
+// Parameters:
+//   - fn: The function called with each record, in order, the header record included. It returns an error
+//     to stop the reading. The record is only valid until fn returns.
+//   - options: Optional settings, to change the delimiter or to limit the number of records.
+//
+// The quoted fields may contain the delimiter, quotes and newlines. The records may have different
+// numbers of fields, and a leading UTF-8 byte order mark is ignored.
+//
+// Returns:
+//   - nil once every record has been read.
+//   - A *csv.ParseError holding the line number for a malformed record, ErrTooManyRecords when the body
+//     has more records than MaxRecords, or the error returned by fn.
+func (c *Context) CSVBody(fn func(record []string) error, options ...CSVOptions) error {
+	opts := csvOptions(options)
+
+	// The byte order mark is skipped before parsing, a quoted first field would be malformed otherwise
+	body := bufio.NewReader(c.Request.Body)
+	if bom, err := body.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		body.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	for count := 0; ; count++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return c.bodyError(err)
+		}
+
+		if opts.MaxRecords > 0 && count >= opts.MaxRecords {
+			return ErrTooManyRecords
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
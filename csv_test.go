@@ -0,0 +1,207 @@
+package feather
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCSVQuoting(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/report", nil)
+
+	c.CSV(http.StatusOK, "report.csv", []string{"name", "comment"}, [][]string{
+		{"plain", "no quoting"},
+		{"comma", "a, b"},
+		{"quote", `say "hi"`},
+		{"newline", "line 1\nline 2"},
+		{"empty", ""},
+	})
+
+	want := "name,comment\n" +
+		"plain,no quoting\n" +
+		"comma,\"a, b\"\n" +
+		"quote,\"say \"\"hi\"\"\"\n" +
+		"newline,\"line 1\nline 2\"\n" +
+		"empty,\n"
+	if body := recorder.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	if got := recorder.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != `attachment; filename=report.csv` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestCSVOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		header   []string
+		options  CSVOptions
+		body     string
+		dispo    string
+	}{
+		{"bom", "", []string{"a", "b"}, CSVOptions{BOM: true}, "\uFEFFa,b\n1,2\n", ""},
+		{"semicolon", "", nil, CSVOptions{Comma: ';'}, "1;2\n", ""},
+		{"quoted filename", "my report.csv", nil, CSVOptions{}, "1,2\n", `attachment; filename="my report.csv"`},
+	}
+
+	for _, test := range tests {
+		c, recorder := NewTestContext("GET", "/", nil)
+		c.CSV(http.StatusCreated, test.filename, test.header, [][]string{{"1", "2"}}, test.options)
+
+		if recorder.Code != http.StatusCreated || recorder.Body.String() != test.body {
+			t.Errorf("%s: got %d with %q, want %q", test.name, recorder.Code, recorder.Body.String(), test.body)
+		}
+		if got := recorder.Header().Get("Content-Disposition"); got != test.dispo {
+			t.Errorf("%s: Content-Disposition = %q, want %q", test.name, got, test.dispo)
+		}
+	}
+}
+
+func TestCSVStreamFlushes(t *testing.T) {
+	const total = 250
+	produced := make(chan int, total)
+	i := 0
+
+	recorder, reader, done := serveStream(t.Context(), func(c *Context) {
+		err := c.CSVStream("export.csv", []string{"id", "note"}, func() ([]string, bool) {
+			if i == total {
+				return nil, false
+			}
+			i++
+			produced <- i
+			return []string{fmt.Sprint(i), "multi\nline"}, true
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The records reach the client by batches of 100, before the export is complete
+	records := csv.NewReader(reader)
+	header, err := records.Read()
+	if err != nil || strings.Join(header, ",") != "id,note" {
+		t.Fatalf("header = %q (%v)", header, err)
+	}
+	for n := 1; n <= total; n++ {
+		record, err := records.Read()
+		if err != nil {
+			t.Fatalf("record %d: %v", n, err)
+		}
+		if record[0] != fmt.Sprint(n) || record[1] != "multi\nline" {
+			t.Fatalf("record %d = %q", n, record)
+		}
+
+		// The first batch is delivered while records are still being produced
+		if n == 100 && len(produced) == total {
+			t.Error("the first batch was only delivered once the whole export was produced")
+		}
+	}
+	if _, err := records.Read(); err != io.EOF {
+		t.Errorf("extra data after the records: %v", err)
+	}
+	<-done
+
+	if got := recorder.header.Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := recorder.header.Get("Content-Disposition"); got != "attachment; filename=export.csv" {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestCSVStreamStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := NewTestContext("GET", "/", nil)
+	c.Request = c.Request.WithContext(ctx)
+
+	calls := 0
+	err := c.CSVStream("", nil, func() ([]string, bool) {
+		calls++
+		return []string{"x"}, true
+	})
+
+	if err == nil || calls != 0 {
+		t.Errorf("CSVStream = %v after %d records, want the context error", err, calls)
+	}
+}
+
+// csvBodyContext returns a Context whose request body is body.
+func csvBodyContext(body string) *Context {
+	c, _ := NewTestContext("POST", "/import", strings.NewReader(body))
+	return c
+}
+
+func TestCSVBody(t *testing.T) {
+	body := "\uFEFF\"name\",note\n" +
+		"alice,\"likes, commas\"\n" +
+		"bob,\"two\nlines\"\n" +
+		"carol,\"quoted \"\"word\"\"\",extra\n"
+
+	var records []string
+	err := csvBodyContext(body).CSVBody(func(record []string) error {
+		records = append(records, strings.Join(record, "|"))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("CSVBody = %v", err)
+	}
+
+	want := []string{"name|note", "alice|likes, commas", "bob|two\nlines", `carol|quoted "word"|extra`}
+	if strings.Join(records, "\n---\n") != strings.Join(want, "\n---\n") {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestCSVBodyOptions(t *testing.T) {
+	count := 0
+	err := csvBodyContext("a;b\n1;2\n").CSVBody(func(record []string) error {
+		if len(record) != 2 {
+			t.Errorf("record = %q, want 2 fields", record)
+		}
+		count++
+		return nil
+	}, CSVOptions{Comma: ';'})
+	if err != nil || count != 2 {
+		t.Errorf("CSVBody = %v after %d records", err, count)
+	}
+
+	count = 0
+	err = csvBodyContext("h\n1\n2\n3\n").CSVBody(func(record []string) error {
+		count++
+		return nil
+	}, CSVOptions{MaxRecords: 3})
+	if !errors.Is(err, ErrTooManyRecords) || count != 3 {
+		t.Errorf("CSVBody = %v after %d records, want ErrTooManyRecords after 3", err, count)
+	}
+}
+
+func TestCSVBodyErrors(t *testing.T) {
+	var parseErr *csv.ParseError
+	err := csvBodyContext("a,b\n1,\"unterminated\n").CSVBody(func(record []string) error { return nil })
+	if !errors.As(err, &parseErr) || parseErr.StartLine != 2 {
+		t.Errorf("CSVBody = %v, want a *csv.ParseError starting on line 2", err)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = csvBodyContext("1\n2\n3\n").CSVBody(func(record []string) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("CSVBody = %v after %d records, want the error of fn after 1", err, count)
+	}
+}
@@ -0,0 +1,53 @@
+package feather
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Preload adds a "Link: <href>; rel=preload; as=<as>" header to the response, so that the browser starts
+// fetching a resource of the page (stylesheet, script, font, ...) while parsing the response. Calling
+// EarlyHints afterwards also sends the accumulated preloads in a 103 Early Hints interim response.
+//
+// Parameters:
+//   - href: The URL of the resource (e.g. "/static/app.css").
+//   - as: The destination of the resource (e.g. "style", "script", "font" or "image"). The font preloads
+//     are marked "crossorigin", as the browsers always fetch the fonts in CORS mode.
+//
+// It does not return any value.
+func (c *Context) Preload(href string, as string) {
+	link := "<" + href + ">; rel=preload; as=" + as
+	if as == "font" {
+		link += "; crossorigin"
+	}
+
+	c.AddHeader("Link", link)
+}
+
+// EarlyHints sends a 103 Early Hints interim response carrying the Link headers of the response, so that
+// the browser starts fetching the resources of the page while the handler is still building the response:
+//
+//	c.Preload("/static/app.css", "style")
+//	c.EarlyHints()
+//	page := renderSlowPage() // the browser fetches app.css meanwhile
+//
+// The Link headers remain in the final response. EarlyHints must be called before the status code of the
+// response is written, and does nothing for the HTTP/1.0 requests, which do not support interim responses.
+// The middlewares buffering the whole response, such as Timeout, drop the interim response.
+//
+// Parameters:
+//   - links: The Link header values to add before sending the interim response, in addition to the ones
+//     added by Preload (e.g. "</static/app.js>; rel=preload; as=script").
+//
+// It does not return any value.
+func (c *Context) EarlyHints(links ...string) {
+	for _, link := range links {
+		c.AddHeader("Link", strings.TrimSpace(link))
+	}
+
+	if !c.Request.ProtoAtLeast(1, 1) || len(c.Writer.Header().Values("Link")) == 0 {
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusEarlyHints)
+}
@@ -0,0 +1,121 @@
+package feather
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rawExchange serves handler with an httptest.Server, sends the raw request on a TCP connection and returns
+// every byte of the answer, interim responses included.
+func rawExchange(t *testing.T, handler http.Handler, request string) string {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, request); err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read: %v (got %q)", err, answer)
+	}
+
+	return string(answer)
+}
+
+func TestEarlyHintsInterimResponse(t *testing.T) {
+	server := NewServer()
+	server.GET("/", func(c *Context) {
+		c.Preload("/static/app.css", "style")
+		c.EarlyHints("</static/app.js>; rel=preload; as=script")
+		c.String(http.StatusOK, "page")
+	})
+
+	answer := rawExchange(t, server, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+
+	interim, final, ok := strings.Cut(answer, "\r\n\r\n")
+	if !ok || !strings.HasPrefix(interim, "HTTP/1.1 103 Early Hints\r\n") {
+		t.Fatalf("answer does not start with a 103 response:\n%s", answer)
+	}
+	for _, link := range []string{
+		"Link: </static/app.css>; rel=preload; as=style",
+		"Link: </static/app.js>; rel=preload; as=script",
+	} {
+		if !strings.Contains(interim, link) {
+			t.Errorf("interim response misses %q:\n%s", link, interim)
+		}
+		if !strings.Contains(final, link) {
+			t.Errorf("final response misses %q:\n%s", link, final)
+		}
+	}
+
+	if !strings.HasPrefix(final, "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(final, "\r\n\r\npage") {
+		t.Errorf("final response:\n%s", final)
+	}
+}
+
+func TestEarlyHintsSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		links   []string
+	}{
+		{"HTTP/1.0 request", "GET / HTTP/1.0\r\nHost: example.com\r\n\r\n", []string{"</static/app.css>; rel=preload; as=style"}},
+		{"no Link header", "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := NewServer()
+			server.GET("/", func(c *Context) {
+				c.EarlyHints(test.links...)
+				c.String(http.StatusOK, "page")
+			})
+
+			answer := rawExchange(t, server, test.request)
+
+			if strings.Contains(answer, " 103 ") {
+				t.Errorf("answer contains an interim response:\n%s", answer)
+			}
+			if !strings.Contains(answer, " 200 OK\r\n") || !strings.HasSuffix(answer, "\r\n\r\npage") {
+				t.Errorf("final response:\n%s", answer)
+			}
+		})
+	}
+}
+
+func TestPreload(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/", nil)
+
+	c.Preload("/static/app.css", "style")
+	c.Preload("/static/inter.woff2", "font")
+	c.String(http.StatusOK, "page")
+
+	want := []string{
+		"</static/app.css>; rel=preload; as=style",
+		"</static/inter.woff2>; rel=preload; as=font; crossorigin",
+	}
+	links := recorder.Header().Values("Link")
+	if len(links) != len(want) {
+		t.Fatalf("Link = %q, want %q", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("Link[%d] = %q, want %q", i, links[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package feather
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrorPage is the data given to the error template configured with Server.SetErrorTemplate.
+type ErrorPage struct {
+	Status  int    // Status is the HTTP status code of the error.
+	Message string // Message is the message of the error, e.g. "not found".
+	Path    string // Path is the path of the request.
+}
+
+/*
+	SetNotFoundHandler configures the handler answering the requests matching no route.
+
+	The default handler negotiates the response with the Accept header of the request, see Context.NegotiateError.
+	The server's middlewares run before it, so that logging and metrics see the unmatched requests too:
+	a middleware can tell these requests apart by their nil Context.Route. The handler does not run when
+	a middleware aborts the request.
+
+	Parameters:
+		- handler (HandlerFunc): The handler of the unmatched requests. Its Context has no Route.
+				A nil handler restores the default one.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetNotFoundHandler(handler HandlerFunc) {
+	server.notFoundHandler = handler
+}
+
+/*
+	SetMethodNotAllowedHandler configures the handler answering the requests whose path matches a route
+	registered for other methods only. The "Allow" header listing these methods is set before it runs.
+
+	The default handler negotiates the response with the Accept header of the request, see Context.NegotiateError.
+	As for the unmatched requests (see SetNotFoundHandler), the server's middlewares run before it, and the
+	handler does not run when a middleware aborts the request.
+
+	Parameters:
+		- handler (HandlerFunc): The handler of the requests with a wrong method. Its Context has no Route.
+				A nil handler restores the default one.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetMethodNotAllowedHandler(handler HandlerFunc) {
+	server.methodNotAllowedHandler = handler
+}
+
+/*
+	SetErrorTemplate configures the HTML template of the error responses negotiated by Context.NegotiateError,
+	sent to the clients preferring HTML (browsers). The template receives an ErrorPage.
+
+	Parameters:
+		- files ([]string): The template files, parsed like the ones of Context.Template. The first file is executed.
+				An empty list disables the HTML error pages.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetErrorTemplate(files []string) {
+	server.errorTemplate = files
+}
+
+// NegotiateError sends an error response in the format preferred by the client, so that the JSON APIs
+// never receive HTML error pages.
+//
+// Parameters:
+//   - status: The HTTP status code of the response.
+//   - message: The message of the error, e.g. "not found".
+//
+// The response is a JSON object {"error": "...", "path": "..."} when the Accept header prefers
+// "application/json", the error template (see Server.SetErrorTemplate) when it accepts "text/html"
+// and a template is configured, and the plain text message otherwise. When the server has an error
+// handler (see Server.SetErrorHandler), the error is routed through it instead.
+// It does not return any value.
+func (c *Context) NegotiateError(status int, message string) {
+	if c.server != nil && c.server.errorHandler != nil {
+		c.Error(status, message)
+		return
+	}
+
+	accept := c.Request.Header.Get("Accept")
+
+	switch {
+	case prefersJSON(accept):
+		c.JSON(status, map[string]string{"error": message, "path": c.Request.URL.Path})
+	case c.server != nil && len(c.server.errorTemplate) > 0 && acceptsHTML(accept):
+		if err := c.renderErrorPage(status, message); err != nil {
+			http.Error(c.Writer, message, status)
+		}
+	default:
+		http.Error(c.Writer, message, status)
+	}
+}
+
+// renderErrorPage renders the error template of the server into the response.
+func (c *Context) renderErrorPage(status int, message string) error {
+	files := c.server.errorTemplate
+
+	tmpl, err := c.parseTemplate(files, nil)
+	if err == nil {
+		tmpl, err = tmpl.Clone()
+	}
+	if err != nil {
+		return err
+	}
+	tmpl.Funcs(template.FuncMap{"t": c.T})
+
+	var buffer bytes.Buffer
+	page := ErrorPage{Status: status, Message: message, Path: c.Request.URL.Path}
+	if err := tmpl.ExecuteTemplate(&buffer, filepath.Base(files[0]), page); err != nil {
+		return err
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(status)
+	c.Writer.Write(buffer.Bytes())
+	return nil
+}
+
+// acceptsHTML reports whether an Accept header accepts "text/html" explicitly.
+func acceptsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(mediaType, "text/html") {
+			continue
+		}
+
+		value, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		quality, err := strconv.ParseFloat(value, 64)
+		return err == nil && quality > 0
+	}
+
+	return false
+}
+
+// notFound answers a request matching no route, with the handler configured by SetNotFoundHandler,
+// after the server's middlewares.
+func (server *Server) notFound(writer http.ResponseWriter, reader *http.Request) {
+	server.serveWithoutRoute(writer, reader, func(context *Context) {
+		if server.notFoundHandler != nil {
+			server.notFoundHandler(context)
+			return
+		}
+		context.NegotiateError(http.StatusNotFound, "not found")
+	})
+}
+
+// methodNotAllowed answers a request whose path matches routes of other methods only, with the handler
+// configured by SetMethodNotAllowedHandler, after the server's middlewares.
+func (server *Server) methodNotAllowed(writer http.ResponseWriter, reader *http.Request, allowed []string) {
+	writer.Header().Set("Allow", strings.Join(allowed, ", "))
+	server.serveWithoutRoute(writer, reader, func(context *Context) {
+		if server.methodNotAllowedHandler != nil {
+			server.methodNotAllowedHandler(context)
+			return
+		}
+		context.NegotiateError(http.StatusMethodNotAllowed, "method not allowed")
+	})
+}
+
+// automaticOptions answers an OPTIONS request whose path matches routes of other methods only. The server's
+// middlewares run, so that the CORS middleware can answer the preflight requests, then a 204 response is sent
+// unless a middleware aborted the request. The Allow header lists the methods of the path, see AllowedMethods.
+func (server *Server) automaticOptions(writer http.ResponseWriter, reader *http.Request, allowed []string) {
+	writer.Header().Set("Allow", strings.Join(allowed, ", "))
+	server.serveWithoutRoute(writer, reader, func(context *Context) {
+		context.Status(http.StatusNoContent)
+	})
+}
+
+// serveWithoutRoute runs the server's middlewares on a request matching no route, then calls respond unless the
+// request has been aborted, and finally the post functions, as ServeHTTP does for the routed requests.
+func (server *Server) serveWithoutRoute(writer http.ResponseWriter, reader *http.Request, respond HandlerFunc) {
+	context := server.errorContext(writer, reader)
+
+	defer server.recoverPanic(context)
+
+	context.runMiddlewares(server.Middlewares)
+	if !context.aborted && context.Request.Context().Err() == nil {
+		respond(context)
+	}
+
+	server.complete(context)
+}
+
+// errorContext builds the Context of a request answered without a route.
+func (server *Server) errorContext(writer http.ResponseWriter, reader *http.Request) *Context {
+	context := &Context{
+		Writer:  writer,
+		Request: reader,
+		Params:  make(map[string]string),
+		Data:    make(map[string]any),
+		server:  server,
+
+		dataMutex: new(sync.RWMutex),
+	}
+	context.countBody()
+
+	return context
+}
+
+// recoverPanic recovers from a panic of a middleware, a handler or a post function, logs it with its stack trace
+// and answers with a negotiated 500. In development mode, the panic value and the stack trace are included in the
+// message. The post functions which have not run yet run afterwards, as they release the resources of the request
+// (e.g. the Logging middleware records the 500). The http.ErrAbortHandler panics, used to abort a response on
+// purpose, are propagated to net/http once the post functions have run.
+func (server *Server) recoverPanic(c *Context) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		server.complete(c)
+		panic(recovered)
+	}
+
+	stack := debug.Stack()
+	log.Printf("feather: panic serving %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, recovered, stack)
+
+	message := "internal server error"
+	if server.developmentMode {
+		message = fmt.Sprintf("panic: %v\n\n%s", recovered, stack)
+	}
+
+	c.NegotiateError(http.StatusInternalServerError, message)
+
+	// A post function panicking in turn is recovered the same way, and the next ones still run
+	defer server.recoverPanic(c)
+	server.complete(c)
+}
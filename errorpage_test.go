@@ -0,0 +1,114 @@
+package feather
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// errorPageServer returns a server with a GET route on /items and a panicking route on /panic. With template set,
+// the HTML error pages are rendered with a template printing the status, the message and the path.
+func errorPageServer(t *testing.T, template bool) *Server {
+	t.Helper()
+
+	server := NewServer()
+	server.GET("/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+	server.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	if template {
+		file := filepath.Join(t.TempDir(), "error.html")
+		if err := os.WriteFile(file, []byte("<h1>{{.Status}} {{.Message}}</h1><p>{{.Path}}</p>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		server.SetErrorTemplate([]string{file})
+	}
+
+	return server
+}
+
+func TestNegotiateError(t *testing.T) {
+	failures := []struct {
+		method  string
+		path    string
+		status  int
+		message string
+	}{
+		{"GET", "/missing", http.StatusNotFound, "not found"},
+		{"POST", "/items", http.StatusMethodNotAllowed, "method not allowed"},
+		{"GET", "/panic", http.StatusInternalServerError, "internal server error"},
+	}
+
+	tests := []struct {
+		name     string
+		accept   string
+		template bool
+		format   string
+	}{
+		{"JSON", "application/json", false, "json"},
+		{"JSON preferred over HTML", "text/html;q=0.5, application/json", true, "json"},
+		{"HTML with a template", "text/html,application/xhtml+xml,*/*;q=0.8", true, "html"},
+		{"HTML without a template", "text/html", false, "plain"},
+		{"any type", "*/*", true, "plain"},
+		{"plain text", "text/plain", true, "plain"},
+		{"no Accept header", "", true, "plain"},
+	}
+
+	for _, test := range tests {
+		for _, e := range failures {
+			t.Run(test.name+"/"+http.StatusText(e.status), func(t *testing.T) {
+				headers := map[string]string{}
+				if test.accept != "" {
+					headers["Accept"] = test.accept
+				}
+
+				recorder := errorPageServer(t, test.template).TestRequest(e.method, e.path, nil, headers)
+
+				if recorder.Code != e.status {
+					t.Fatalf("%s %s = %d, want %d", e.method, e.path, recorder.Code, e.status)
+				}
+
+				contentType, body := recorder.Header().Get("Content-Type"), recorder.Body.String()
+				switch test.format {
+				case "json":
+					var payload map[string]string
+					if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+						t.Fatalf("invalid JSON %q: %v", body, err)
+					}
+					if !strings.HasPrefix(contentType, "application/json") {
+						t.Errorf("Content-Type = %q, want application/json", contentType)
+					}
+					if len(payload) != 2 || payload["error"] != e.message || payload["path"] != e.path {
+						t.Errorf("body = %v, want the error %q and the path %q", payload, e.message, e.path)
+					}
+				case "html":
+					want := "<h1>" + strconv.Itoa(e.status) + " " + e.message + "</h1><p>" + e.path + "</p>"
+					if contentType != "text/html; charset=utf-8" {
+						t.Errorf("Content-Type = %q, want text/html", contentType)
+					}
+					if body != want {
+						t.Errorf("body = %q, want %q", body, want)
+					}
+				case "plain":
+					if contentType != "text/plain; charset=utf-8" {
+						t.Errorf("Content-Type = %q, want text/plain", contentType)
+					}
+					if body != e.message+"\n" {
+						t.Errorf("body = %q, want %q", body, e.message+"\n")
+					}
+				}
+
+				if e.status == http.StatusMethodNotAllowed && recorder.Header().Get("Allow") == "" {
+					t.Error("405 response without an Allow header")
+				}
+			})
+		}
+	}
+}
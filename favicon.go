@@ -0,0 +1,113 @@
+package feather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"time"
+)
+
+/*
+	Favicon serves "/favicon.ico" from a file of the server's filesystem.
+
+	The file is read once, kept in memory and served with long cache headers and an ETag, so conditional
+	requests receive a 304 Not Modified. The route is resolved before the normal route scan and the middlewares,
+	so browsers requesting the icon never reach a catch-all handler nor pollute the logs. Until Favicon or
+	FaviconFS is called, "/favicon.ico" is routed like any other path, e.g. to a Static route.
+
+	Parameters:
+		- filePath (string): The path of the icon file. If empty, "/favicon.ico" is answered with a cheap
+				404 Not Found instead, e.g. for the applications without an icon behind a catch-all handler.
+
+	Returns:
+		- error: An error if the file cannot be read.
+*/
+func (server *Server) Favicon(filePath string) error {
+	if filePath == "" {
+		server.earlyRoutes["/favicon.ico"] = http.NotFound
+		return nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	server.earlyRoutes["/favicon.ico"] = inMemoryFileHandler(path.Base(filePath), info.ModTime(), content, "public, max-age=31536000")
+	return nil
+}
+
+/*
+	FaviconFS serves "/favicon.ico" from a file of a filesystem, such as an embed.FS.
+
+	It behaves like Favicon, reading the file once from fsys.
+
+	Parameters:
+		- fsys (fs.FS): The filesystem holding the icon.
+		- name (string): The name of the icon file in fsys (e.g. "static/favicon.ico").
+
+	Returns:
+		- error: An error if the file cannot be read.
+*/
+func (server *Server) FaviconFS(fsys fs.FS, name string) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+
+	server.earlyRoutes["/favicon.ico"] = inMemoryFileHandler(path.Base(name), info.ModTime(), content, "public, max-age=31536000")
+	return nil
+}
+
+/*
+	RobotsTxt serves "/robots.txt" with the given content.
+
+	Like Favicon, the route is resolved before the normal route scan and the middlewares, and is served
+	with cache headers and an ETag.
+
+	Parameters:
+		- content (string): The content of the robots.txt file (e.g. "User-agent: *\nDisallow: /admin\n").
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) RobotsTxt(content string) {
+	server.earlyRoutes["/robots.txt"] = inMemoryFileHandler("robots.txt", time.Now(), []byte(content), "public, max-age=86400")
+}
+
+/*
+	inMemoryFileHandler returns an http.HandlerFunc serving content from memory, with the given Cache-Control header
+	and a strong ETag computed from the content. The Content-Type is guessed from the extension of name.
+
+	Conditional requests (If-None-Match, If-Modified-Since) and HEAD requests are handled by http.ServeContent.
+*/
+func inMemoryFileHandler(name string, modTime time.Time, content []byte, cacheControl string) http.HandlerFunc {
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	return func(writer http.ResponseWriter, reader *http.Request) {
+		if reader.Method != http.MethodGet && reader.Method != http.MethodHead {
+			writer.Header().Set("Allow", "GET, HEAD")
+			http.Error(writer, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Cache-Control", cacheControl)
+		writer.Header().Set("ETag", etag)
+		http.ServeContent(writer, reader, name, modTime, bytes.NewReader(content))
+	}
+}
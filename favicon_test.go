@@ -0,0 +1,144 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// newCatchAllServer returns a server whose catch-all route answers every GET request with 200.
+func newCatchAllServer() *Server {
+	server := NewServer()
+	server.GET("/*path", func(c *Context) {
+		c.String(http.StatusOK, "spa")
+	})
+
+	return server
+}
+
+func TestFaviconRoutedByDefault(t *testing.T) {
+	server := NewServer()
+	server.GET("/favicon.ico", func(c *Context) {
+		c.String(http.StatusOK, "route icon")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "route icon" {
+		t.Errorf("got %d %q, want the route registered for /favicon.ico", recorder.Code, recorder.Body.String())
+	}
+
+	// The icon served by a Static route is not shadowed either
+	server = NewServer()
+	server.StaticFS("/", fstest.MapFS{"favicon.ico": {Data: []byte("static icon")}})
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "static icon" {
+		t.Errorf("got %d %q, want the icon of the Static route", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestFaviconNotFoundWhenUnset(t *testing.T) {
+	server := newCatchAllServer()
+	if err := server.Favicon(""); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/app/home", nil))
+	if recorder.Body.String() != "spa" {
+		t.Fatalf("the catch-all route answered %q", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404 before the catch-all route", recorder.Code)
+	}
+
+	// Reset removes the early route, so the catch-all route answers again
+	server.Reset()
+	server.GET("/*path", func(c *Context) {
+		c.String(http.StatusOK, "spa")
+	})
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "spa" {
+		t.Errorf("after Reset: got %d %q, want the catch-all route", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestFaviconCacheHeaders(t *testing.T) {
+	iconPath := filepath.Join(t.TempDir(), "favicon.ico")
+	if err := os.WriteFile(iconPath, []byte("icon"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newCatchAllServer()
+	if err := server.Favicon(iconPath); err != nil {
+		t.Fatalf("Favicon: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "icon" {
+		t.Fatalf("got %d %q, want the icon", recorder.Code, recorder.Body.String())
+	}
+	if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "public, max-age=31536000" {
+		t.Errorf("Cache-Control = %q", cacheControl)
+	}
+
+	etag := recorder.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("the icon has no ETag")
+	}
+
+	request := httptest.NewRequest("GET", "/favicon.ico", nil)
+	request.Header.Set("If-None-Match", etag)
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("conditional request: got %d, want 304", recorder.Code)
+	}
+}
+
+func TestFaviconFS(t *testing.T) {
+	server := newCatchAllServer()
+	fsys := fstest.MapFS{"static/favicon.ico": {Data: []byte("embedded")}}
+	if err := server.FaviconFS(fsys, "static/favicon.ico"); err != nil {
+		t.Fatalf("FaviconFS: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Body.String() != "embedded" {
+		t.Errorf("got %q, want the embedded icon", recorder.Body.String())
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	server := newCatchAllServer()
+	server.RobotsTxt("User-agent: *\nDisallow: /admin\n")
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/robots.txt", nil))
+	if recorder.Body.String() != "User-agent: *\nDisallow: /admin\n" {
+		t.Errorf("got %q", recorder.Body.String())
+	}
+	if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "public, max-age=86400" {
+		t.Errorf("Cache-Control = %q", cacheControl)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("POST", "/robots.txt", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got %d, want 405", recorder.Code)
+	}
+}
@@ -1,36 +1,217 @@
 package feather
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"path"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const VERSION string = "0.2.1"
 
+// DefaultShutdownTimeout is the maximum duration a graceful shutdown waits for the in-flight requests and
+// background tasks when Server.ShutdownTimeout is not set.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultMaxURILength is the default maximum length, in bytes, of the request target (path and query string)
+// of the requests, see Server.MaxURILength.
+const DefaultMaxURILength = 16 << 10
+
+// DefaultMaxHeaderValueLength is the default maximum length, in bytes, of each header value of the requests,
+// see Server.MaxHeaderValueLength.
+const DefaultMaxHeaderValueLength = 64 << 10
+
+// DefaultMaxFileSize is the default maximum size, in bytes, of the files read by Context.FormFile.
+const DefaultMaxFileSize int64 = 10 << 20
+
 // HandlerFunc represents a function that handles an HTTP request.
 // It takes a single parameter:
 //   - c: A pointer to the Context, which contains information about the HTTP request, response, and other data.
 type HandlerFunc func(c *Context)
 
+// PreRoutingFunc represents a function executed before route matching, see Server.PreRouting.
+// It takes the raw response writer and request, which it can mutate (e.g. rewriting reader.URL.Path),
+// and returns true to stop the processing of the request once it has written the response itself.
+type PreRoutingFunc func(writer http.ResponseWriter, reader *http.Request) (stop bool)
+
+// standardMethods is the set of the HTTP methods defined by RFC 7231 and RFC 5789 (PATCH).
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPatch:   true,
+}
+
 type Route struct {
+	Pattern string				// Pattern is the URL pattern the route was registered with (e.g. "/user/:id").
+	Name string					// Name is the optional name of the route, see RouteBuilder.Name.
 	Regex *regexp.Regexp		// Regex is the compiled regular expression used to match the incoming request URL.
 	Params []string 			// Params is a list of parameter names extracted from the dynamic segments of the route.
 	Handler HandlerFunc 		// Handler is the function that will be executed when the route is matched.
+	Meta map[string]any			// Meta holds arbitrary metadata attached at registration time, see RouteBuilder.Meta.
+	Headers http.Header			// Headers holds the default response headers of the route, see RouteBuilder.Header.
+	Middlewares []HandlerFunc	// Middlewares holds the middlewares of the route, run after the server's ones, see RouteBuilder.Use.
+	Doc RouteDoc				// Doc holds the documentation of the route, e.g. for OpenAPI generators, see RouteBuilder.Doc.
+	Priority int				// Priority orders the routes matching the same paths, the higher first, see RouteOptions.
+
+	group *Group				// group is the Group the route was registered with, nil for the routes registered on the Server.
+	handlerName string			// handlerName is the name of the handler in the definition the route was registered from, see RegisterRoutes.
+	middlewareNames []string	// middlewareNames are the names of the first middlewares in the definition of the route, see RegisterRoutes.
+}
+
+// isStatic reports whether the route has no parameter, i.e. matches a single path.
+func (route *Route) isStatic() bool {
+	return len(route.Params) == 0
+}
+
+// matchesBefore reports whether the route must be matched before other: the routes with a higher priority
+// come first, then, for the same priority, the static routes come before the dynamic ones.
+func (route *Route) matchesBefore(other *Route) bool {
+	if route.Priority != other.Priority {
+		return route.Priority > other.Priority
+	}
+
+	return route.isStatic() && !other.isStatic()
+}
+
+// applyHeaders sets the default response headers of the route and of its group, before the middlewares
+// and the handler run. The headers of the route override the ones of its group.
+func (route *Route) applyHeaders(header http.Header) {
+	if route.group != nil {
+		route.group.applyHeaders(header)
+	}
+
+	for name, values := range route.Headers {
+		header[name] = append([]string(nil), values...)
+	}
 }
 
 type Server struct {
 	// routes is a map where the key is the HTTP method (e.g., "GET", "POST") and the value is a slice of Route.
 	// Each Route contains the compiled regular expression for matching the URL, the parameter names extracted from the route,
 	// and the handler function to execute when the route is matched.
-	Routes map[string][]Route
+	// A route registered for several methods is shared by all of them.
+	// Routes can be registered while the server handles requests: the map is protected by a mutex, so it must
+	// not be modified directly once the server is started.
+	Routes map[string][]*Route
+
+	// inFlight is the number of requests being handled by ServeHTTP, see InFlight.
+	inFlight atomic.Int64
+
+	// onStateChange is called when a connection changes state, see OnStateChange.
+	onStateChange func(conn net.Conn, state http.ConnState)
+
+	// contextPool recycles the Contexts of the routed requests when contextPooling is set, see acquireContext.
+	contextPool sync.Pool
+
+	// contextPooling enables the recycling of the Contexts, see SetContextPooling.
+	contextPooling bool
+
+	// shutdownHooks holds the functions registered by OnShutdown, run at the end of a graceful shutdown.
+	shutdownHooks []func()
+
+	// routesMutex protects the Routes map, written by Handle and read by ServeHTTP.
+	routesMutex sync.RWMutex
 
 	// middlewares is a slice of HandlerFunc that represents middleware functions.
 	// These functions are executed in the order they are added, before the final route handler is called.
 	Middlewares []HandlerFunc
+
+	// ShutdownTimeout is the maximum duration the graceful shutdowns (ListenWithShutdown, ListenGraceful, Run) wait for
+	// the in-flight requests and the background tasks. Once exceeded, the remaining connections are closed forcibly.
+	// DefaultShutdownTimeout is used when it is zero.
+	ShutdownTimeout time.Duration
+
+	// MaxURILength is the maximum length, in bytes, of the request target (path and query string). The longer
+	// requests are rejected with a 414 URI Too Long status before the pre-routing hooks, the routing and the
+	// middlewares, so that they never reach the route matching or the logs. NewServer sets it to
+	// DefaultMaxURILength (16KB); zero means unlimited.
+	MaxURILength int
+
+	// MaxHeaderValueLength is the maximum length, in bytes, of each header value of the requests, Host included.
+	// The requests with a longer value are rejected with a 431 Request Header Fields Too Large status, like the ones
+	// exceeding MaxURILength. NewServer sets it to DefaultMaxHeaderValueLength (64KB); zero means unlimited.
+	MaxHeaderValueLength int
+
+	// BaseLogger is the logger from which Context.Logger derives the logger of each request.
+	// slog.Default() is used when it is nil.
+	BaseLogger *slog.Logger
+
+	// BackgroundTasks tracks the functions registered with Context.Background which are still running.
+	// A graceful shutdown can call BackgroundTasks.Wait() once the HTTP server is stopped.
+	BackgroundTasks sync.WaitGroup
+
+	// developmentMode enables the behaviours meant for local development: stack traces in error responses,
+	// template hot-reload, verbose logging and pretty-printed JSON. It is disabled (production mode) by default.
+	developmentMode bool
+
+	// cleanPath enables the normalization of the request path before the route matching, see SetCleanPath.
+	cleanPath bool
+
+	// allowCustomMethods allows Handle to register routes for non-standard HTTP methods, see SetAllowCustomMethods.
+	allowCustomMethods bool
+
+	// userIDKey is the key of the Context's Data map under which the authentication middlewares store the user ID.
+	// It defaults to "user_id", see SetUserIDKey.
+	userIDKey string
+
+	// preRouting is a slice of PreRoutingFunc executed, in the order they are added, before the route matching.
+	preRouting []PreRoutingFunc
+
+	// formConfig holds the limits applied when parsing the forms, see SetFormConfig.
+	formConfig FormConfig
+
+	// earlyRoutes maps an exact path (e.g. "/favicon.ico") to a handler served before the route scan and the middlewares.
+	// It is filled by Favicon, FaviconFS and RobotsTxt.
+	earlyRoutes map[string]http.HandlerFunc
+
+	// errorHandler intercepts the calls to Context.Error when it is set, see SetErrorHandler.
+	errorHandler func(c *Context, status int, err string)
+
+	// singletons holds the dependencies registered with Provide, indexed by key.
+	singletons map[any]any
+
+	// factories holds the factories of the per-request dependencies registered with ProvideRequest, indexed by key.
+	factories map[any]func(c *Context) any
+
+	// listener is the listener opened by ListenGraceful, passed to the new process by Fork.
+	listener net.Listener
+
+	// middlewareTags holds the tag of each middleware of Middlewares, empty for the untagged ones, see AddMiddlewareTagged.
+	middlewareTags []string
+
+	// notFoundHandler answers the requests matching no route when it is set, see SetNotFoundHandler.
+	notFoundHandler HandlerFunc
+
+	// methodNotAllowedHandler answers the requests matching routes of other methods only when it is set, see SetMethodNotAllowedHandler.
+	methodNotAllowedHandler HandlerFunc
+
+	// errorTemplate holds the files of the HTML template of the negotiated error responses, see SetErrorTemplate.
+	errorTemplate []string
+
+	// routeErrors holds the errors of the routes which could not be registered, see Err.
+	routeErrors []error
+
+	// templates caches the templates parsed by Context.Template so that template files are only read once in production mode.
+	templates *templateCache
 }
 
 // NewServer creates and initializes a new instance of the Server struct.
@@ -42,11 +223,67 @@ type Server struct {
 //   - *Server: A pointer to the newly created Server instance.
 func NewServer() *Server {
 	return &Server{
-		Routes: make(map[string][]Route),
+		Routes: make(map[string][]*Route),
 		Middlewares: make([]HandlerFunc, 0),
+		userIDKey: "user_id",
+		earlyRoutes: make(map[string]http.HandlerFunc),
+		templates: newTemplateCache(),
+		MaxURILength: DefaultMaxURILength,
+		MaxHeaderValueLength: DefaultMaxHeaderValueLength,
 	}
 }
 
+// Reset removes every route and middleware of the server, keeping its configuration, e.g. between the cases
+// of a table-driven test.
+//
+// The routes registered by Favicon, FaviconFS and RobotsTxt and the registration errors reported by Err
+// are removed as well. The settings configured with the Set* methods, the pre-routing hooks and the
+// dependencies are preserved: use ResetAll to restore them too.
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) Reset() {
+	server.routesMutex.Lock()
+	server.Routes = make(map[string][]*Route)
+	server.routesMutex.Unlock()
+
+	server.Middlewares = make([]HandlerFunc, 0)
+	server.middlewareTags = nil
+	server.routeErrors = nil
+	server.earlyRoutes = make(map[string]http.HandlerFunc)
+}
+
+// ResetAll restores the server to the state returned by NewServer: it removes the routes and middlewares
+// like Reset, and restores the default configuration (Set* methods, pre-routing hooks, dependencies,
+// BaseLogger and template cache).
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) ResetAll() {
+	server.Reset()
+
+	server.BaseLogger = nil
+	server.developmentMode = false
+	server.cleanPath = false
+	server.allowCustomMethods = false
+	server.contextPooling = false
+	server.userIDKey = "user_id"
+	server.preRouting = nil
+	server.formConfig = FormConfig{}
+	server.errorHandler = nil
+	server.notFoundHandler = nil
+	server.methodNotAllowedHandler = nil
+	server.errorTemplate = nil
+	server.onStateChange = nil
+	server.shutdownHooks = nil
+	server.ShutdownTimeout = 0
+	server.MaxURILength = DefaultMaxURILength
+	server.MaxHeaderValueLength = DefaultMaxHeaderValueLength
+	server.singletons = nil
+	server.factories = nil
+	server.templates.clear()
+}
+
 // AddMiddleware appends one or more middleware functions to the server's middleware stack.
 //
 // Middleware functions are executed in the order they are added, before the final route handler is called.
@@ -64,6 +301,273 @@ func (server *Server) AddMiddleware(middlewares ...HandlerFunc) {
 	}
 }
 
+// UseFor appends one or more middleware functions to the server's middleware stack, run only for the requests
+// using one of the given HTTP methods, e.g. a CSRF check for the write methods only.
+//
+// The middlewares keep their position in the stack: they run after the middlewares added before them, and before
+// the middlewares added after them and the middlewares of the routes. For the other methods, they are skipped.
+//
+// Parameters:
+//   - methods: The HTTP methods of the requests the middlewares run for (e.g. "POST", "PUT"), case-insensitive.
+//   - middlewares: The middleware functions to append.
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) UseFor(methods []string, middlewares ...HandlerFunc) {
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[strings.ToUpper(method)] = true
+	}
+
+	for _, mw := range middlewares {
+		server.AddMiddleware(func(c *Context) {
+			if allowed[c.Request.Method] {
+				mw(c)
+			}
+		})
+	}
+}
+
+// AddMiddlewareTagged appends a middleware function to the server's middleware stack under a tag,
+// so that it can be removed later with RemoveMiddlewareByTag, e.g. in the teardown of a test.
+//
+// Parameters:
+//   - tag: The tag of the middleware. Several middlewares can share the same tag.
+//   - mw: The middleware function to append.
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) AddMiddlewareTagged(tag string, mw HandlerFunc) {
+	server.syncMiddlewareTags()
+
+	server.Middlewares = append(server.Middlewares, mw)
+	server.middlewareTags = append(server.middlewareTags, tag)
+}
+
+// RemoveMiddleware removes the middleware function at the given position of the server's middleware stack.
+//
+// Parameters:
+//   - index: The 0-based position of the middleware, in the order they were added.
+//     An index out of range is ignored.
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) RemoveMiddleware(index int) {
+	if index < 0 || index >= len(server.Middlewares) {
+		return
+	}
+
+	server.syncMiddlewareTags()
+
+	server.Middlewares = slices.Delete(server.Middlewares, index, index+1)
+	server.middlewareTags = slices.Delete(server.middlewareTags, index, index+1)
+}
+
+// RemoveMiddlewareByTag removes every middleware function added with AddMiddlewareTagged under the given tag.
+//
+// Parameters:
+//   - tag: The tag of the middlewares to remove. An empty tag is ignored, so that the untagged middlewares are kept.
+//
+// Returns:
+//   - This function does not return any value.
+func (server *Server) RemoveMiddlewareByTag(tag string) {
+	if tag == "" {
+		return
+	}
+
+	server.syncMiddlewareTags()
+
+	for i := len(server.Middlewares) - 1; i >= 0; i-- {
+		if server.middlewareTags[i] == tag {
+			server.RemoveMiddleware(i)
+		}
+	}
+}
+
+// syncMiddlewareTags aligns the tags with the middleware stack, the middlewares added without a tag
+// (with AddMiddleware or by appending to Middlewares directly) having an empty tag.
+func (server *Server) syncMiddlewareTags() {
+	for len(server.middlewareTags) < len(server.Middlewares) {
+		server.middlewareTags = append(server.middlewareTags, "")
+	}
+	server.middlewareTags = server.middlewareTags[:len(server.Middlewares)]
+}
+
+/*
+	SetDevelopmentMode enables or disables the development mode of the server.
+
+	When enabled, the server and the bundled middlewares favour debuggability over performance and opacity:
+	a recovery middleware may include the stack trace in the response body (see Context.IsDevelopmentMode),
+	templates are re-parsed on every request, the logger prints verbose request lines and JSON responses
+	are pretty-printed.
+	Production mode (false) is the default and should be used for any deployed application.
+
+	Parameters:
+		- enabled (bool): true to enable the development mode, false to go back to production mode.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetDevelopmentMode(enabled bool) {
+	server.developmentMode = enabled
+}
+
+/*
+	IsDevelopmentMode reports whether the server is running in development mode.
+
+	Returns:
+		- bool: true if SetDevelopmentMode(true) has been called, false otherwise.
+*/
+func (server *Server) IsDevelopmentMode() bool {
+	return server.developmentMode
+}
+
+/*
+	SetCleanPath enables or disables the normalization of the request paths before the route matching.
+
+	When enabled, duplicate slashes are collapsed and the dot segments are resolved ("/a//b" and "/a/./b" become "/a/b",
+	"/a/../admin" becomes "/admin"), keeping any trailing slash. Requests made with a safe method (GET, HEAD) to a
+	non-canonical path are redirected (301) to the canonical path, keeping the query string. Requests made with other
+	methods are matched against the canonical path directly, as redirecting them could lose their body.
+	Static always cleans the requested file path, whether this option is enabled or not.
+
+	Parameters:
+		- enabled (bool): true to normalize the request paths, false to match them as they are (the default).
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetCleanPath(enabled bool) {
+	server.cleanPath = enabled
+}
+
+/*
+	SetContextPooling enables or disables the recycling of the Contexts of the routed requests, which saves
+	most of the allocations of a request for the high-throughput services.
+
+	Once a request has been handled, its Context (with its Data and Params maps and its counted body) is reused
+	for another request. The handlers must then not use the Context after they return, except through the copies
+	of Detach: a goroutine started by the handler must call Context.Retain first. The Contexts handed to
+	WithValue and Background, and the ones of the requests cut off by the Timeout middleware, are retained
+	automatically. Pooling is disabled by default.
+
+	Parameters:
+		- enabled (bool): true to recycle the Contexts, false to allocate a new Context per request (the default).
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetContextPooling(enabled bool) {
+	server.contextPooling = enabled
+}
+
+/*
+	SetAllowCustomMethods allows or forbids the registration of routes for non-standard HTTP methods.
+
+	By default, Handle rejects the methods not defined by RFC 7231 and RFC 5789 (e.g. a typo such as "GETT"),
+	since such routes could never match a regular request. Enable this option for extension methods such as
+	WebDAV's "PROPFIND".
+
+	Parameters:
+		- allowed (bool): true to accept any method, false to only accept the standard ones.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetAllowCustomMethods(allowed bool) {
+	server.allowCustomMethods = allowed
+}
+
+/*
+	SetUserIDKey configures the key of the Context's Data map holding the ID of the authenticated user.
+
+	Authentication middlewares (JWT, Basic, sessions, ...) store the user ID with Context.SetUserID and handlers
+	read it with Context.UserID, so that handlers do not depend on the key used by a specific middleware.
+
+	Parameters:
+		- key (string): The key holding the user ID. The default key is "user_id".
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetUserIDKey(key string) {
+	server.userIDKey = key
+}
+
+/*
+	SetErrorHandler configures a function intercepting every call to Context.Error, to handle the errors of
+	all the routes in a central place: logging, reporting to an error-tracking service or formatting the
+	error responses consistently.
+
+	The handler is responsible for writing the response. A call to Context.Error from inside the handler
+	sends the default plain text response instead of calling the handler again.
+
+	Parameters:
+		- handler (func(c *Context, status int, err string)): The function receiving the Context, the status code
+				and the message of the error. A nil handler restores the default behaviour (http.Error).
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetErrorHandler(handler func(c *Context, status int, err string)) {
+	server.errorHandler = handler
+}
+
+/*
+	SetMaxFileSize configures the maximum size of the uploaded files read into memory by Context.FormFile.
+	It is a shortcut for setting the MaxFileSize field of the FormConfig, see SetFormConfig.
+
+	Parameters:
+		- size (int64): The maximum size in bytes. The default is DefaultMaxFileSize (10 MB).
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetMaxFileSize(size int64) {
+	server.formConfig.MaxFileSize = size
+}
+
+/*
+	ClearTemplateCache removes every parsed template from the server's template cache.
+
+	The next call to Context.Template for each template will read and parse its files from disk again.
+	This is useful to pick up template changes in production mode without restarting the server.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) ClearTemplateCache() {
+	server.templates.clear()
+}
+
+/*
+	PreRouting appends one or more hooks executed at the very beginning of ServeHTTP, before the route matching.
+
+	Unlike middlewares, which run once the route has been selected, pre-routing hooks can change which route
+	matches: method override, path rewriting behind an ingress, request normalization, or terminating the request
+	early (e.g. maintenance page). A request goes through the following steps, in order:
+		1. the pre-routing hooks, in the order they are added;
+		2. the normalization of the path when SetCleanPath is enabled, redirecting the GET and HEAD requests
+				whose path is not clean, so a hook sees the path as sent by the client;
+		3. the routes registered by Favicon and RobotsTxt;
+		4. the route matching, using the (possibly rewritten) request method, host and URL path;
+		5. the middlewares of the server, then the ones of the route (see RouteBuilder.Use), in the order they are added;
+		6. the route's handler;
+		7. the functions registered with Context.Post.
+	When a hook returns true, the request stops there: the following hooks, the routing, the middlewares and the
+	post functions are skipped, so the hook must have written the response itself. The requests exceeding
+	MaxURILength or MaxHeaderValueLength are rejected before the first step.
+
+	Parameters:
+		- hooks (...PreRoutingFunc): The hooks to add. Each hook receives the raw http.ResponseWriter and *http.Request.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) PreRouting(hooks ...PreRoutingFunc) {
+	server.preRouting = append(server.preRouting, hooks...)
+}
+
 /*
 	Handle registers a new route with the server, associating it with a specific URL pattern, handler function, 
 	and one or more HTTP methods.
@@ -76,17 +580,169 @@ func (server *Server) AddMiddleware(middlewares ...HandlerFunc) {
 					and optional custom regular expressions for dynamic segments.
 			- handler (HandlerFunc): The function to execute when the route is matched. It receives a pointer 
 					to the Context, which contains request and response data.
-			- methods ([]string): A slice of HTTP methods (e.g., "GET", "POST") for which this route should be registered. 
-					If no methods are provided, the default is ["GET"].
+			- methods (...string): The HTTP methods (e.g., "GET", "POST") for which this route should be registered.
+					Methods are case-insensitive and must be standard methods (RFC 7231 and RFC 5789), unless
+					SetAllowCustomMethods(true) has been called. If no methods are provided, the default is "GET".
+
+	Returns:
+			- *RouteBuilder: A builder to attach metadata to the registered route.
+			- error: A *RouteError if the route cannot be registered (empty pattern or not starting with "/",
+					non-standard method, invalid regular expression). The route is then not registered and the
+					builder holds the same error.
+*/
+func (server *Server) Handle(pattern string, handler HandlerFunc, methods ...string) (*RouteBuilder, error) {
+	return server.HandleWithOptions(pattern, handler, RouteOptions{Methods: methods})
+}
+
+// RouteOptions configures a route registered with HandleWithOptions.
+type RouteOptions struct {
+	// Methods are the HTTP methods of the route, see Handle. "GET" is used when it is empty.
+	Methods []string
+
+	// Priority orders the routes which could match the same paths (e.g. "/:id|[0-9]+" and "/:category"):
+	// the routes with a higher priority are matched first, regardless of their registration order.
+	// For the same priority, the static routes (without parameters) are matched before the dynamic ones,
+	// then the routes are matched in their registration order. The default priority is 0.
+	Priority int
+}
+
+/*
+	HandleWithOptions registers a new route like Handle, with the options of RouteOptions such as its priority.
+
+	Parameters:
+			- pattern (string): The URL pattern for the route, see Handle.
+			- handler (HandlerFunc): The function to execute when the route is matched.
+			- opts (RouteOptions): The options of the route: its methods and priority.
 
 	Returns:
-			- This function does not return any value.
+			- *RouteBuilder: A builder to attach metadata to the registered route.
+			- error: A *RouteError if the route cannot be registered, see Handle.
 */
-func (server *Server) Handle(pattern string, handler HandlerFunc, methods []string) {
+func (server *Server) HandleWithOptions(pattern string, handler HandlerFunc, opts RouteOptions) (*RouteBuilder, error) {
+	return server.addRoute(pattern, handler, opts, nil)
+}
+
+// addRoute registers a route of the given group, nil for a route registered on the server. The route is fully
+// built before it is inserted in the method tables, since the requests read it without locking routesMutex.
+func (server *Server) addRoute(pattern string, handler HandlerFunc, opts RouteOptions, group *Group) (*RouteBuilder, error) {
+	methods := opts.Methods
+	if pattern == "" {
+		return server.routeError(pattern, fmt.Errorf("%w: the pattern is empty, use \"/\" for the root", ErrInvalidPattern))
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return server.routeError(pattern, fmt.Errorf("%w: the pattern must start with \"/\"", ErrInvalidPattern))
+	}
+
 	if len(methods) == 0 {
 		methods = []string{"GET"}
 	}
 
+	normalized := make([]string, len(methods))
+	for i, method := range methods {
+		normalized[i] = strings.ToUpper(method)
+
+		if !server.allowCustomMethods && !standardMethods[normalized[i]] {
+			return server.routeError(pattern, fmt.Errorf("%w: %q (use SetAllowCustomMethods(true) to register custom methods)", ErrNonStandardMethod, method))
+		}
+	}
+	methods = normalized
+
+	regexPattern, paramsList := parsePattern(pattern)
+	re, err := regexp.Compile(regexPattern)
+
+	if err != nil {
+		return server.routeError(pattern, fmt.Errorf("%w: %v", ErrInvalidPattern, err))
+	}
+
+	route := &Route{
+		Pattern: pattern,
+		Regex: re,
+		Params: paramsList,
+		Handler: handler,
+		Meta: make(map[string]any),
+		Headers: make(http.Header),
+		Priority: opts.Priority,
+		group: group,
+	}
+
+	server.routesMutex.Lock()
+	defer server.routesMutex.Unlock()
+
+	for _, method := range methods {
+		// Insert the route after the routes matched before it, keeping the registration order otherwise
+		routes := server.Routes[method]
+		index := len(routes)
+		for index > 0 && route.matchesBefore(routes[index-1]) {
+			index--
+		}
+
+		// The slice is copied on write, so the requests iterating the previous one are not affected
+		server.Routes[method] = slices.Insert(slices.Clip(routes), index, route)
+	}
+
+	return &RouteBuilder{route: route, server: server}, nil
+}
+
+/*
+	MustHandle registers a new route like Handle, and panics if the route cannot be registered.
+
+	It suits the applications declaring their routes in code, for which an invalid route is a programming error
+	to detect at startup.
+
+	Parameters:
+			- pattern (string): The URL pattern for the route, see Handle.
+			- handler (HandlerFunc): The function to execute when the route is matched.
+			- methods (...string): The HTTP methods for which this route should be registered, see Handle.
+
+	Returns:
+			- *RouteBuilder: A builder to attach metadata to the registered route.
+*/
+func (server *Server) MustHandle(pattern string, handler HandlerFunc, methods ...string) *RouteBuilder {
+	builder, err := server.Handle(pattern, handler, methods...)
+	if err != nil {
+		panic(err)
+	}
+
+	return builder
+}
+
+/*
+	Err returns the errors of every route which could not be registered, including the ones registered by the
+	methods returning a builder only (GET, POST, PUT, PATCH, DELETE, Redirect, Static, ...), so that an
+	application can check every registration at once before starting the server.
+
+	Returns:
+		- error: The registration errors joined with errors.Join, or nil if every route has been registered.
+*/
+func (server *Server) Err() error {
+	server.routesMutex.RLock()
+	defer server.routesMutex.RUnlock()
+
+	return errors.Join(server.routeErrors...)
+}
+
+// routeError records the error of a route registration and returns it along with a builder holding it.
+func (server *Server) routeError(pattern string, err error) (*RouteBuilder, error) {
+	routeErr := &RouteError{Pattern: pattern, Err: err}
+
+	server.routesMutex.Lock()
+	server.routeErrors = append(server.routeErrors, routeErr)
+	server.routesMutex.Unlock()
+
+	return &RouteBuilder{err: routeErr}, routeErr
+}
+
+// handle registers a route with Handle for the methods returning a builder only, the error being held by the builder.
+func (server *Server) handle(pattern string, handler HandlerFunc, methods ...string) *RouteBuilder {
+	builder, _ := server.Handle(pattern, handler, methods...)
+	return builder
+}
+
+/*
+	parsePattern converts a route pattern (e.g. "/user/:id|[0-9]+") into the source of its regular expression
+	and the list of the names of its parameters, in order.
+*/
+func parsePattern(pattern string) (string, []string) {
 	fragmentRegex := make([]string, 0)
 	paramsList := make([]string, 0)
 
@@ -116,27 +772,7 @@ func (server *Server) Handle(pattern string, handler HandlerFunc, methods []stri
 		} 
 	}
 
-	regexPattern := "^/" + strings.Join(fragmentRegex, "/") + "$"
-	re, err := regexp.Compile(regexPattern)
-
-	if err != nil {
-		fmt.Printf("An error occured while parsing the dynamic route of \"%s\", the Regex isn't valid. \nFull error: %v\n", pattern, err)
-		os.Exit(1)
-	}
-
-	route := Route{
-		Regex: re,
-		Params: paramsList,
-		Handler: handler,
-	}
-
-	for _, method := range methods {
-		if server.Routes[method] == nil {
-			server.Routes[method] = make([]Route, 0)
-		}
-
-		server.Routes[method] = append(server.Routes[method], route)
-	}
+	return "^/" + strings.Join(fragmentRegex, "/") + "$", paramsList
 }
 
 /*
@@ -152,10 +788,11 @@ func (server *Server) Handle(pattern string, handler HandlerFunc, methods []stri
 			to the Context, which contains request and response data.
 
 	Returns:
-		- This function does not return any value.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered,
+			its Err method returns the error, also reported by Server.Err.
 */
-func (server *Server) GET(pattern string, handler HandlerFunc) {
-	server.Handle(pattern, handler, []string{"GET"})
+func (server *Server) GET(pattern string, handler HandlerFunc) *RouteBuilder {
+	return server.handle(pattern, handler, "GET")
 }
 
 /*
@@ -171,10 +808,11 @@ func (server *Server) GET(pattern string, handler HandlerFunc) {
 			to the Context, which contains request and response data.
 
 	Returns:
-		- This function does not return any value.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered,
+			its Err method returns the error, also reported by Server.Err.
 */
-func (server *Server) POST(pattern string, handler HandlerFunc) {
-	server.Handle(pattern, handler, []string{"POST"})
+func (server *Server) POST(pattern string, handler HandlerFunc) *RouteBuilder {
+	return server.handle(pattern, handler, "POST")
 }
 
 /*
@@ -190,10 +828,11 @@ func (server *Server) POST(pattern string, handler HandlerFunc) {
 			to the Context, which contains request and response data.
 
 	Returns:
-		- This function does not return any value.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered,
+			its Err method returns the error, also reported by Server.Err.
 */
-func (server *Server) PUT(pattern string, handler HandlerFunc) {
-	server.Handle(pattern, handler, []string{"PUT"})
+func (server *Server) PUT(pattern string, handler HandlerFunc) *RouteBuilder {
+	return server.handle(pattern, handler, "PUT")
 }
 
 /*
@@ -209,10 +848,11 @@ func (server *Server) PUT(pattern string, handler HandlerFunc) {
 			to the Context, which contains request and response data.
 
 	Returns:
-		- This function does not return any value.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered,
+			its Err method returns the error, also reported by Server.Err.
 */
-func (server *Server) PATCH(pattern string, handler HandlerFunc) {
-	server.Handle(pattern, handler, []string{"PATCH"})
+func (server *Server) PATCH(pattern string, handler HandlerFunc) *RouteBuilder {
+	return server.handle(pattern, handler, "PATCH")
 }
 
 /*
@@ -228,47 +868,109 @@ func (server *Server) PATCH(pattern string, handler HandlerFunc) {
 			to the Context, which contains request and response data.
 
 	Returns:
-		- This function does not return any value.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered,
+			its Err method returns the error, also reported by Server.Err.
 */
-func (server *Server) DELETE(pattern string, handler HandlerFunc) {
-	server.Handle(pattern, handler, []string{"DELETE"})
+func (server *Server) DELETE(pattern string, handler HandlerFunc) *RouteBuilder {
+	return server.handle(pattern, handler, "DELETE")
 }
 
 /*
-	Static serves files from a specified folder when the requested URL matches a given prefix.
+	Redirect registers a route redirecting the requests matching pattern to target, e.g. during URL migrations.
 
-	This function registers a route that maps a URL prefix to a folder on the server's filesystem.
-	When a request is made to a URL that matches the prefix, the server attempts to locate the corresponding
-	file in the specified folder and serves it to the client. The route uses the "GET" HTTP method and supports
-	wildcard paths to serve files dynamically.
+	The parameters of the pattern can be interpolated in the target by using the same ":name" (or "*name") segments:
+	server.Redirect("/old/:id", "/new/:id", 301) redirects "/old/42" to "/new/42". The query string of the request
+	is preserved, appended to the query string of the target if it has one. The route is registered for the "GET"
+	and "HEAD" methods.
 
 	Parameters:
-		- prefix (string): The URL prefix that maps to the folder. For example, if the prefix is "/static",
-			a request to "/static/file.txt" will attempt to serve "file.txt" from the specified folder.
-		- folderPath (string): The path to the folder on the server's filesystem that contains the files to be served.
+		- pattern (string): The URL pattern of the route, as accepted by Handle.
+		- target (string): The URL to redirect to. Its ":name" and "*name" segments are replaced by the matched parameters.
+		- code (int): The HTTP status code of the redirect (e.g. 301, 302, 307 or 308).
 
 	Returns:
-		- This function does not return any value. It registers a route with the server to handle file-serving requests.
+		- *RouteBuilder: A builder to attach metadata to the registered route. If the route cannot be registered
+			(e.g. the target uses a parameter not defined by the pattern), its Err method returns the error,
+			also reported by Server.Err.
 */
-func (server *Server) Static(prefix string, folderPath string) {
-	prefix = strings.TrimSuffix(prefix, "/")
+func (server *Server) Redirect(pattern string, target string, code int) *RouteBuilder {
+	_, params := parsePattern(pattern)
+	known := make(map[string]bool, len(params))
+	for _, param := range params {
+		known[param] = true
+	}
 
-	server.GET(prefix + "/*filepath", func (c *Context) {
-		file := c.Params["filepath"]
-		file = filepath.Clean(file)
+	targetPath, targetQuery, _ := strings.Cut(target, "?")
+	segments := strings.Split(targetPath, "/")
 
-		fullPath := filepath.Join(folderPath, file)
-		c.File(http.StatusOK, fullPath)
-	})
+	for _, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') && !known[segment[1:]] {
+			builder, _ := server.routeError(pattern, fmt.Errorf("%w: the redirect target %q uses the parameter %q", ErrUnknownParameter, target, segment[1:]))
+			return builder
+		}
+	}
+
+	return server.handle(pattern, func(c *Context) {
+		resolved := make([]string, len(segments))
+		for i, segment := range segments {
+			// The values are decoded, they are escaped again so a "?" or a "#" cannot end the path
+			if len(segment) > 1 && segment[0] == ':' {
+				segment = url.PathEscape(c.Params[segment[1:]])
+			} else if len(segment) > 1 && segment[0] == '*' {
+				parts := strings.Split(c.Params[segment[1:]], "/")
+				for j, part := range parts {
+					parts[j] = url.PathEscape(part)
+				}
+				segment = strings.Join(parts, "/")
+			}
+			resolved[i] = segment
+		}
+
+		location := strings.Join(resolved, "/")
+		query := targetQuery
+		if c.Request.URL.RawQuery != "" {
+			if query != "" {
+				query += "&"
+			}
+			query += c.Request.URL.RawQuery
+		}
+		if query != "" {
+			location += "?" + query
+		}
+
+		c.Redirect(code, location)
+	}, http.MethodGet, http.MethodHead)
+}
+
+/*
+	cleanPath returns the canonical form of a URL path: rooted, without duplicate slashes and with its
+	dot segments resolved. A trailing slash is kept, except for the root path.
+*/
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
 }
 
 /*
 	ServeHTTP is the main entry point for handling HTTP requests in the Server.
 
-	This function matches incoming HTTP requests against the registered routes based on the HTTP method and URL pattern.
+	This function matches incoming HTTP requests against the registered routes based on the HTTP method and URL pattern,
+	after running the pre-routing hooks (see PreRouting for the full ordering).
+	The routes of the method are checked in order of priority, the static routes (e.g. "/users/me") before the
+	dynamic ones (e.g. "/users/:id") for the same priority, then in their registration order.
 	If a matching route is found, it creates a Context object, executes middleware functions, and invokes the route's handler.
-	If no matching route is found, it responds with a 404 Not Found status. If the HTTP method is not allowed, it responds
-	with a 405 Method Not Allowed status.
+	When a middleware aborts the request, the remaining middlewares and the route's handler are skipped, but the functions
+	registered with Context.Post still run.
+	If no matching route is found, it responds with a 404 Not Found status. If the path matches routes registered for
+	other HTTP methods only, it responds with a 405 Method Not Allowed status and an Allow header listing these methods.
 
 	Parameters:
 		- writer (http.ResponseWriter): The HTTP response writer used to send data back to the client.
@@ -278,26 +980,60 @@ func (server *Server) Static(prefix string, folderPath string) {
 		- This function does not return any value. It writes the HTTP response directly to the writer.
 */
 func (server *Server) ServeHTTP(writer http.ResponseWriter, reader *http.Request) {
-	routes, ok := server.Routes[reader.Method]
-	if !ok {
-		http.Error(writer, "Method Not Allowed", http.StatusMethodNotAllowed)
+	server.inFlight.Add(1)
+	defer server.inFlight.Add(-1)
+
+	if server.rejectOversized(writer, reader) {
 		return
 	}
 
+	for _, hook := range server.preRouting {
+		if hook(writer, reader) {
+			return
+		}
+	}
+
+	if server.cleanPath {
+		if cleaned := cleanPath(reader.URL.Path); cleaned != reader.URL.Path {
+			if reader.Method == http.MethodGet || reader.Method == http.MethodHead {
+				target := *reader.URL
+				target.Path = cleaned
+				target.RawPath = ""
+
+				http.Redirect(writer, reader, target.RequestURI(), http.StatusMovedPermanently)
+				return
+			}
+
+			reader.URL.Path = cleaned
+			reader.URL.RawPath = ""
+		}
+	}
+
+	// Short-circuit the routes registered by Favicon and RobotsTxt
+	if handler, ok := server.earlyRoutes[reader.URL.Path]; ok {
+		handler(writer, reader)
+		return
+	}
+
+	server.routesMutex.RLock()
+	routes := server.Routes[reader.Method]
+	server.routesMutex.RUnlock()
+
 	found := false
 	index := -1
-	params := make(map[string]string)
+	var matches, hostMatches []string
 
 	for i, route := range routes {
-		matches := route.Regex.FindStringSubmatch(reader.URL.Path)
+		matches = route.Regex.FindStringSubmatch(reader.URL.Path)
 		if len(matches) != 0 {
+			var hostMatched bool
+			if hostMatches, hostMatched = route.matchHost(reader.Host); !hostMatched {
+				continue
+			}
+
 			found = true
 			index = i
 
-			for j, paramName := range route.Params {
-				params[paramName] = matches[j + 1]
-			}
-
 			break
 		} else {
 			continue
@@ -305,37 +1041,177 @@ func (server *Server) ServeHTTP(writer http.ResponseWriter, reader *http.Request
 	}
 
 	if !found {
-		http.NotFound(writer, reader)
+		// The path exists for other methods: answer 405 with the list of the methods allowed
+		if allowed := server.AllowedMethods(reader.Host, reader.URL.Path); len(allowed) > 0 {
+			// CORS preflights reach the middlewares even without an OPTIONS route
+			if reader.Method == http.MethodOptions {
+				server.automaticOptions(writer, reader, allowed)
+				return
+			}
+
+			server.methodNotAllowed(writer, reader, allowed)
+			return
+		}
+
+		server.notFound(writer, reader)
 		return
 	}
 
-	context := &Context{
-		Writer:  writer,
-		Request: reader,
-		Data:    make(map[string]any),
-		Params:  params,
+	context := server.acquireContext(writer, reader, routes[index])
+	for j, paramName := range routes[index].Params {
+		context.Params[paramName] = matches[j + 1]
+	}
+	if routes[index].group != nil && routes[index].group.host != nil {
+		for j, paramName := range routes[index].group.host.params {
+			context.Params[paramName] = hostMatches[j]
+		}
 	}
-	context.Data["PostFunc"] = make([]HandlerFunc, 0)
-	context.Data["Abort"] = false
 
-	for _, mw := range server.Middlewares {
-		mw(context)
+	routes[index].applyHeaders(writer.Header())
 
-		if context.Get("Abort").(bool) {
-			break
+	defer server.releaseContext(context)
+	defer server.recoverPanic(context)
+
+	// The middlewares and the handler are skipped once the client has disconnected, since nobody reads
+	// the response anymore. The post functions still run, as they release the resources of the request.
+	// The global middlewares run first, then the middlewares of the route (see RouteBuilder.Use)
+	context.runMiddlewares(server.Middlewares)
+	context.runMiddlewares(routes[index].Middlewares)
+
+	// A middleware which aborted the request has already written the response
+	if !context.aborted && context.Request.Context().Err() == nil {
+		routes[index].Handler(context)
+	}
+
+	server.complete(context)
+}
+
+// complete runs the post functions of a handled request, then starts its background functions. It resumes
+// after the last post function started, when called again by recoverPanic.
+func (server *Server) complete(context *Context) {
+	// A post function may register another one (e.g. closing a dependency it looked up): iterate by index
+	for context.postRun < len(context.postFuncs) {
+		context.postRun++
+		context.postFuncs[context.postRun-1](context)
+	}
+
+	server.runBackground(context.background)
+}
+
+// runMiddlewares runs the middlewares in order, stopping once the request is aborted or the client has disconnected.
+func (c *Context) runMiddlewares(middlewares []HandlerFunc) {
+	for _, mw := range middlewares {
+		if c.aborted || c.Request.Context().Err() != nil {
+			return
 		}
+
+		mw(c)
+	}
+}
+
+// runBackground starts the functions registered with Context.Background, each in its own goroutine.
+func (server *Server) runBackground(tasks []func()) {
+	for _, task := range tasks {
+		server.BackgroundTasks.Add(1)
+
+		go func(task func()) {
+			defer server.BackgroundTasks.Done()
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					log.Printf("feather: background task panicked: %v", recovered)
+				}
+			}()
+
+			task()
+		}(task)
 	}
+}
 
-	routes[index].Handler(context)
+/*
+	AllowedMethods returns the HTTP methods having a route matching the given concrete host and path.
 
-	postFuncs, ok := context.Data["PostFunc"].([]HandlerFunc)
-	if !ok {
-		return
+	It runs the matchers of every method table, which makes it useful to build the Allow header of 405 responses
+	and to advertise the available actions of a resource (e.g. in HATEOAS responses). The routes of the groups
+	created by HostPattern only count when the host matches their pattern, as when the requests are routed.
+
+	"OPTIONS" is included whenever another method matches, since the server answers the OPTIONS requests of the
+	paths having no OPTIONS route automatically.
+
+	Parameters:
+		- host (string): The host of the request (e.g. c.Request.Host), its port being ignored.
+		- path (string): The concrete URL path to test (e.g. "/user/42"), not a route pattern.
+
+	Returns:
+		- []string: The methods having a matching route, without duplicates and sorted alphabetically.
+				It is empty if no route matches the path.
+*/
+func (server *Server) AllowedMethods(host string, path string) []string {
+	allowed := make([]string, 0)
+
+	server.routesMutex.RLock()
+	defer server.routesMutex.RUnlock()
+
+	for method, routes := range server.Routes {
+		for _, route := range routes {
+			if _, hostMatched := route.matchHost(host); hostMatched && route.Regex.MatchString(path) {
+				allowed = append(allowed, method)
+				break
+			}
+		}
 	}
 
-	for _, fn := range postFuncs {
-		fn(context)
+	if len(allowed) > 0 && !slices.Contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
 	}
+
+	sort.Strings(allowed)
+	return allowed
+}
+
+/*
+	Handler returns an http.Handler serving the routes of the server under a prefix, to mount the server in
+	another router such as an http.ServeMux, without owning the whole listener:
+
+		mux := http.NewServeMux()
+		mux.Handle("/api/", server.Handler("/api"))
+		mux.Handle("/", http.FileServer(http.Dir("public")))
+
+	The prefix is stripped from the path of the requests before they are dispatched by ServeHTTP, so that
+	"/api/users" matches the route "/users". The requests whose path does not start with the prefix are
+	answered with the 404 handler of the server.
+
+	Parameters:
+		- prefix (string): The prefix stripped from the paths (e.g. "/api"), without trailing slash.
+
+	Returns:
+		- http.Handler: The handler dispatching the requests to the server.
+*/
+func (server *Server) Handler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, reader *http.Request) {
+		path, found := strings.CutPrefix(reader.URL.Path, prefix)
+		if !found || (path != "" && path[0] != '/') {
+			server.notFound(writer, reader)
+			return
+		}
+		if path == "" {
+			path = "/"
+		}
+
+		stripped := new(http.Request)
+		*stripped = *reader
+		stripped.URL = new(url.URL)
+		*stripped.URL = *reader.URL
+		stripped.URL.Path = path
+		stripped.URL.RawPath = ""
+
+		if rawPath, ok := strings.CutPrefix(reader.URL.RawPath, prefix); ok && rawPath != "" {
+			stripped.URL.RawPath = rawPath
+		}
+
+		server.ServeHTTP(writer, stripped)
+	})
 }
 
 /*
@@ -354,5 +1230,220 @@ func (server *Server) ServeHTTP(writer http.ResponseWriter, reader *http.Request
 				Otherwise, it blocks indefinitely and does not return.
 */
 func (server *Server) Listen(addr string) error {
-	return http.ListenAndServe(addr, server)
+	return server.newHTTPServer(addr).ListenAndServe()
+}
+
+/*
+	ListenTLS starts the HTTPS server on the specified address and begins handling incoming requests.
+
+	This function uses the http.ListenAndServeTLS function from the net/http package. HTTP/2 is enabled
+	automatically for TLS connections, which makes features such as Context.Push available.
+	For certificates obtained and renewed automatically from Let's Encrypt, see the autotls module
+	(github.com/esmyxvatu/feather/autotls).
+
+	Parameters:
+		- addr (string): The address to listen on, in the format "host:port" (e.g. ":443").
+		- certFile (string): The path to the certificate file. If the certificate is signed by a certificate
+				authority, it should be the concatenation of the server's certificate, any intermediates,
+				and the CA's certificate.
+		- keyFile (string): The path to the private key file matching the certificate.
+
+	Returns:
+		- error: If the server fails to start or encounters an error, this function returns the error.
+				Otherwise, it blocks indefinitely and does not return.
+*/
+func (server *Server) ListenTLS(addr string, certFile string, keyFile string) error {
+	return server.newHTTPServer(addr).ListenAndServeTLS(certFile, keyFile)
+}
+
+/*
+	Serve handles the incoming connections accepted on the given listener, e.g. a Unix domain socket
+	or a listener created by the application.
+
+	Parameters:
+		- listener (net.Listener): The listener accepting the connections. It is closed when Serve returns.
+
+	Returns:
+		- error: The error returned by http.Server.Serve when the listener fails. Otherwise, it blocks
+				indefinitely and does not return.
+*/
+func (server *Server) Serve(listener net.Listener) error {
+	return server.newHTTPServer("").Serve(listener)
+}
+
+/*
+	ListenUnix starts the HTTP server on a Unix domain socket, to be fronted by a reverse proxy such as Nginx
+	(e.g. proxy_pass http://unix:/run/myapp.sock).
+
+	A stale socket file left by a previous run is removed before binding, and the permissions of the socket
+	are restricted to 0600 so that only the user running the server (and root) can connect to it.
+
+	Parameters:
+		- path (string): The path of the socket file (e.g. "/run/myapp.sock").
+
+	Returns:
+		- error: An error if the socket cannot be created, or the error returned by Serve.
+*/
+func (server *Server) ListenUnix(path string) error {
+	if info, err := os.Stat(path); err == nil && info.Mode().Type() == os.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	return server.Serve(listener)
+}
+
+/*
+	ListenWithShutdown starts the HTTP server on the specified address and shuts it down gracefully once ctx is cancelled.
+
+	The server is started in a background goroutine. When ctx is cancelled (e.g. by signal.NotifyContext on SIGTERM),
+	the server stops accepting connections and waits for the in-flight requests, then for the tasks registered with
+	Context.Background, for at most ShutdownTimeout (30 seconds by default).
+
+	Parameters:
+		- addr (string): The address to listen on, in the format "host:port" (e.g. ":8080").
+		- ctx (context.Context): The context whose cancellation triggers the shutdown.
+
+	Returns:
+		- error: The error of the server if it fails to start or stops unexpectedly, or the error of the shutdown
+				(a *ShutdownError when the requests did not complete in time). It returns nil after a clean shutdown.
+*/
+func (server *Server) ListenWithShutdown(addr string, ctx context.Context) error {
+	httpServer := server.newHTTPServer(addr)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	return server.drain(serveErr, httpServer)
+}
+
+/*
+	InFlight returns the number of requests being handled by the server, e.g. to expose it as a metric
+	or to implement a drain-then-exit logic.
+
+	Returns:
+		- int: The number of requests for which ServeHTTP has not returned yet.
+*/
+func (server *Server) InFlight() int {
+	return int(server.inFlight.Load())
+}
+
+/*
+	OnStateChange configures a function called when a client connection changes state, passed as the
+	ConnState hook of the http.Server created by the Listen and Serve methods, e.g. for connection metrics.
+
+	Parameters:
+		- hook (func(conn net.Conn, state http.ConnState)): The function called on every state change.
+				It must be safe for concurrent use.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) OnStateChange(hook func(conn net.Conn, state http.ConnState)) {
+	server.onStateChange = hook
+}
+
+// ShutdownError is returned by the graceful shutdowns when the requests did not complete within the
+// ShutdownTimeout of the server, and the remaining connections have been closed forcibly.
+type ShutdownError struct {
+	CutOff int // CutOff is the number of requests still in flight when the connections were closed.
+}
+
+// Error returns a message reporting the number of requests cut off.
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("feather: shutdown timed out, %d requests cut off", e.CutOff)
+}
+
+// Unwrap returns context.DeadlineExceeded, so that errors.Is(err, context.DeadlineExceeded) works.
+func (e *ShutdownError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// newHTTPServer creates the http.Server serving the server's routes on addr.
+func (server *Server) newHTTPServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:      addr,
+		Handler:   server,
+		ConnState: server.onStateChange,
+	}
+}
+
+// drain shuts the HTTP servers down gracefully, then waits for the background tasks, both within ShutdownTimeout,
+// and finally runs the OnShutdown hooks. When the timeout is exceeded, the remaining connections are closed forcibly
+// and a *ShutdownError is returned. serveErr receives the errors returned by the Serve methods of the servers.
+func (server *Server) drain(serveErr <-chan error, httpServers ...*http.Server) error {
+	defer server.runShutdownHooks()
+
+	timeout := server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shutdownErrs := make([]error, len(httpServers))
+	var wg sync.WaitGroup
+	for i, httpServer := range httpServers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shutdownErrs[i] = httpServer.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(shutdownErrs...); err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		cutOff := server.InFlight()
+		for _, httpServer := range httpServers {
+			httpServer.Close()
+		}
+		return &ShutdownError{CutOff: cutOff}
+	}
+	for range httpServers {
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+
+	return server.waitBackground(ctx)
+}
+
+// waitBackground waits for the tasks registered with Context.Background, until ctx is done.
+func (server *Server) waitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		server.BackgroundTasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
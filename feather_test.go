@@ -0,0 +1,102 @@
+package feather
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHandleNormalizesMethods(t *testing.T) {
+	server := NewServer()
+	if _, err := server.Handle("/items", func(c *Context) { c.String(200, c.Request.Method) }, "get", "Post"); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	for _, method := range []string{"GET", "POST"} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(method, "/items", nil))
+		if recorder.Code != 200 {
+			t.Errorf("%s: got %d, want 200", method, recorder.Code)
+		}
+	}
+}
+
+func TestHandleDefaultsToGET(t *testing.T) {
+	server := NewServer()
+	server.Handle("/items", func(c *Context) {})
+
+	if len(server.Routes["GET"]) != 1 || len(server.Routes) != 1 {
+		t.Errorf("routes = %v, want a single GET route", server.Routes)
+	}
+}
+
+func TestHandleRejectsInvalidMethod(t *testing.T) {
+	server := NewServer()
+
+	_, err := server.Handle("/items", func(c *Context) {}, "GETT")
+	if !errors.Is(err, ErrNonStandardMethod) {
+		t.Errorf("err = %v, want ErrNonStandardMethod", err)
+	}
+	if len(server.Routes) != 0 {
+		t.Error("the route has been registered")
+	}
+	if !errors.Is(server.Err(), ErrNonStandardMethod) {
+		t.Errorf("Err() = %v, want the registration error", server.Err())
+	}
+}
+
+func TestHandleAllowsCustomMethods(t *testing.T) {
+	server := NewServer()
+	server.SetAllowCustomMethods(true)
+
+	if _, err := server.Handle("/items", func(c *Context) {}, "purge"); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("PURGE", "/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("got %d, want 200", recorder.Code)
+	}
+}
+
+func FuzzRoutePattern(f *testing.F) {
+	seeds := []string{
+		// Valid patterns
+		"/",
+		"/users",
+		"/users/:id",
+		"/users/:id|[0-9]+/posts/:post",
+		"/files/*path",
+		"/:lang|en|fr/docs",
+		"/a/:b|(x|y)+/c",
+		// Invalid patterns
+		"",
+		"users",
+		"/users/:id|[0-9",
+		"/users/:id|(a",
+		"/users/:id|a{1001}",
+		"/users/:id|(((a+)+)+)+b",
+		"/users/:id|\\",
+		"/users/:id|a**",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, pattern string) {
+		server := NewServer()
+
+		builder, err := server.Handle(pattern, func(c *Context) {})
+		if err != nil {
+			if builder == nil || builder.Err() == nil {
+				t.Fatalf("Handle(%q) returned %v without a builder holding the error", pattern, err)
+			}
+			return
+		}
+
+		// The registered route must be matchable without panicking, whatever the path
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/"+url.PathEscape(pattern), nil))
+	})
+}
@@ -0,0 +1,141 @@
+package feather
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FlashCookieName is the name of the cookie holding the flash messages, see Context.Flash.
+const FlashCookieName = "feather_flash"
+
+// maxFlashCookieSize is the size limit of the flash cookie, as the browsers drop the cookies larger than 4 KB.
+const maxFlashCookieSize = 4096
+
+// ErrFlashTooLarge is returned by Context.Flash when the flash messages no longer fit in a cookie.
+var ErrFlashTooLarge = errors.New("feather: flash messages are too large for a cookie")
+
+// flashes holds the flash messages of a request, loaded from the flash cookie on first use.
+type flashes struct {
+	received map[string][]any             // received holds the messages set by the previous request, removed once read.
+	pending  map[string][]json.RawMessage // pending holds the messages set by Flash for the next request.
+}
+
+// Flash stores a message for the next request, typically the one following a redirect (Post/Redirect/Get),
+// e.g. a success banner or the errors of a form. The messages are kept in a cookie: no session store is needed,
+// but the client can read and modify them, so they must not hold secrets nor be trusted.
+//
+// Parameters:
+//   - key: The category of the message, e.g. "success" or "errors".
+//   - value: The message. Any JSON-encodable value is accepted; it is read back decoded as JSON by Flashes
+//     (e.g. a struct becomes a map[string]any, a number a float64).
+//
+// Flash must be called before the response is written, as it sets the cookie.
+//
+// Returns:
+//   - The error of the JSON encoding, ErrFlashTooLarge if the messages exceed the size of a cookie (the message is
+//     then dropped), or nil once the message is stored.
+func (c *Context) Flash(key string, value any) error {
+	message, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	state := c.loadFlashes()
+
+	pending := maps.Clone(state.pending)
+	pending[key] = append(slices.Clone(pending[key]), message)
+	if err := c.setFlashCookie(pending); err != nil {
+		return err
+	}
+	state.pending = pending
+
+	return nil
+}
+
+// Flashes returns the messages stored with Flash by the previous request under key, and consumes them:
+// a second call returns nil. The messages of the previous request are only delivered to the current one,
+// whether they are read or not, and the messages set by Flash during the current request are kept for the next one.
+// Templates rendered with Template can call it through the "flashes" function: {{range flashes "success"}}.
+//
+// Parameters:
+//   - key: The category of the messages.
+//
+// Flashes must first be called before the response is written, as it clears the cookie. Template does it itself.
+//
+// Returns:
+//   - The messages of the category decoded as JSON, in the order they were stored, or nil if there are none.
+func (c *Context) Flashes(key string) []any {
+	state := c.loadFlashes()
+
+	messages := state.received[key]
+	delete(state.received, key)
+
+	return messages
+}
+
+// loadFlashes returns the flash messages of the request, reading the flash cookie on first use. The cookie is
+// cleared at once, so that the messages of the previous request are delivered to this request only.
+func (c *Context) loadFlashes() *flashes {
+	if c.flashes != nil {
+		return c.flashes
+	}
+	c.flashes = &flashes{pending: make(map[string][]json.RawMessage)}
+
+	cookie, err := c.Request.Cookie(FlashCookieName)
+	if err != nil {
+		return c.flashes
+	}
+
+	// A cookie which cannot be decoded, e.g. modified by the client, holds no messages
+	if data, err := base64.RawURLEncoding.DecodeString(cookie.Value); err == nil {
+		if err := json.Unmarshal(data, &c.flashes.received); err != nil {
+			c.flashes.received = nil
+		}
+	}
+	c.setFlashCookie(c.flashes.pending)
+
+	return c.flashes
+}
+
+// setFlashCookie sets the flash cookie holding the pending messages, replacing the flash cookie already set by
+// the request if any. The cookie is deleted when there are no messages.
+func (c *Context) setFlashCookie(pending map[string][]json.RawMessage) error {
+	cookie := &http.Cookie{
+		Name:     FlashCookieName,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if len(pending) == 0 {
+		cookie.MaxAge = -1
+	} else {
+		data, err := json.Marshal(pending)
+		if err != nil {
+			return err
+		}
+		cookie.Value = base64.RawURLEncoding.EncodeToString(data)
+
+		if len(cookie.String()) > maxFlashCookieSize {
+			return ErrFlashTooLarge
+		}
+	}
+
+	header := c.Writer.Header()
+	cookies := header.Values("Set-Cookie")
+	header.Del("Set-Cookie")
+	for _, line := range cookies {
+		if !strings.HasPrefix(line, FlashCookieName+"=") {
+			header.Add("Set-Cookie", line)
+		}
+	}
+	http.SetCookie(c.Writer, cookie)
+
+	return nil
+}
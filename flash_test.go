@@ -0,0 +1,211 @@
+package feather
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// flashServer returns a server whose POST /contact stores a flash message and redirects to GET /contact, which
+// renders it with a template.
+func flashServer(t *testing.T) *Server {
+	t.Helper()
+
+	page := filepath.Join(t.TempDir(), "contact.html")
+	content := `{{range flashes "success"}}<p class="success">{{.}}</p>{{end}}<form></form>`
+	if err := os.WriteFile(page, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	server.POST("/contact", func(c *Context) {
+		if err := c.Flash("success", "Message sent"); err != nil {
+			t.Errorf("Flash: %v", err)
+		}
+		c.Redirect(http.StatusSeeOther, "/contact")
+	})
+	server.GET("/contact", func(c *Context) {
+		c.Template([]string{page}, nil, nil)
+	})
+
+	return server
+}
+
+// flashClient returns a client of server keeping its cookies, and the function sending a request with it.
+func flashClient(t *testing.T, server *Server) func(method string, path string) string {
+	t.Helper()
+
+	listener := httptest.NewServer(server)
+	t.Cleanup(listener.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+
+	return func(method string, path string) string {
+		t.Helper()
+
+		request, err := http.NewRequest(method, listener.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(body)
+	}
+}
+
+func TestFlashPostRedirectGet(t *testing.T) {
+	send := flashClient(t, flashServer(t))
+
+	// The client follows the 303 with a GET, which shows the message once
+	if body := send("POST", "/contact"); strings.Count(body, `<p class="success">Message sent</p>`) != 1 {
+		t.Errorf("page after the redirect = %q, want the flash message", body)
+	}
+	if body := send("GET", "/contact"); strings.Contains(body, "Message sent") {
+		t.Errorf("page after a reload = %q, want no flash message", body)
+	}
+}
+
+func TestFlashValues(t *testing.T) {
+	server := NewServer()
+	server.POST("/flash", func(c *Context) {
+		c.Flash("errors", map[string]any{"field": "email", "code": 42})
+		c.Flash("errors", []string{"a", "b"})
+		c.Flash("count", 3)
+		c.String(http.StatusOK, "stored")
+	})
+	server.GET("/read", func(c *Context) {
+		c.JSON(http.StatusOK, map[string]any{
+			"errors": c.Flashes("errors"),
+			"count":  c.Flashes("count"),
+			"again":  c.Flashes("errors"),
+		})
+	})
+
+	send := flashClient(t, server)
+	send("POST", "/flash")
+
+	want := `{"again":null,"count":[3],"errors":[{"code":42,"field":"email"},["a","b"]]}`
+	if body := strings.TrimSpace(send("GET", "/read")); body != want {
+		t.Errorf("messages = %s, want %s", body, want)
+	}
+	if body := strings.TrimSpace(send("GET", "/read")); body != `{"again":null,"count":null,"errors":null}` {
+		t.Errorf("messages of the next request = %s, want none", body)
+	}
+}
+
+func TestFlashNotReadInTheSameRequest(t *testing.T) {
+	var messages []any
+
+	server := NewServer()
+	server.POST("/flash", func(c *Context) {
+		c.Flash("success", "saved")
+		messages = c.Flashes("success")
+	})
+
+	recorder := server.TestRequest("POST", "/flash", nil, nil)
+
+	if messages != nil {
+		t.Errorf("Flashes = %v in the request calling Flash, want the message kept for the next request", messages)
+	}
+	if cookies := recorder.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != FlashCookieName || cookies[0].MaxAge < 0 {
+		t.Errorf("cookies = %v, want the flash cookie", cookies)
+	}
+}
+
+func TestFlashSingleCookie(t *testing.T) {
+	server := NewServer()
+	server.POST("/flash", func(c *Context) {
+		c.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+		c.Flash("success", "first")
+		c.Flash("success", "second")
+	})
+
+	recorder := server.TestRequest("POST", "/flash", nil, nil)
+
+	var names []string
+	for _, cookie := range recorder.Result().Cookies() {
+		names = append(names, cookie.Name)
+	}
+	if strings.Join(names, ",") != "theme,"+FlashCookieName {
+		t.Errorf("cookies = %v, want the other cookie and a single flash cookie", names)
+	}
+}
+
+func TestFlashInvalidCookie(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "%%%"},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("{broken"))},
+		{"wrong shape", base64.RawURLEncoding.EncodeToString([]byte(`{"success":"not a list"}`))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var messages []any
+
+			server := NewServer()
+			server.GET("/", func(c *Context) {
+				messages = c.Flashes("success")
+			})
+
+			recorder := server.TestRequest("GET", "/", nil, map[string]string{"Cookie": FlashCookieName + "=" + url.QueryEscape(test.value)})
+
+			if recorder.Code != http.StatusOK || messages != nil {
+				t.Errorf("got %d with %v, want 200 without messages", recorder.Code, messages)
+			}
+			if cookies := recorder.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+				t.Errorf("cookies = %v, want the flash cookie deleted", cookies)
+			}
+		})
+	}
+}
+
+func TestFlashTooLarge(t *testing.T) {
+	var errs []error
+
+	server := NewServer()
+	server.POST("/flash", func(c *Context) {
+		errs = append(errs, c.Flash("success", "saved"))
+		errs = append(errs, c.Flash("details", strings.Repeat("x", maxFlashCookieSize)))
+		errs = append(errs, c.Flash("unencodable", func() {}))
+	})
+
+	recorder := server.TestRequest("POST", "/flash", nil, nil)
+
+	if errs[0] != nil || !errors.Is(errs[1], ErrFlashTooLarge) || errs[2] == nil {
+		t.Fatalf("errors = %v, want nil, ErrFlashTooLarge and a JSON error", errs)
+	}
+
+	// The rejected messages are dropped, the previous ones are kept
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %v, want the flash cookie", cookies)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookies[0].Value)
+	if err != nil || string(data) != `{"success":["saved"]}` {
+		t.Errorf("cookie = %s (%v), want the first message only", data, err)
+	}
+}
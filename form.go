@@ -0,0 +1,325 @@
+package feather
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+)
+
+// ErrTooManyFields is returned when a form has more fields than FormConfig.MaxFields.
+var ErrTooManyFields = errors.New("feather: form has too many fields")
+
+// ErrFormTooLarge is returned when a url-encoded form body exceeds FormConfig.MaxMemory.
+var ErrFormTooLarge = errors.New("feather: form body is too large")
+
+// ErrUnsupportedFormType is returned when the Content-Type of a form is not listed in FormConfig.ContentTypes.
+var ErrUnsupportedFormType = errors.New("feather: unsupported form content type")
+
+// FormConfig holds the limits applied when parsing the forms of the requests, see Server.SetFormConfig.
+// A zero field uses the value of DefaultFormConfig.
+type FormConfig struct {
+	// MaxMemory is the maximum number of bytes of a form held in memory. A url-encoded body exceeding it is
+	// rejected, while the files of a multipart form exceeding it are stored in temporary files.
+	MaxMemory int64
+
+	// MaxFields is the maximum number of fields of a form, counting every value and file. It protects the
+	// server against the forms made of millions of tiny fields.
+	MaxFields int
+
+	// MaxFileSize is the maximum size, in bytes, of the files read into memory by Context.FormFile.
+	MaxFileSize int64
+
+	// MaxUploadSize is the maximum total size, in bytes, of the multipart body streamed by Context.EachPart.
+	MaxUploadSize int64
+
+	// ContentTypes restricts the media types accepted for forms (e.g. "application/x-www-form-urlencoded").
+	// Any media type is accepted when it is empty.
+	ContentTypes []string
+}
+
+// DefaultFormConfig is the FormConfig used by the servers which do not configure one.
+var DefaultFormConfig = FormConfig{
+	MaxMemory:     32 << 20,
+	MaxFields:     1000,
+	MaxFileSize:   DefaultMaxFileSize,
+	MaxUploadSize: 1 << 30,
+}
+
+// FormError is the error returned by the form parsing helpers when a form is malformed or exceeds the limits
+// of the FormConfig. It carries the HTTP status code to answer with.
+type FormError struct {
+	Status int   // Status is the HTTP status code matching the error: 400, 413 or 415.
+	Err    error // Err is the underlying error, e.g. ErrTooManyFields.
+}
+
+// Error returns the message of the underlying error.
+func (e *FormError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, so that errors.Is(err, ErrTooManyFields) works.
+func (e *FormError) Unwrap() error {
+	return e.Err
+}
+
+/*
+	SetFormConfig configures the limits applied when parsing the forms of the requests with Context.ParseForm,
+	Context.FormValue, Context.FormFile, Context.MultipartForm and Context.EachPart.
+
+	Parameters:
+		- config (FormConfig): The limits of the forms. Its zero fields use the values of DefaultFormConfig.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) SetFormConfig(config FormConfig) {
+	server.formConfig = config
+}
+
+// formConfig returns the FormConfig of the server, completed with the default values.
+func (c *Context) formConfig() FormConfig {
+	config := DefaultFormConfig
+	if c.server == nil {
+		return config
+	}
+
+	if c.server.formConfig.MaxMemory > 0 {
+		config.MaxMemory = c.server.formConfig.MaxMemory
+	}
+	if c.server.formConfig.MaxFields > 0 {
+		config.MaxFields = c.server.formConfig.MaxFields
+	}
+	if c.server.formConfig.MaxFileSize > 0 {
+		config.MaxFileSize = c.server.formConfig.MaxFileSize
+	}
+	if c.server.formConfig.MaxUploadSize > 0 {
+		config.MaxUploadSize = c.server.formConfig.MaxUploadSize
+	}
+	config.ContentTypes = c.server.formConfig.ContentTypes
+
+	return config
+}
+
+// ParseForm parses the form of the request (query string, url-encoded or multipart body) once,
+// enforcing the limits configured with Server.SetFormConfig.
+//
+// Returns:
+//   - nil if the form has been parsed, including on later calls.
+//   - A *FormError otherwise, holding the status to answer with: 413 when the body exceeds
+//     MaxMemory, 415 when its media type is not accepted, 400 when it has too many fields or
+//     is malformed. When the client disconnected during the upload, its status is
+//     StatusClientClosedRequest and it wraps ErrClientAborted. The same error is returned on later calls.
+func (c *Context) ParseForm() error {
+	if !c.formParsed {
+		c.formParsed = true
+		c.formErr = c.parseForm()
+	}
+
+	return c.formErr
+}
+
+// parseForm parses the form of the request, see ParseForm.
+func (c *Context) parseForm() error {
+	config := c.formConfig()
+
+	mediaType := ""
+	if contentType := c.Request.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return &FormError{Status: http.StatusBadRequest, Err: err}
+		}
+		mediaType = parsed
+	}
+
+	if mediaType != "" && len(config.ContentTypes) > 0 && !slices.Contains(config.ContentTypes, mediaType) {
+		return &FormError{Status: http.StatusUnsupportedMediaType, Err: ErrUnsupportedFormType}
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		if err := c.Request.ParseMultipartForm(config.MaxMemory); err != nil {
+			if err := c.bodyError(err); errors.Is(err, ErrClientAborted) {
+				return &FormError{Status: StatusClientClosedRequest, Err: err}
+			}
+			if errors.Is(err, multipart.ErrMessageTooLarge) {
+				return &FormError{Status: http.StatusRequestEntityTooLarge, Err: err}
+			}
+			return &FormError{Status: http.StatusBadRequest, Err: err}
+		}
+	case "application/x-www-form-urlencoded":
+		// Check the limits on the raw body, before the fields are decoded into memory
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, config.MaxMemory+1))
+			if err := c.bodyError(err); errors.Is(err, ErrClientAborted) {
+				return &FormError{Status: StatusClientClosedRequest, Err: err}
+			}
+			if err != nil {
+				return &FormError{Status: http.StatusBadRequest, Err: err}
+			}
+			if int64(len(body)) > config.MaxMemory {
+				return &FormError{Status: http.StatusRequestEntityTooLarge, Err: ErrFormTooLarge}
+			}
+			if bytes.Count(body, []byte("&"))+1 > config.MaxFields {
+				return &FormError{Status: http.StatusBadRequest, Err: ErrTooManyFields}
+			}
+
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fallthrough
+	default:
+		if err := c.Request.ParseForm(); err != nil {
+			return &FormError{Status: http.StatusBadRequest, Err: err}
+		}
+	}
+
+	fields := 0
+	for _, values := range c.Request.Form {
+		fields += len(values)
+	}
+	if c.Request.MultipartForm != nil {
+		for _, files := range c.Request.MultipartForm.File {
+			fields += len(files)
+		}
+	}
+	if fields > config.MaxFields {
+		return &FormError{Status: http.StatusBadRequest, Err: fmt.Errorf("%w (%d > %d)", ErrTooManyFields, fields, config.MaxFields)}
+	}
+
+	return nil
+}
+
+// MultipartForm parses the multipart form of the request, see ParseForm, and returns it.
+//
+// Returns:
+//   - The parsed multipart form, holding its values and files.
+//   - The *FormError returned by ParseForm, or http.ErrNotMultipart if the request is not a multipart form.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if err := c.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	if c.Request.MultipartForm == nil {
+		return nil, http.ErrNotMultipart
+	}
+
+	return c.Request.MultipartForm, nil
+}
+
+// EachPart streams the parts of a multipart request one by one, without buffering the form, for the
+// endpoints receiving many or large files.
+//
+// Parameters:
+//   - fn: The function called for each part, in order. The part can only be read until fn returns.
+//     Returning an error stops the iteration.
+//
+// The total size of the body is limited to FormConfig.MaxUploadSize and the number of parts to
+// FormConfig.MaxFields. The size of each file is limited when it is read with CopyPart.
+// When the iteration fails, the error response is written: the status of a *FormError returned by fn
+// or by CopyPart, 413 when the body exceeds its limit, 400 otherwise. No response is written when the
+// client disconnected during the upload (see ErrClientAborted).
+//
+// Returns:
+//   - nil once every part has been processed, or the error which stopped the iteration.
+func (c *Context) EachPart(fn func(part *multipart.Part) error) error {
+	err := c.eachPart(fn)
+	if err != nil {
+		var formErr *FormError
+		var maxBytesErr *http.MaxBytesError
+
+		switch {
+		case errors.Is(err, ErrClientAborted):
+			c.PushError(err)
+		case errors.As(err, &formErr):
+			c.Error(formErr.Status, err.Error())
+		case errors.As(err, &maxBytesErr):
+			c.Error(http.StatusRequestEntityTooLarge, err.Error())
+		default:
+			c.Error(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	return err
+}
+
+// eachPart iterates the parts of the request, see EachPart.
+func (c *Context) eachPart(fn func(part *multipart.Part) error) error {
+	config := c.formConfig()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxUploadSize)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for count := 1; ; count++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return c.bodyError(err)
+		}
+
+		if count > config.MaxFields {
+			part.Close()
+			return &FormError{Status: http.StatusBadRequest, Err: ErrTooManyFields}
+		}
+
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CopyPart copies the content of a part streamed by EachPart to dst, e.g. a file or an object storage upload.
+//
+// Parameters:
+//   - dst: The writer receiving the content.
+//   - part: The part to copy.
+//
+// Returns:
+//   - The number of bytes copied.
+//   - A *FormError wrapping ErrFileTooLarge (status 413) if the part exceeds FormConfig.MaxFileSize,
+//     or the error returned while reading the part or writing to dst.
+func (c *Context) CopyPart(dst io.Writer, part *multipart.Part) (int64, error) {
+	maxSize := c.formConfig().MaxFileSize
+
+	written, err := io.Copy(dst, io.LimitReader(part, maxSize+1))
+	if err != nil {
+		return written, c.bodyError(err)
+	}
+	if written > maxSize {
+		return maxSize, &FormError{Status: http.StatusRequestEntityTooLarge, Err: ErrFileTooLarge}
+	}
+
+	return written, nil
+}
+
+// SniffPart detects the content type of a part from its first bytes, ignoring the type declared by the
+// client, e.g. to reject the uploads which are not images.
+//
+// Parameters:
+//   - part: The part to sniff.
+//
+// Returns:
+//   - The content type detected by http.DetectContentType.
+//   - A reader of the whole content of the part, including the bytes read to sniff it.
+//   - The error returned while reading the part.
+func SniffPart(part *multipart.Part) (string, io.Reader, error) {
+	buffer := make([]byte, 512)
+
+	n, err := io.ReadFull(part, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	buffer = buffer[:n]
+
+	return http.DetectContentType(buffer), io.MultiReader(bytes.NewReader(buffer), part), nil
+}
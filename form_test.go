@@ -0,0 +1,364 @@
+package feather
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// urlEncodedRequest returns a POST request with a url-encoded form of n fields.
+func urlEncodedRequest(n int) *http.Request {
+	form := url.Values{}
+	for i := range n {
+		form.Set("f"+strconv.Itoa(i), "v")
+	}
+
+	request := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+// multipartRequest returns a POST request with a multipart form made of the given fields and files.
+func multipartRequest(t *testing.T, fields map[string]string, files map[string][]byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+// parseWith parses the form of request on a server configured with config.
+func parseWith(config FormConfig, request *http.Request) (*Context, *httptest.ResponseRecorder, error) {
+	server := NewServer()
+	server.SetFormConfig(config)
+
+	recorder := httptest.NewRecorder()
+	c := &Context{Writer: recorder, Request: request, server: server}
+
+	return c, recorder, c.ParseForm()
+}
+
+// formStatus returns the status of the *FormError err, or 0.
+func formStatus(err error) int {
+	var formErr *FormError
+	if errors.As(err, &formErr) {
+		return formErr.Status
+	}
+	return 0
+}
+
+func TestParseFormLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  FormConfig
+		request func() *http.Request
+		status  int
+		err     error
+	}{
+		{"fields within the limit", FormConfig{MaxFields: 10}, func() *http.Request { return urlEncodedRequest(10) }, 0, nil},
+		{"too many url-encoded fields", FormConfig{MaxFields: 10}, func() *http.Request { return urlEncodedRequest(11) }, http.StatusBadRequest, ErrTooManyFields},
+		{"too many query fields", FormConfig{MaxFields: 2}, func() *http.Request { return httptest.NewRequest("GET", "/?a=1&b=2&c=3", nil) }, http.StatusBadRequest, ErrTooManyFields},
+		{"url-encoded body too large", FormConfig{MaxMemory: 64}, func() *http.Request { return urlEncodedRequest(20) }, http.StatusRequestEntityTooLarge, ErrFormTooLarge},
+		{"too many multipart fields", FormConfig{MaxFields: 2}, func() *http.Request {
+			return multipartRequest(t, map[string]string{"a": "1", "b": "2"}, map[string][]byte{"file": []byte("x")})
+		}, http.StatusBadRequest, ErrTooManyFields},
+		{"unsupported content type", FormConfig{ContentTypes: []string{"application/x-www-form-urlencoded"}}, func() *http.Request {
+			return multipartRequest(t, map[string]string{"a": "1"}, nil)
+		}, http.StatusUnsupportedMediaType, ErrUnsupportedFormType},
+		{"malformed content type", FormConfig{}, func() *http.Request {
+			request := urlEncodedRequest(1)
+			request.Header.Set("Content-Type", "multipart/form-data; boundary=\"")
+			return request
+		}, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		_, _, err := parseWith(test.config, test.request())
+
+		if status := formStatus(err); status != test.status {
+			t.Errorf("%s: status = %d, want %d (%v)", test.name, status, test.status, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s: err = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestParseFormMultipartSpillsToDisk(t *testing.T) {
+	// Files larger than MaxMemory are stored in temporary files rather than rejected
+	c, _, err := parseWith(FormConfig{MaxMemory: 16}, multipartRequest(t, nil, map[string][]byte{"file": bytes.Repeat([]byte("x"), 1024)}))
+	if err != nil {
+		t.Fatalf("ParseForm: %v", err)
+	}
+	defer c.Request.MultipartForm.RemoveAll()
+
+	if files := c.Request.MultipartForm.File["file"]; len(files) != 1 || files[0].Size != 1024 {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestParseFormErrorIsKept(t *testing.T) {
+	c, _, err := parseWith(FormConfig{MaxFields: 1}, urlEncodedRequest(2))
+	if err == nil {
+		t.Fatal("no error for too many fields")
+	}
+	if again := c.ParseForm(); again != err {
+		t.Errorf("second call = %v, want %v", again, err)
+	}
+}
+
+func TestFormValueWritesError(t *testing.T) {
+	c, recorder, _ := parseWith(FormConfig{MaxFields: 3}, urlEncodedRequest(4))
+
+	if value := c.FormValue("f0"); value != "" {
+		t.Errorf("FormValue = %q, want empty", value)
+	}
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", recorder.Code)
+	}
+}
+
+func TestFormFileMaxFileSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		status int
+	}{
+		{"within the limit", 100, 0},
+		{"too large", 101, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		server := NewServer()
+		server.SetFormConfig(FormConfig{MaxFileSize: 100})
+
+		request := multipartRequest(t, nil, map[string][]byte{"avatar": bytes.Repeat([]byte("x"), test.size)})
+		c := &Context{Writer: httptest.NewRecorder(), Request: request, server: server}
+
+		content, _, err := c.FormFile("avatar")
+		if status := formStatus(err); status != test.status {
+			t.Errorf("%s: status = %d, want %d (%v)", test.name, status, test.status, err)
+		}
+		if test.status == 0 && len(content) != test.size {
+			t.Errorf("%s: read %d bytes, want %d", test.name, len(content), test.size)
+		}
+		if test.status != 0 && !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("%s: err = %v, want ErrFileTooLarge", test.name, err)
+		}
+	}
+}
+
+func TestEachPartLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FormConfig
+		files  map[string][]byte
+		status int
+	}{
+		{"within the limits", FormConfig{MaxFields: 2, MaxFileSize: 10}, map[string][]byte{"a": []byte("1"), "b": []byte("2")}, http.StatusOK},
+		{"too many parts", FormConfig{MaxFields: 2}, map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3")}, http.StatusBadRequest},
+		{"file too large", FormConfig{MaxFileSize: 10}, map[string][]byte{"a": bytes.Repeat([]byte("x"), 11)}, http.StatusRequestEntityTooLarge},
+		{"upload too large", FormConfig{MaxUploadSize: 512}, map[string][]byte{"a": bytes.Repeat([]byte("x"), 1024)}, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		server := NewServer()
+		server.SetFormConfig(test.config)
+		server.POST("/", func(c *Context) {
+			err := c.EachPart(func(part *multipart.Part) error {
+				_, err := c.CopyPart(io.Discard, part)
+				return err
+			})
+			if err == nil {
+				c.String(http.StatusOK, "ok")
+			}
+		})
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, multipartRequest(t, nil, test.files))
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: status = %d, want %d (%s)", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+}
+
+// craftedMultipart is a multipart body mixing fields and files, the image being a PNG declared as text.
+var craftedMultipart = "--XBOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"title\"\r\n\r\n" +
+	"Holidays\r\n" +
+	"--XBOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"photo\"; filename=\"beach.png\"\r\n" +
+	"Content-Type: text/plain\r\n\r\n" +
+	"\x89PNG\r\n\x1a\n0000\r\n" +
+	"--XBOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"notes\"; filename=\"notes.txt\"\r\n" +
+	"Content-Type: text/plain\r\n\r\n" +
+	"sunny all week\r\n" +
+	"--XBOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"video\"; filename=\"clip.mp4\"\r\n" +
+	"Content-Type: video/mp4\r\n\r\n" +
+	strings.Repeat("v", 64) + "\r\n" +
+	"--XBOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"tag\"\r\n\r\n" +
+	"beach\r\n" +
+	"--XBOUNDARY--\r\n"
+
+// craftedRequest returns a POST request with the crafted multipart body.
+func craftedRequest() *http.Request {
+	request := httptest.NewRequest("POST", "/upload", strings.NewReader(craftedMultipart))
+	request.Header.Set("Content-Type", "multipart/form-data; boundary=XBOUNDARY")
+	return request
+}
+
+func TestEachPartStreamsMixedParts(t *testing.T) {
+	type received struct {
+		name, filename, sniffed, content string
+	}
+	var parts []received
+
+	server := NewServer()
+	server.POST("/upload", func(c *Context) {
+		err := c.EachPart(func(part *multipart.Part) error {
+			if part.FileName() == "" {
+				value, err := io.ReadAll(part)
+				parts = append(parts, received{name: part.FormName(), content: string(value)})
+				return err
+			}
+
+			sniffed, reader, err := SniffPart(part)
+			if err != nil {
+				return err
+			}
+
+			var content bytes.Buffer
+			if _, err := io.Copy(&content, reader); err != nil {
+				return err
+			}
+			parts = append(parts, received{part.FormName(), part.FileName(), sniffed, content.String()})
+			return nil
+		})
+		if err == nil {
+			c.String(http.StatusOK, "ok")
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, craftedRequest())
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", recorder.Code, recorder.Body.String())
+	}
+
+	want := []received{
+		{"title", "", "", "Holidays"},
+		{"photo", "beach.png", "image/png", "\x89PNG\r\n\x1a\n0000"},
+		{"notes", "notes.txt", "text/plain; charset=utf-8", "sunny all week"},
+		{"video", "clip.mp4", "text/plain; charset=utf-8", strings.Repeat("v", 64)},
+		{"tag", "", "", "beach"},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("got %d parts, want %d: %+v", len(parts), len(want), parts)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestEachPartLimitMidStream(t *testing.T) {
+	var seen []string
+
+	server := NewServer()
+	server.SetFormConfig(FormConfig{MaxFileSize: 32})
+	server.POST("/upload", func(c *Context) {
+		c.EachPart(func(part *multipart.Part) error {
+			seen = append(seen, part.FormName())
+			_, err := c.CopyPart(io.Discard, part)
+			return err
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, craftedRequest())
+
+	// The video exceeds MaxFileSize: the parts before it are processed, the ones after it are not
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", recorder.Code)
+	}
+	if got := strings.Join(seen, ","); got != "title,photo,notes,video" {
+		t.Errorf("parts = %s", got)
+	}
+}
+
+func TestEachPartCallbackError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"plain error", errors.New("unexpected field"), http.StatusBadRequest},
+		{"form error", &FormError{Status: http.StatusUnsupportedMediaType, Err: ErrUnsupportedFormType}, http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		count := 0
+
+		server := NewServer()
+		server.POST("/upload", func(c *Context) {
+			err := c.EachPart(func(part *multipart.Part) error {
+				count++
+				if part.FormName() == "photo" {
+					return test.err
+				}
+				return nil
+			})
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: EachPart = %v, want %v", test.name, err, test.err)
+			}
+		})
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, craftedRequest())
+
+		if recorder.Code != test.status || count != 2 {
+			t.Errorf("%s: status = %d after %d parts, want %d after 2", test.name, recorder.Code, count, test.status)
+		}
+	}
+}
+
+func TestEachPartNotMultipart(t *testing.T) {
+	server := NewServer()
+	server.POST("/", func(c *Context) {
+		c.EachPart(func(part *multipart.Part) error { return nil })
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, urlEncodedRequest(1))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", recorder.Code)
+	}
+}
@@ -0,0 +1,153 @@
+//go:build unix
+
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+// ListenerFDEnv is the environment variable holding the file descriptor of the listener inherited
+// from the parent process, set by Fork for the new process.
+const ListenerFDEnv = "FEATHER_FD"
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+/*
+	ListenGraceful starts the HTTP server on the specified address, supporting zero-downtime restarts.
+
+	The listener is inherited instead of being bound anew when the process has been started:
+		- by Fork, which passes the listener of the parent in the FEATHER_FD environment variable,
+		- by systemd socket activation, which passes it as the file descriptor 3 (LISTEN_FDS and LISTEN_PID).
+
+	On SIGUSR2, the server starts a new instance of the binary with Fork, then drains: it stops accepting
+	connections and waits for the in-flight requests and background tasks before returning. The new process
+	accepts the connections from the shared socket meanwhile, so no connection is dropped. On SIGTERM or
+	SIGINT, the server drains the same way without starting a new process. The drain is limited to ShutdownTimeout.
+
+	Parameters:
+		- addr (string): The address to listen on when no listener is inherited, e.g. ":8080".
+
+	Returns:
+		- error: The error of the server if it fails to start or stops unexpectedly, or the error of the fork
+				or of the shutdown (a *ShutdownError when the requests did not complete in time). It returns nil
+				after a clean drain.
+*/
+func (server *Server) ListenGraceful(addr string) error {
+	listener, err := inheritedListener()
+	if err != nil {
+		return err
+	}
+	if listener == nil {
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+	}
+	server.listener = listener
+
+	httpServer := server.newHTTPServer(addr)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-signals:
+		if sig == syscall.SIGUSR2 {
+			if _, err := server.Fork(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return server.drain(serveErr, httpServer)
+}
+
+/*
+	Fork starts a new instance of the running binary, with the same arguments and environment, passing it the
+	listener of the server so that it can serve the same socket with ListenGraceful.
+
+	The current process keeps serving: it is up to the caller to drain it afterwards. ListenGraceful calls Fork
+	automatically on SIGUSR2.
+
+	Returns:
+		- *os.Process: The new process.
+		- error: An error if the server is not listening with ListenGraceful, or if the process cannot be started.
+*/
+func (server *Server) Fork() (*os.Process, error) {
+	filer, ok := server.listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, errors.New("feather: Fork requires a server started with ListenGraceful")
+	}
+
+	file, err := filer.File()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// The first extra file becomes the file descriptor 3 of the new process
+	command := exec.Command(executable, os.Args[1:]...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.ExtraFiles = []*os.File{file}
+	command.Env = append(os.Environ(), fmt.Sprintf("%s=%d", ListenerFDEnv, listenFDsStart))
+
+	if err := command.Start(); err != nil {
+		return nil, err
+	}
+
+	return command.Process, nil
+}
+
+// inheritedListener returns the listener passed by the parent process (see Fork) or by systemd socket activation,
+// or nil when the process has not inherited any listener.
+func inheritedListener() (net.Listener, error) {
+	fd := -1
+
+	if value := os.Getenv(ListenerFDEnv); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("feather: invalid %s %q", ListenerFDEnv, value)
+		}
+		fd = parsed
+
+		// The children of this process must not adopt the descriptor again
+		os.Unsetenv(ListenerFDEnv)
+	} else if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		if count, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err == nil && count > 0 {
+			fd = listenFDsStart
+		}
+	}
+
+	if fd < 0 {
+		return nil, nil
+	}
+
+	file := os.NewFile(uintptr(fd), "feather-listener")
+	defer file.Close()
+
+	// net.FileListener duplicates the descriptor, the original one can be closed
+	return net.FileListener(file)
+}
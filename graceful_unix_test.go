@@ -0,0 +1,183 @@
+//go:build unix
+
+package feather
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// helperProcessEnv is set in the environment of the test binary re-executed by TestListenGracefulSystemd.
+const helperProcessEnv = "FEATHER_TEST_HELPER_PROCESS"
+
+// socketFile opens a listener on a free port and returns its address and its socket, detached from the listener.
+// The listener is closed: the socket is only reachable through the returned file.
+func socketFile(t *testing.T) (string, *os.File) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return listener.Addr().String(), file
+}
+
+// getBody sends a GET request to addr and returns the body of the response.
+func getBody(t *testing.T, addr string) string {
+	t.Helper()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	response, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("GET %s: %v", addr, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+// adoptedServer returns a server answering "adopted" followed by the process ID.
+func adoptedServer() *Server {
+	server := NewServer()
+	server.ShutdownTimeout = time.Second
+	server.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "adopted "+strconv.Itoa(os.Getpid()))
+	})
+
+	return server
+}
+
+func TestListenGracefulAdoptsFeatherFD(t *testing.T) {
+	addr, file := socketFile(t)
+
+	// ListenGraceful owns the descriptor it adopts, so it receives a copy the test never closes
+	fd, err := syscall.Dup(int(file.Fd()))
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ListenerFDEnv, strconv.Itoa(fd))
+
+	// The signal is delivered to this channel too, so that it cannot kill the test before ListenGraceful handles it
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	unused := freeAddr(t)
+	result := make(chan error, 1)
+	go func() { result <- adoptedServer().ListenGraceful(unused) }()
+
+	waitFor(t, "the adopted listener to serve", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	if body := getBody(t, addr); body != "adopted "+strconv.Itoa(os.Getpid()) {
+		t.Errorf("body = %q", body)
+	}
+	if conn, err := net.Dial("tcp", unused); err == nil {
+		conn.Close()
+		t.Errorf("ListenGraceful bound %s although it inherited a listener", unused)
+	}
+	if value, set := os.LookupEnv(ListenerFDEnv); set {
+		t.Errorf("%s = %q after the adoption, want it unset for the children", ListenerFDEnv, value)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("ListenGraceful = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenGraceful did not return after SIGTERM")
+	}
+}
+
+func TestListenGracefulSystemd(t *testing.T) {
+	addr, file := socketFile(t)
+	defer file.Close()
+
+	// systemd passes the socket as the file descriptor 3, as the first extra file of a child process
+	command := exec.Command(os.Args[0], "-test.run=^TestListenGracefulSystemdHelper$")
+	command.Env = append(os.Environ(), helperProcessEnv+"=1", "LISTEN_FDS=1")
+	command.ExtraFiles = []*os.File{file}
+	command.Stderr = os.Stderr
+	if err := command.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer command.Process.Kill()
+
+	// The test keeps its copy of the socket, but never accepts on it: only the child answers
+	if body := getBody(t, addr); body != "adopted "+strconv.Itoa(command.Process.Pid) {
+		t.Errorf("body = %q, want the answer of the child process %d", body, command.Process.Pid)
+	}
+
+	if err := command.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := command.Wait(); err != nil {
+		t.Errorf("child process: %v", err)
+	}
+}
+
+// TestListenGracefulSystemdHelper is the child process of TestListenGracefulSystemd.
+func TestListenGracefulSystemdHelper(t *testing.T) {
+	if os.Getenv(helperProcessEnv) == "" {
+		t.Skip("helper process of TestListenGracefulSystemd")
+	}
+
+	// systemd sets LISTEN_PID to the PID of the service, unknown to the parent before it starts the child
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := adoptedServer().ListenGraceful("127.0.0.1:0"); err != nil {
+		t.Fatalf("ListenGraceful = %v", err)
+	}
+}
+
+func TestInheritedListenerIgnoresOtherProcesses(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+
+	listener, err := inheritedListener()
+	if listener != nil || err != nil {
+		t.Errorf("inheritedListener = %v, %v, want no listener for the sockets of another process", listener, err)
+	}
+}
+
+func TestInheritedListenerInvalidFD(t *testing.T) {
+	t.Setenv(ListenerFDEnv, "socket")
+
+	if _, err := inheritedListener(); err == nil {
+		t.Error("inheritedListener accepted an invalid descriptor")
+	}
+}
@@ -0,0 +1,165 @@
+package feather
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+/*
+	Group registers routes sharing a common prefix and configuration, such as default response headers.
+
+		api := server.Group("/api")
+		api.DefaultHeaders(map[string]string{"Cache-Control": "no-store", "X-API-Version": "2"})
+		api.GET("/users", listUsers)
+
+	Groups can be nested with Group.Group: the nested group inherits the configuration of its parent.
+*/
+type Group struct {
+	server  *Server                     // server is the Server the routes are registered on.
+	parent  *Group                      // parent is the group the group was created from, nil for a top-level group.
+	prefix  string                      // prefix is the full prefix of the routes of the group, including the prefixes of its parents.
+	headers atomic.Pointer[http.Header] // headers holds the default response headers of the group, replaced on write, see DefaultHeaders.
+	host    *hostPattern                // host restricts the routes of the group to the matching hosts, see Server.HostPattern.
+	err     error                       // err is the error of an invalid host pattern, reported for every route of the group.
+}
+
+/*
+	Group creates a group of routes sharing the given prefix.
+
+	Parameters:
+		- prefix (string): The prefix of the routes of the group (e.g. "/api"), without trailing slash.
+
+	Returns:
+		- *Group: The group, to register routes and configure them.
+*/
+func (server *Server) Group(prefix string) *Group {
+	group := &Group{
+		server: server,
+		prefix: prefix,
+	}
+	group.headers.Store(&http.Header{})
+
+	return group
+}
+
+/*
+	Group creates a nested group, whose prefix is appended to the prefix of the group and which inherits its configuration.
+
+	Parameters:
+		- prefix (string): The prefix appended to the prefix of the group (e.g. "/v2").
+
+	Returns:
+		- *Group: The nested group.
+*/
+func (group *Group) Group(prefix string) *Group {
+	nested := &Group{
+		server: group.server,
+		parent: group,
+		prefix: group.prefix + prefix,
+		host:   group.host,
+		err:    group.err,
+	}
+	nested.headers.Store(&http.Header{})
+
+	return nested
+}
+
+/*
+	DefaultHeaders sets response headers applied to every route of the group and of its nested groups,
+	including the routes registered before the call.
+
+	The headers are set before the middlewares and the handler run, so the handler can override them.
+	The headers of a nested group override the ones of its parent, and the headers set on a route with
+	RouteBuilder.Header override the ones of its group.
+
+	Parameters:
+		- headers (map[string]string): The headers to set, indexed by name.
+
+	Returns:
+		- *Group: The group itself, to chain further calls.
+*/
+func (group *Group) DefaultHeaders(headers map[string]string) *Group {
+	group.server.routesMutex.Lock()
+	defer group.server.routesMutex.Unlock()
+
+	// The map is copied on write, since the requests of the routes already registered read it without locking
+	updated := group.headers.Load().Clone()
+	for name, value := range headers {
+		updated.Set(name, value)
+	}
+	group.headers.Store(&updated)
+
+	return group
+}
+
+/*
+	Handle registers a route of the group, see Server.Handle.
+
+	Parameters:
+		- pattern (string): The pattern of the route, appended to the prefix of the group.
+		- handler (HandlerFunc): The function to execute when the route is matched.
+		- methods (...string): The HTTP methods of the route, "GET" by default.
+
+	Returns:
+		- *RouteBuilder: A builder to configure the registered route.
+		- error: A *RouteError if the route cannot be registered.
+*/
+func (group *Group) Handle(pattern string, handler HandlerFunc, methods ...string) (*RouteBuilder, error) {
+	return group.handleWithOptions(pattern, handler, RouteOptions{Methods: methods})
+}
+
+// handleWithOptions registers a route of the group with the options of RouteOptions, see Server.HandleWithOptions.
+func (group *Group) handleWithOptions(pattern string, handler HandlerFunc, opts RouteOptions) (*RouteBuilder, error) {
+	if group.err != nil {
+		return group.server.routeError(group.prefix+pattern, group.err)
+	}
+
+	_, pathParams := parsePattern(group.prefix + pattern)
+	if err := group.checkHostParams(pathParams); err != nil {
+		return group.server.routeError(group.prefix+pattern, err)
+	}
+
+	return group.server.addRoute(group.prefix+pattern, handler, opts, group)
+}
+
+// handle registers a route of the group for the methods returning a builder only, see Server.handle.
+func (group *Group) handle(pattern string, handler HandlerFunc, method string) *RouteBuilder {
+	builder, _ := group.Handle(pattern, handler, method)
+	return builder
+}
+
+// GET registers a route of the group for the "GET" method, see Server.GET.
+func (group *Group) GET(pattern string, handler HandlerFunc) *RouteBuilder {
+	return group.handle(pattern, handler, http.MethodGet)
+}
+
+// POST registers a route of the group for the "POST" method, see Server.POST.
+func (group *Group) POST(pattern string, handler HandlerFunc) *RouteBuilder {
+	return group.handle(pattern, handler, http.MethodPost)
+}
+
+// PUT registers a route of the group for the "PUT" method, see Server.PUT.
+func (group *Group) PUT(pattern string, handler HandlerFunc) *RouteBuilder {
+	return group.handle(pattern, handler, http.MethodPut)
+}
+
+// PATCH registers a route of the group for the "PATCH" method, see Server.PATCH.
+func (group *Group) PATCH(pattern string, handler HandlerFunc) *RouteBuilder {
+	return group.handle(pattern, handler, http.MethodPatch)
+}
+
+// DELETE registers a route of the group for the "DELETE" method, see Server.DELETE.
+func (group *Group) DELETE(pattern string, handler HandlerFunc) *RouteBuilder {
+	return group.handle(pattern, handler, http.MethodDelete)
+}
+
+// applyHeaders sets the default headers of the group and of its parents, the outermost group first.
+func (group *Group) applyHeaders(header http.Header) {
+	if group.parent != nil {
+		group.parent.applyHeaders(header)
+	}
+
+	for name, values := range *group.headers.Load() {
+		header[name] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,53 @@
+package feather
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestGroupRouteHasGroupWhenPublished(t *testing.T) {
+	server := NewServer()
+	api := server.Group("/api")
+	api.DefaultHeaders(map[string]string{"X-API": "1"})
+
+	builder := api.GET("/users", func(c *Context) {})
+	if err := builder.Err(); err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	if builder.route.group != api {
+		t.Fatal("the route is not attached to its group")
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/users", nil))
+	if got := recorder.Header().Get("X-API"); got != "1" {
+		t.Errorf("X-API = %q, want %q", got, "1")
+	}
+}
+
+func TestGroupDefaultHeadersConcurrentWithRequests(t *testing.T) {
+	server := NewServer()
+	api := server.Group("/api")
+	api.GET("/users", func(c *Context) {})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			api.DefaultHeaders(map[string]string{"X-API": "2"})
+		}()
+		go func() {
+			defer wg.Done()
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+		}()
+	}
+	wg.Wait()
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/users", nil))
+	if got := recorder.Header().Get("X-API"); got != "2" {
+		t.Errorf("X-API = %q, want %q", got, "2")
+	}
+}
@@ -0,0 +1,121 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultHeadersPrecedence(t *testing.T) {
+	server := NewServer()
+
+	api := server.Group("/api")
+	api.DefaultHeaders(map[string]string{"Cache-Control": "no-store", "X-API-Version": "2", "X-Layer": "api"})
+
+	v3 := api.Group("/v3")
+	v3.DefaultHeaders(map[string]string{"X-API-Version": "3"})
+
+	api.GET("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+	v3.GET("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+	v3.GET("/report", func(c *Context) { c.String(http.StatusOK, "report") }).Header("X-Layer", "route")
+	v3.GET("/live", func(c *Context) {
+		c.SetHeader("Cache-Control", "max-age=5")
+		c.String(http.StatusOK, "live")
+	})
+	server.GET("/plain", func(c *Context) { c.String(http.StatusOK, "plain") })
+
+	tests := []struct {
+		url     string
+		headers map[string]string
+	}{
+		{"/api/users", map[string]string{"Cache-Control": "no-store", "X-API-Version": "2", "X-Layer": "api"}},
+		// The nested group inherits the headers of its parent and overrides some of them
+		{"/api/v3/users", map[string]string{"Cache-Control": "no-store", "X-API-Version": "3", "X-Layer": "api"}},
+		// The route overrides its groups
+		{"/api/v3/report", map[string]string{"Cache-Control": "no-store", "X-API-Version": "3", "X-Layer": "route"}},
+		// The handler overrides every default
+		{"/api/v3/live", map[string]string{"Cache-Control": "max-age=5", "X-API-Version": "3"}},
+		// The routes outside of the group are left alone
+		{"/plain", map[string]string{"Cache-Control": "", "X-API-Version": "", "X-Layer": ""}},
+	}
+
+	for _, test := range tests {
+		recorder := server.TestRequest("GET", test.url, nil, nil)
+
+		for name, value := range test.headers {
+			if got := recorder.Header().Values(name); (value == "" && len(got) != 0) || (value != "" && (len(got) != 1 || got[0] != value)) {
+				t.Errorf("%s: %s = %q, want %q", test.url, name, got, value)
+			}
+		}
+	}
+}
+
+func TestDefaultHeadersAppliedToExistingRoutes(t *testing.T) {
+	server := NewServer()
+
+	api := server.Group("/api")
+	v2 := api.Group("/v2")
+	v2.GET("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+
+	// The headers set after the registration apply too, including the ones of the parent group
+	api.DefaultHeaders(map[string]string{"X-API": "yes"})
+	v2.DefaultHeaders(map[string]string{"X-Version": "2"})
+
+	recorder := server.TestRequest("GET", "/api/v2/users", nil, nil)
+	if recorder.Header().Get("X-API") != "yes" || recorder.Header().Get("X-Version") != "2" {
+		t.Errorf("headers = %v", recorder.Header())
+	}
+}
+
+func TestRouteHeader(t *testing.T) {
+	server := NewServer()
+	server.GET("/asset", func(c *Context) { c.String(http.StatusOK, "asset") }).
+		Header("Cache-Control", "public, max-age=60").
+		Header("Cache-Control", "public, max-age=31536000, immutable").
+		Header("X-Asset", "1")
+
+	recorder := server.TestRequest("GET", "/asset", nil, nil)
+	if got := recorder.Header().Values("Cache-Control"); len(got) != 1 || got[0] != "public, max-age=31536000, immutable" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if got := recorder.Header().Get("X-Asset"); got != "1" {
+		t.Errorf("X-Asset = %q", got)
+	}
+}
+
+func TestDefaultHeadersVisibleToMiddlewares(t *testing.T) {
+	server := NewServer()
+
+	api := server.Group("/api")
+	api.DefaultHeaders(map[string]string{"Cache-Control": "no-store"})
+
+	var seen string
+	server.AddMiddleware(func(c *Context) {
+		seen = c.Writer.Header().Get("Cache-Control")
+	})
+	api.GET("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	if seen != "no-store" {
+		t.Errorf("the middleware saw Cache-Control = %q", seen)
+	}
+}
+
+func TestDefaultHeadersNotShared(t *testing.T) {
+	server := NewServer()
+
+	api := server.Group("/api")
+	api.DefaultHeaders(map[string]string{"X-Tags": "a"})
+	api.GET("/users", func(c *Context) {
+		c.Writer.Header().Add("X-Tags", "b")
+		c.String(http.StatusOK, "users")
+	})
+
+	// Appending to a default header must not modify the defaults of the next requests
+	server.TestRequest("GET", "/api/users", nil, nil)
+	recorder := server.TestRequest("GET", "/api/users", nil, nil)
+
+	if got := recorder.Header().Values("X-Tags"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("X-Tags = %q, want [a b]", got)
+	}
+}
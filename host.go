@@ -0,0 +1,155 @@
+package feather
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// hostPattern is the compiled host pattern of a group created by Server.HostPattern.
+type hostPattern struct {
+	pattern string         // pattern is the host pattern the group was created with (e.g. ":tenant.app.com").
+	regex   *regexp.Regexp // regex matches the normalized hosts of the requests.
+	params  []string       // params is the list of the names of the parameters of the host, in order.
+}
+
+/*
+	HostPattern creates a group of routes matching only the requests whose host matches the given pattern,
+	e.g. to serve a multi-tenant application where the tenant is the subdomain.
+
+		tenants := server.HostPattern(":tenant.app.com")
+		tenants.GET("/dashboard", func(c *feather.Context) {
+			tenant := c.Params["tenant"]
+		})
+
+	The pattern is made of labels separated by dots, using the syntax of the path patterns:
+		- a static label (e.g. "app") matches itself, case-insensitively,
+		- ":name" matches a single label, so ":tenant.app.com" matches "acme.app.com" but neither
+				"app.com" nor "eu.acme.app.com",
+		- ":name|regex" matches a single label with a custom regular expression (e.g. ":tenant|[a-z]+"),
+		- "*name" matches one or more labels (e.g. "*sub.app.com" matches "eu.acme.app.com", sub being "eu.acme").
+
+	The dots separate the labels: a literal dot inside a custom regular expression must be escaped as "\."
+	(e.g. ":region|eu\.west"). The host of the request is lowercased and stripped of its port and trailing dot
+	before matching. The host parameters are stored in c.Params along with the path parameters, and a route
+	cannot use a path parameter having the name of a host parameter.
+
+	The routes are matched in the order they are registered: register the routes of the host groups before the
+	routes matching the same paths on any host.
+
+	Parameters:
+		- pattern (string): The host pattern, without scheme nor port.
+
+	Returns:
+		- *Group: The group of the routes restricted to the host. If the pattern is invalid, the routes of the
+				group are not registered and their errors are reported by Server.Err.
+*/
+func (server *Server) HostPattern(pattern string) *Group {
+	group := server.Group("")
+
+	regexPattern, params := parseHostPattern(pattern)
+	re, err := regexp.Compile(regexPattern)
+	if err != nil {
+		group.err = fmt.Errorf("%w: host %q: %v", ErrInvalidPattern, pattern, err)
+		return group
+	}
+
+	group.host = &hostPattern{
+		pattern: pattern,
+		regex:   re,
+		params:  params,
+	}
+
+	return group
+}
+
+// parseHostPattern converts a host pattern (e.g. ":tenant.app.com") into the source of its regular expression
+// and the list of the names of its parameters, in order. See Server.HostPattern.
+func parseHostPattern(pattern string) (string, []string) {
+	labelRegex := make([]string, 0)
+	paramsList := make([]string, 0)
+
+	for _, label := range splitHostLabels(pattern) {
+		name, constraint, custom := strings.Cut(label, "|")
+
+		switch {
+		case custom && label[0] == ':':
+			labelRegex = append(labelRegex, "("+constraint+")")
+			paramsList = append(paramsList, name[1:])
+		case label[0] == ':':
+			labelRegex = append(labelRegex, "([^.]+)")
+			paramsList = append(paramsList, label[1:])
+		case label[0] == '*':
+			labelRegex = append(labelRegex, `([^.]+(?:\.[^.]+)*)`)
+			paramsList = append(paramsList, label[1:])
+		default:
+			labelRegex = append(labelRegex, regexp.QuoteMeta(strings.ToLower(label)))
+		}
+	}
+
+	return "^" + strings.Join(labelRegex, `\.`) + "$", paramsList
+}
+
+// splitHostLabels splits a host pattern on its unescaped dots, ignoring the empty labels.
+func splitHostLabels(pattern string) []string {
+	labels := make([]string, 0)
+	start := 0
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '.':
+			if i > start {
+				labels = append(labels, pattern[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(pattern) {
+		labels = append(labels, pattern[start:])
+	}
+
+	return labels
+}
+
+// normalizeHost lowercases host and strips its port and trailing dot, e.g. "Acme.App.com.:8080" becomes "acme.app.com".
+func normalizeHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// matchHost reports whether host matches the host pattern of the route, if any, and returns the values of the
+// host parameters, in order. The routes registered without a host pattern match every host.
+func (route *Route) matchHost(host string) ([]string, bool) {
+	if route.group == nil || route.group.host == nil {
+		return nil, true
+	}
+
+	matches := route.group.host.regex.FindStringSubmatch(normalizeHost(host))
+	if matches == nil {
+		return nil, false
+	}
+
+	return matches[1:], true
+}
+
+// checkHostParams returns an error if a path parameter has the name of a parameter of the host pattern of the group.
+func (group *Group) checkHostParams(pathParams []string) error {
+	if group.host == nil {
+		return nil
+	}
+
+	for _, name := range pathParams {
+		if slices.Contains(group.host.params, name) {
+			return fmt.Errorf("%w: parameter %q is defined in both the host %q and the path", ErrInvalidPattern, name, group.host.pattern)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package feather
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveHost serves a request for the given URL, whose host is taken from the URL.
+func serveHost(server *Server, method string, url string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, url, nil))
+	return recorder
+}
+
+func TestHostPatternParams(t *testing.T) {
+	server := NewServer()
+	server.HostPattern(":tenant.app.com").GET("/users/:id", func(c *Context) {
+		c.String(200, c.Params["tenant"]+" "+c.Params["id"])
+	})
+
+	recorder := serveHost(server, "GET", "http://Acme.App.com:8080/users/42")
+	if recorder.Code != 200 || recorder.Body.String() != "acme 42" {
+		t.Errorf("got %d %q, want 200 %q", recorder.Code, recorder.Body.String(), "acme 42")
+	}
+}
+
+func TestHostPatternNestedSubdomains(t *testing.T) {
+	server := NewServer()
+	server.HostPattern(":tenant.app.com").GET("/single", func(c *Context) {
+		c.String(200, c.Params["tenant"])
+	})
+	server.HostPattern("*sub.app.com").GET("/nested", func(c *Context) {
+		c.String(200, c.Params["sub"])
+	})
+
+	if recorder := serveHost(server, "GET", "http://eu.acme.app.com/nested"); recorder.Body.String() != "eu.acme" {
+		t.Errorf("sub = %q, want %q", recorder.Body.String(), "eu.acme")
+	}
+	if recorder := serveHost(server, "GET", "http://eu.acme.app.com/single"); recorder.Code != 404 {
+		t.Errorf("a single-label parameter matched a nested subdomain: got %d, want 404", recorder.Code)
+	}
+}
+
+func TestHostPatternConstraint(t *testing.T) {
+	server := NewServer()
+	server.HostPattern(":region|eu\\.west.app.com").GET("/", func(c *Context) {
+		c.String(200, c.Params["region"])
+	})
+
+	if recorder := serveHost(server, "GET", "http://eu.west.app.com/"); recorder.Body.String() != "eu.west" {
+		t.Errorf("region = %q, want %q", recorder.Body.String(), "eu.west")
+	}
+	if recorder := serveHost(server, "GET", "http://us.east.app.com/"); recorder.Code != 404 {
+		t.Errorf("got %d, want 404", recorder.Code)
+	}
+}
+
+func TestHostPatternApexIsNotFound(t *testing.T) {
+	server := NewServer()
+	server.HostPattern(":tenant.app.com").GET("/dashboard", func(c *Context) {})
+
+	recorder := serveHost(server, "GET", "http://app.com/dashboard")
+	if recorder.Code != 404 {
+		t.Errorf("got %d, want 404", recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "" {
+		t.Errorf("Allow = %q, want none", allow)
+	}
+}
+
+func TestHostPatternMethodNotAllowed(t *testing.T) {
+	server := NewServer()
+	server.HostPattern(":tenant.app.com").GET("/dashboard", func(c *Context) {})
+
+	recorder := serveHost(server, "POST", "http://acme.app.com/dashboard")
+	if recorder.Code != 405 {
+		t.Errorf("got %d, want 405", recorder.Code)
+	}
+
+	if recorder := serveHost(server, "POST", "http://app.com/dashboard"); recorder.Code != 404 {
+		t.Errorf("apex host: got %d, want 404", recorder.Code)
+	}
+}
+
+func TestHostPatternConflictingParams(t *testing.T) {
+	server := NewServer()
+	_, err := server.HostPattern(":tenant.app.com").Handle("/:tenant/users", func(c *Context) {})
+
+	if !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("err = %v, want ErrInvalidPattern", err)
+	}
+	if len(server.Routes["GET"]) != 0 {
+		t.Error("the conflicting route has been registered")
+	}
+}
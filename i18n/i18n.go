@@ -0,0 +1,450 @@
+/*
+Package i18n provides message catalogs, locale resolution and translation helpers for Feather applications.
+
+Messages are loaded per locale from JSON or TOML files into a Bundle. The Middleware resolves the locale of each
+request and stores a Translator on the Context, so handlers can call c.T(key, args...) and templates rendered with
+c.Template can use the "t" function.
+*/
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pluralForms lists the CLDR plural categories a message can define.
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// message is a single entry of a catalog. A message without plural forms only has the "other" form.
+type message map[string]string
+
+/*
+Bundle holds the message catalogs of every locale of an application.
+It is safe for concurrent use once the catalogs are loaded.
+*/
+type Bundle struct {
+	// DefaultLocale is the locale used when a message is missing from the locale of the request,
+	// and when the locale of the request cannot be resolved.
+	DefaultLocale string
+
+	// LogMissing enables logging the keys missing from every locale of a fallback chain.
+	// Each missing key is logged once per locale.
+	LogMissing bool
+
+	mutex    sync.RWMutex                  // mutex protects the catalogs map.
+	catalogs map[string]map[string]message // catalogs maps a locale to its messages, indexed by key.
+	missing  sync.Map                      // missing records the "locale/key" pairs already logged as missing.
+}
+
+/*
+NewBundle creates an empty Bundle.
+
+Parameters:
+		- defaultLocale: The locale used as the last fallback (e.g. "en").
+
+Returns:
+		- A pointer to the newly created Bundle.
+*/
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		DefaultLocale: normalizeLocale(defaultLocale),
+		catalogs:      make(map[string]map[string]message),
+	}
+}
+
+/*
+AddMessages adds messages to the catalog of a locale, replacing the messages with the same keys.
+
+Each value is either a string, or a map of plural forms ("zero", "one", "two", "few", "many", "other") to strings.
+Any other map is treated as a namespace: its keys are joined to the parent key with a dot, so that
+{"user": {"greeting": "Hello"}} defines the key "user.greeting".
+
+Parameters:
+		- locale: The locale of the messages (e.g. "fr" or "fr-CA").
+		- messages: The messages to add.
+
+Returns:
+		- error: An error if a value is neither a string nor a map.
+*/
+func (bundle *Bundle) AddMessages(locale string, messages map[string]any) error {
+	flat := make(map[string]message)
+	if err := flattenMessages("", messages, flat); err != nil {
+		return fmt.Errorf("i18n: locale %q: %w", locale, err)
+	}
+
+	locale = normalizeLocale(locale)
+
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+
+	if bundle.catalogs[locale] == nil {
+		bundle.catalogs[locale] = make(map[string]message)
+	}
+	for key, msg := range flat {
+		bundle.catalogs[locale][key] = msg
+	}
+
+	return nil
+}
+
+// flattenMessages converts the raw messages of a catalog into messages indexed by their dotted keys.
+func flattenMessages(prefix string, raw map[string]any, flat map[string]message) error {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value := value.(type) {
+		case string:
+			flat[key] = message{"other": value}
+		case map[string]any:
+			if !isPluralMap(value) {
+				if err := flattenMessages(key, value, flat); err != nil {
+					return err
+				}
+				continue
+			}
+
+			msg := make(message)
+			for form, text := range value {
+				str, ok := text.(string)
+				if !ok {
+					return fmt.Errorf("plural form %q of key %q is not a string", form, key)
+				}
+				msg[form] = str
+			}
+			flat[key] = msg
+		default:
+			return fmt.Errorf("value of key %q must be a string or a map, got %T", key, value)
+		}
+	}
+
+	return nil
+}
+
+// isPluralMap reports whether every key of value is a plural category.
+func isPluralMap(value map[string]any) bool {
+	if len(value) == 0 {
+		return false
+	}
+
+	for form := range value {
+		if !pluralForms[form] {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+LoadFile loads a message catalog from a JSON (.json) or TOML (.toml) file.
+The locale is taken from the name of the file without its extension, e.g. "fr-CA.json" holds the "fr-CA" messages.
+
+Only a subset of TOML is supported: comments, `key = "string"` pairs and `[table]` headers, where a table
+either defines the plural forms of a message or a namespace of keys.
+
+Parameters:
+		- path: The path of the catalog file.
+
+Returns:
+		- error: An error if the file cannot be read or parsed.
+*/
+func (bundle *Bundle) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	extension := filepath.Ext(path)
+	locale := strings.TrimSuffix(filepath.Base(path), extension)
+
+	messages := make(map[string]any)
+	switch strings.ToLower(extension) {
+	case ".json":
+		err = json.Unmarshal(content, &messages)
+	case ".toml":
+		messages, err = parseTOML(string(content))
+	default:
+		return fmt.Errorf("i18n: unsupported catalog format %q", extension)
+	}
+	if err != nil {
+		return fmt.Errorf("i18n: %s: %w", path, err)
+	}
+
+	return bundle.AddMessages(locale, messages)
+}
+
+/*
+LoadDir loads every JSON and TOML catalog of a directory, see LoadFile.
+
+Parameters:
+		- dir: The path of the directory holding the catalogs.
+
+Returns:
+		- error: An error if the directory cannot be read or if a catalog cannot be loaded.
+*/
+func (bundle *Bundle) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (extension != ".json" && extension != ".toml") {
+			continue
+		}
+
+		if err := bundle.LoadFile(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Locales returns the sorted list of the locales having a catalog in the bundle.
+
+Returns:
+		- []string: The locales of the bundle.
+*/
+func (bundle *Bundle) Locales() []string {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	locales := make([]string, 0, len(bundle.catalogs))
+	for locale := range bundle.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+/*
+Localizer returns the Translator of a locale.
+
+Messages are looked up along a fallback chain: the locale itself, then its parent locales ("fr-CA" falls back
+to "fr"), and finally the default locale of the bundle.
+
+Parameters:
+		- locale: The locale to translate into.
+
+Returns:
+		- *Localizer: The Localizer of the locale.
+*/
+func (bundle *Bundle) Localizer(locale string) *Localizer {
+	locale = normalizeLocale(locale)
+	chain := make([]string, 0, 3)
+
+	for current := locale; current != ""; {
+		chain = append(chain, current)
+
+		index := strings.LastIndex(current, "-")
+		if index < 0 {
+			break
+		}
+		current = current[:index]
+	}
+
+	if bundle.DefaultLocale != "" && !contains(chain, bundle.DefaultLocale) {
+		chain = append(chain, bundle.DefaultLocale)
+	}
+
+	return &Localizer{
+		bundle: bundle,
+		locale: locale,
+		chain:  chain,
+	}
+}
+
+// hasLocale reports whether locale, or one of its parent locales, has a catalog in the bundle.
+func (bundle *Bundle) hasLocale(locale string) bool {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	for current := locale; current != ""; {
+		if _, ok := bundle.catalogs[current]; ok {
+			return true
+		}
+
+		index := strings.LastIndex(current, "-")
+		if index < 0 {
+			return false
+		}
+		current = current[:index]
+	}
+
+	return false
+}
+
+// lookup returns the message of key in the catalog of locale.
+func (bundle *Bundle) lookup(locale string, key string) (message, bool) {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	msg, ok := bundle.catalogs[locale][key]
+	return msg, ok
+}
+
+// logMissing logs once that key is missing from every locale of the chain starting at locale.
+func (bundle *Bundle) logMissing(locale string, key string) {
+	if !bundle.LogMissing {
+		return
+	}
+
+	if _, logged := bundle.missing.LoadOrStore(locale+"/"+key, true); !logged {
+		log.Printf("i18n: missing translation for key %q in locale %q", key, locale)
+	}
+}
+
+/*
+Localizer translates messages into a given locale. It implements feather.Translator.
+*/
+type Localizer struct {
+	bundle *Bundle  // bundle holds the message catalogs.
+	locale string   // locale is the locale requested for the Localizer.
+	chain  []string // chain is the list of locales to look messages up into, in order.
+}
+
+/*
+Locale returns the locale of the Localizer.
+
+Returns:
+		- string: The normalized locale (e.g. "fr-CA").
+*/
+func (localizer *Localizer) Locale() string {
+	return localizer.locale
+}
+
+/*
+T returns the message of key, formatted with args.
+
+The first integer argument, if any, is used as the count selecting the plural form of the message, according to
+the plural rules of the locale the message was found in. The message is then formatted with fmt.Sprintf, the
+arguments having no verb in the selected form being ignored, so that "one item" can be selected by a count
+without displaying it. When the key is missing from every locale of the fallback chain, the key itself is returned.
+
+Parameters:
+		- key: The key of the message.
+		- args: The values used to format the message.
+
+Returns:
+		- string: The translated message.
+*/
+func (localizer *Localizer) T(key string, args ...any) string {
+	for _, locale := range localizer.chain {
+		msg, ok := localizer.bundle.lookup(locale, key)
+		if !ok {
+			continue
+		}
+
+		text := msg["other"]
+		if count, ok := firstCount(args); ok {
+			if form, ok := msg[pluralForm(locale, count)]; ok {
+				text = form
+			}
+			if form, ok := msg["zero"]; ok && count == 0 {
+				text = form
+			}
+		}
+
+		if len(args) == 0 {
+			return text
+		}
+		return formatMessage(text, args)
+	}
+
+	localizer.bundle.logMissing(localizer.locale, key)
+	return key
+}
+
+// formatMessage formats text with args like fmt.Sprintf, dropping the trailing args text has no verb for.
+// The args are all kept when text uses explicit argument indexes (e.g. "%[2]s").
+func formatMessage(text string, args []any) string {
+	verbs := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' {
+			continue
+		}
+
+		// Skip the flags, width and precision, a '*' consuming an argument as well
+		for i++; i < len(text) && strings.IndexByte("+-# 0123456789.*[]", text[i]) >= 0; i++ {
+			switch text[i] {
+			case '*':
+				verbs++
+			case '[':
+				return fmt.Sprintf(text, args...)
+			}
+		}
+		if i < len(text) && text[i] != '%' {
+			verbs++
+		}
+	}
+
+	return fmt.Sprintf(text, args[:min(verbs, len(args))]...)
+}
+
+// firstCount returns the first integer of args, used to select the plural form of a message.
+func firstCount(args []any) (int64, bool) {
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case int:
+			return int64(value), true
+		case int8:
+			return int64(value), true
+		case int16:
+			return int64(value), true
+		case int32:
+			return int64(value), true
+		case int64:
+			return value, true
+		case uint:
+			return int64(value), true
+		case uint8:
+			return int64(value), true
+		case uint16:
+			return int64(value), true
+		case uint32:
+			return int64(value), true
+		case uint64:
+			return int64(value), true
+		}
+	}
+
+	return 0, false
+}
+
+// normalizeLocale converts a locale to its canonical form: lowercase language, "-" separator and uppercase region,
+// e.g. "fr_ca" becomes "fr-CA".
+func normalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// contains reports whether list contains value.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,183 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// newTestBundle returns a bundle with English, French and Russian catalogs, English being the default locale.
+func newTestBundle(t *testing.T) *Bundle {
+	t.Helper()
+
+	bundle := NewBundle("en")
+	catalogs := map[string]map[string]any{
+		"en": {
+			"items":    map[string]any{"zero": "no items", "one": "one item", "other": "%d items"},
+			"greeting": "Hello %s",
+			"footer":   "Powered by Feather",
+		},
+		"fr": {
+			"items":    map[string]any{"one": "%d article", "other": "%d articles"},
+			"greeting": "Bonjour %s",
+		},
+		"fr-CA": {
+			"greeting": "Allo %s",
+		},
+		"ru": {
+			"items": map[string]any{"one": "%d предмет", "few": "%d предмета", "many": "%d предметов"},
+		},
+	}
+	for locale, messages := range catalogs {
+		if err := bundle.AddMessages(locale, messages); err != nil {
+			t.Fatalf("AddMessages(%q): %v", locale, err)
+		}
+	}
+
+	return bundle
+}
+
+func TestPluralForms(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	tests := []struct {
+		locale string
+		count  int
+		want   string
+	}{
+		{"en", 0, "no items"},
+		{"en", 1, "one item"},
+		{"en", 2, "2 items"},
+		{"fr", 0, "0 article"},
+		{"fr", 1, "1 article"},
+		{"fr", 5, "5 articles"},
+		{"ru", 1, "1 предмет"},
+		{"ru", 3, "3 предмета"},
+		{"ru", 5, "5 предметов"},
+		{"ru", 21, "21 предмет"},
+		{"ru", 12, "12 предметов"},
+	}
+
+	for _, test := range tests {
+		if got := bundle.Localizer(test.locale).T("items", test.count); got != test.want {
+			t.Errorf("%s: T(items, %d) = %q, want %q", test.locale, test.count, got, test.want)
+		}
+	}
+}
+
+func TestFallbackResolution(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	tests := []struct {
+		locale string
+		key    string
+		arg    any
+		want   string
+	}{
+		{"fr-CA", "greeting", "Ana", "Allo Ana"},       // the locale itself
+		{"fr-CA", "items", 2, "2 articles"},            // the parent locale
+		{"fr_ca", "footer", nil, "Powered by Feather"}, // the default locale
+		{"de", "greeting", "Ana", "Hello Ana"},         // a locale without catalog
+		{"fr", "unknown.key", nil, "unknown.key"},      // a key missing from every locale
+	}
+
+	for _, test := range tests {
+		var args []any
+		if test.arg != nil {
+			args = append(args, test.arg)
+		}
+
+		if got := bundle.Localizer(test.locale).T(test.key, args...); got != test.want {
+			t.Errorf("%s: T(%q) = %q, want %q", test.locale, test.key, got, test.want)
+		}
+	}
+}
+
+func TestFormatMessageIgnoresUnusedArgs(t *testing.T) {
+	tests := []struct {
+		text string
+		args []any
+		want string
+	}{
+		{"one item", []any{1}, "one item"},
+		{"100%% done", []any{1}, "100% done"},
+		{"%d items", []any{2, "extra"}, "2 items"},
+		{"%*d|", []any{4, 7}, "   7|"},
+		{"%[2]s %[1]s", []any{"a", "b"}, "b a"},
+	}
+
+	for _, test := range tests {
+		if got := formatMessage(test.text, test.args); got != test.want {
+			t.Errorf("formatMessage(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestMiddlewareResolvesLocale(t *testing.T) {
+	bundle := newTestBundle(t)
+
+	server := feather.NewServer()
+	server.AddMiddleware(Middleware(bundle))
+	server.GET("/", func(c *feather.Context) {
+		c.String(200, c.T("greeting", "Ana"))
+	})
+
+	tests := []struct {
+		url            string
+		acceptLanguage string
+		want           string
+	}{
+		{"/?lang=fr", "en", "Bonjour Ana"},
+		{"/", "de, fr-CA;q=0.8, en;q=0.5", "Allo Ana"},
+		{"/", "de", "Hello Ana"},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", test.url, nil)
+		request.Header.Set("Accept-Language", test.acceptLanguage)
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if recorder.Body.String() != test.want {
+			t.Errorf("%s with %q: got %q, want %q", test.url, test.acceptLanguage, recorder.Body.String(), test.want)
+		}
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	dir := t.TempDir()
+	content := "# Catalog\nfooter = 'Propulsé par Feather'\n\n[items]\none = \"%d article\"\nother = \"%d articles\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "fr.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := NewBundle("fr")
+	if err := bundle.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+
+	localizer := bundle.Localizer("fr")
+	if got := localizer.T("items", 3); got != "3 articles" {
+		t.Errorf("T(items, 3) = %q", got)
+	}
+	if got := localizer.T("footer"); got != "Propulsé par Feather" {
+		t.Errorf("T(footer) = %q", got)
+	}
+}
+
+func TestParseTOMLRejectsConflicts(t *testing.T) {
+	tests := []string{
+		"user = \"User\"\n[user]\nname = \"Name\"\n",
+		"[user]\nname = \"Name\"\n[root]\n[user.name]\nfirst = \"First\"\n",
+		"title = \"One\"\ntitle = \"Two\"\n",
+	}
+
+	for _, content := range tests {
+		if _, err := parseTOML(content); err == nil {
+			t.Errorf("parseTOML(%q) succeeded, want an error", content)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+Middleware is a middleware function that resolves the locale of each request and stores its Localizer on the
+Context under feather.TranslatorKey, making c.T and the "t" template function available to the handlers.
+
+The locale is resolved from, in order of precedence:
+		1. the "lang" query parameter,
+		2. the "lang" cookie,
+		3. the "Accept-Language" header, honouring the quality values.
+
+The first candidate having a catalog in the bundle (directly or through a parent locale) is used,
+otherwise the default locale of the bundle is.
+
+Parameters:
+		- bundle: The Bundle holding the message catalogs.
+
+Returns:
+		- A feather.HandlerFunc that sets the Translator of the request.
+*/
+func Middleware(bundle *Bundle) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		c.Set(feather.TranslatorKey, bundle.Localizer(resolveLocale(c, bundle)))
+	}
+}
+
+// resolveLocale returns the locale to use for the request, see Middleware.
+func resolveLocale(c *feather.Context, bundle *Bundle) string {
+	candidates := make([]string, 0, 4)
+
+	if lang := c.Query("lang"); lang != "" {
+		candidates = append(candidates, lang)
+	}
+	if cookie, err := c.Cookie("lang"); err == nil && cookie.Value != "" {
+		candidates = append(candidates, cookie.Value)
+	}
+	candidates = append(candidates, parseAcceptLanguage(c.Header("Accept-Language"))...)
+
+	for _, candidate := range candidates {
+		locale := normalizeLocale(candidate)
+		if bundle.hasLocale(locale) {
+			return locale
+		}
+	}
+
+	return bundle.DefaultLocale
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header, sorted by decreasing quality.
+// The wildcard and the languages with a quality of 0 are ignored.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		language string
+		quality  float64
+	}
+
+	languages := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		language, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if language == "" || language == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if quality > 0 {
+			languages = append(languages, weighted{language, quality})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	result := make([]string, len(languages))
+	for i, item := range languages {
+		result[i] = item.language
+	}
+
+	return result
+}
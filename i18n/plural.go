@@ -0,0 +1,74 @@
+package i18n
+
+import "strings"
+
+/*
+pluralForm returns the CLDR plural category of count in the given locale.
+
+Only the cardinal rules of the most common languages are implemented, the other languages use the
+English rule ("one" for 1, "other" otherwise).
+*/
+func pluralForm(locale string, count int64) string {
+	language := strings.SplitN(locale, "-", 2)[0]
+	if count < 0 {
+		count = -count
+	}
+
+	switch language {
+	case "ja", "ko", "zh", "vi", "th", "id", "ms", "tr":
+		// Languages without plural forms
+		return "other"
+	case "fr", "pt":
+		if count == 0 || count == 1 {
+			return "one"
+		}
+		return "other"
+	case "ru", "uk", "be", "sr", "hr", "bs":
+		switch {
+		case count%10 == 1 && count%100 != 11:
+			return "one"
+		case count%10 >= 2 && count%10 <= 4 && (count%100 < 12 || count%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "pl":
+		switch {
+		case count == 1:
+			return "one"
+		case count%10 >= 2 && count%10 <= 4 && (count%100 < 12 || count%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "cs", "sk":
+		switch {
+		case count == 1:
+			return "one"
+		case count >= 2 && count <= 4:
+			return "few"
+		default:
+			return "other"
+		}
+	case "ar":
+		switch {
+		case count == 0:
+			return "zero"
+		case count == 1:
+			return "one"
+		case count == 2:
+			return "two"
+		case count%100 >= 3 && count%100 <= 10:
+			return "few"
+		case count%100 >= 11:
+			return "many"
+		default:
+			return "other"
+		}
+	default:
+		if count == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+parseTOML parses the subset of TOML used by message catalogs: comments, `key = "string"` pairs (basic or
+literal strings) and `[table]` headers, where dotted table names define nested tables. As in TOML, a key cannot
+be defined twice, nor be both a string and a table.
+*/
+func parseTOML(content string) (map[string]any, error) {
+	root := make(map[string]any)
+	current := root
+
+	for number, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", number+1)
+			}
+
+			current = root
+			for _, name := range strings.Split(line[1:len(line)-1], ".") {
+				name = unquoteKey(strings.TrimSpace(name))
+
+				existing, defined := current[name]
+				table, ok := existing.(map[string]any)
+				if defined && !ok {
+					return nil, fmt.Errorf("line %d: table %q conflicts with the key defined with the same name", number+1, name)
+				}
+				if !ok {
+					table = make(map[string]any)
+					current[name] = table
+				}
+				current = table
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key = value", number+1)
+		}
+
+		text, err := parseTOMLString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+
+		key = unquoteKey(strings.TrimSpace(key))
+		if _, defined := current[key]; defined {
+			return nil, fmt.Errorf("line %d: key %q is already defined", number+1, key)
+		}
+		current[key] = text
+	}
+
+	return root, nil
+}
+
+// parseTOMLString parses a basic ("...") or literal ('...') TOML string, followed by an optional comment.
+func parseTOMLString(value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("missing value")
+	}
+
+	switch value[0] {
+	case '"':
+		for i := 1; i < len(value); i++ {
+			if value[i] == '\\' {
+				i++
+				continue
+			}
+			if value[i] == '"' {
+				return strconv.Unquote(value[:i+1])
+			}
+		}
+	case '\'':
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return value[1 : end+1], nil
+		}
+	default:
+		return "", fmt.Errorf("only string values are supported")
+	}
+
+	return "", fmt.Errorf("unterminated string")
+}
+
+// unquoteKey removes the quotes around a quoted TOML key.
+func unquoteKey(key string) string {
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		return key[1 : len(key)-1]
+	}
+
+	return key
+}
@@ -0,0 +1,124 @@
+package feather
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+	Provide registers a singleton dependency (database handle, service, client, ...) shared by every request,
+	retrievable in handlers with Lookup and MustLookup.
+
+	Like the routes, the dependencies should be registered before the server starts.
+
+	Parameters:
+		- key (any): The key of the dependency. An unexported type, or the type of the dependency, avoids collisions.
+		- value (any): The dependency. A value provided again for the same key replaces the previous one.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) Provide(key any, value any) {
+	if server.singletons == nil {
+		server.singletons = make(map[any]any)
+	}
+
+	server.singletons[key] = value
+}
+
+/*
+	ProvideRequest registers a per-request dependency (transaction, request-scoped cache, ...), created by factory
+	the first time it is looked up during a request, then reused until the end of the request.
+
+	When the created value implements io.Closer, it is closed by a post function once the handler has returned.
+
+	Parameters:
+		- key (any): The key of the dependency. A per-request dependency takes precedence over a singleton
+				provided for the same key.
+		- factory (func(c *Context) any): The function creating the dependency for the request.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) ProvideRequest(key any, factory func(c *Context) any) {
+	if server.factories == nil {
+		server.factories = make(map[any]func(c *Context) any)
+	}
+
+	server.factories[key] = factory
+}
+
+// dependency returns the dependency of key, creating and memoizing the per-request dependencies.
+func (c *Context) dependency(key any) (any, bool) {
+	if c.server == nil {
+		return nil, false
+	}
+
+	if value, ok := c.scoped[key]; ok {
+		return value, true
+	}
+
+	if factory, ok := c.server.factories[key]; ok {
+		value := factory(c)
+
+		if c.scoped == nil {
+			c.scoped = make(map[any]any)
+		}
+		c.scoped[key] = value
+
+		if closer, ok := value.(io.Closer); ok {
+			c.Post(func(*Context) {
+				closer.Close()
+			})
+		}
+
+		return value, true
+	}
+
+	value, ok := c.server.singletons[key]
+	return value, ok
+}
+
+// Lookup retrieves the dependency registered under key with Server.Provide or Server.ProvideRequest.
+//
+// Parameters:
+//   - c: The Context of the request.
+//   - key: The key of the dependency.
+//
+// Returns:
+//   - The dependency converted to T, and true when it exists and has the type T.
+//     The zero value of T and false otherwise.
+func Lookup[T any](c *Context, key any) (T, bool) {
+	value, ok := c.dependency(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// MustLookup retrieves the dependency registered under key, like Lookup, and panics if it is missing.
+//
+// Parameters:
+//   - c: The Context of the request.
+//   - key: The key of the dependency.
+//
+// Returns:
+//   - The dependency converted to T. It panics when the dependency does not exist or does not have the type T,
+//     which is a programming error.
+func MustLookup[T any](c *Context, key any) T {
+	value, ok := c.dependency(key)
+	if !ok {
+		panic(fmt.Sprintf("feather: no dependency provided for key %v", key))
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("feather: dependency %v has type %T, not %T", key, value, zero))
+	}
+
+	return typed
+}
@@ -0,0 +1,181 @@
+package feather
+
+import (
+	"strings"
+	"testing"
+)
+
+type (
+	databaseKey struct{}
+	txKey       struct{}
+)
+
+// fakeTx is a per-request dependency recording whether it has been closed.
+type fakeTx struct {
+	id     int
+	closed bool
+}
+
+func (tx *fakeTx) Close() error {
+	tx.closed = true
+	return nil
+}
+
+func TestProvideSingleton(t *testing.T) {
+	database := &strings.Builder{}
+
+	server := NewServer()
+	server.Provide(databaseKey{}, database)
+
+	var seen []*strings.Builder
+	server.GET("/", func(c *Context) {
+		seen = append(seen, MustLookup[*strings.Builder](c, databaseKey{}))
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+	server.TestRequest("GET", "/", nil, nil)
+
+	if len(seen) != 2 || seen[0] != database || seen[1] != database {
+		t.Errorf("the requests got %v, want the singleton twice", seen)
+	}
+}
+
+func TestProvideRequestLifetime(t *testing.T) {
+	var created []*fakeTx
+
+	server := NewServer()
+	server.ProvideRequest(txKey{}, func(c *Context) any {
+		tx := &fakeTx{id: len(created) + 1}
+		created = append(created, tx)
+		return tx
+	})
+
+	server.AddMiddleware(func(c *Context) {
+		tx := MustLookup[*fakeTx](c, txKey{})
+		c.Set("middleware_tx", tx.id)
+	})
+	server.GET("/", func(c *Context) {
+		// The dependency is memoized for the request, and still open while the handler runs
+		tx := MustLookup[*fakeTx](c, txKey{})
+		if tx.id != c.Get("middleware_tx") || tx.closed {
+			t.Errorf("handler got the transaction %d (closed: %v), the middleware got %v", tx.id, tx.closed, c.Get("middleware_tx"))
+		}
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+	server.TestRequest("GET", "/", nil, nil)
+
+	if len(created) != 2 {
+		t.Fatalf("%d transactions created, want one per request", len(created))
+	}
+	for _, tx := range created {
+		if !tx.closed {
+			t.Errorf("the transaction %d has not been closed", tx.id)
+		}
+	}
+}
+
+func TestProvideRequestCreatedLazily(t *testing.T) {
+	created := 0
+
+	server := NewServer()
+	server.ProvideRequest(txKey{}, func(c *Context) any {
+		created++
+		return &fakeTx{}
+	})
+	server.GET("/", func(c *Context) {})
+
+	server.TestRequest("GET", "/", nil, nil)
+
+	if created != 0 {
+		t.Errorf("the factory has been called %d times for a request not looking it up", created)
+	}
+}
+
+func TestProvideRequestClosedAfterPanic(t *testing.T) {
+	var tx *fakeTx
+
+	server := NewServer()
+	server.ProvideRequest(txKey{}, func(c *Context) any {
+		tx = &fakeTx{}
+		return tx
+	})
+	server.GET("/", func(c *Context) {
+		MustLookup[*fakeTx](c, txKey{})
+		panic("boom")
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, nil)
+
+	if recorder.Code != 500 {
+		t.Errorf("status = %d, want 500", recorder.Code)
+	}
+	if tx == nil || !tx.closed {
+		t.Error("the transaction has not been closed after the panic")
+	}
+}
+
+func TestProvideRequestOverridesSingleton(t *testing.T) {
+	server := NewServer()
+	server.Provide(txKey{}, "singleton")
+	server.ProvideRequest(txKey{}, func(c *Context) any { return "request" })
+
+	var got string
+	server.GET("/", func(c *Context) {
+		got = MustLookup[string](c, txKey{})
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+
+	if got != "request" {
+		t.Errorf("got %q, want the per-request dependency", got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	server := NewServer()
+	server.Provide(databaseKey{}, "postgres")
+
+	server.GET("/", func(c *Context) {
+		if value, ok := Lookup[string](c, databaseKey{}); !ok || value != "postgres" {
+			t.Errorf("Lookup = %q, %v", value, ok)
+		}
+		if value, ok := Lookup[int](c, databaseKey{}); ok || value != 0 {
+			t.Errorf("Lookup with the wrong type = %d, %v", value, ok)
+		}
+		if _, ok := Lookup[string](c, txKey{}); ok {
+			t.Error("Lookup of a missing dependency succeeded")
+		}
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+}
+
+func TestMustLookupPanics(t *testing.T) {
+	server := NewServer()
+	server.Provide(databaseKey{}, "postgres")
+
+	tests := []struct {
+		name   string
+		lookup func(c *Context)
+		want   string
+	}{
+		{"missing", func(c *Context) { MustLookup[string](c, txKey{}) }, "no dependency provided"},
+		{"wrong type", func(c *Context) { MustLookup[int](c, databaseKey{}) }, "has type string, not int"},
+	}
+
+	for _, test := range tests {
+		c, _ := NewTestContext("GET", "/", nil)
+		c.server = server
+
+		func() {
+			defer func() {
+				message, _ := recover().(string)
+				if !strings.Contains(message, test.want) {
+					t.Errorf("%s: panic = %q, want %q", test.name, message, test.want)
+				}
+			}()
+			test.lookup(c)
+		}()
+	}
+}
@@ -0,0 +1,42 @@
+package feather
+
+import (
+	"net/http"
+)
+
+// rejectOversized answers the requests whose target exceeds MaxURILength with a 414 status, and the ones with
+// a header value (including Host) exceeding MaxHeaderValueLength with a 431 status, before anything else reads them.
+// It reports whether the request has been rejected.
+func (server *Server) rejectOversized(writer http.ResponseWriter, reader *http.Request) bool {
+	if server.MaxURILength > 0 {
+		// RequestURI is only set on the requests received by an http.Server
+		uri := reader.RequestURI
+		if uri == "" {
+			uri = reader.URL.RequestURI()
+		}
+
+		if len(uri) > server.MaxURILength {
+			http.Error(writer, http.StatusText(http.StatusRequestURITooLong), http.StatusRequestURITooLong)
+			return true
+		}
+	}
+
+	if server.MaxHeaderValueLength > 0 {
+		// The Host header is moved out of reader.Header, while the host patterns still match it
+		if len(reader.Host) > server.MaxHeaderValueLength {
+			http.Error(writer, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+			return true
+		}
+
+		for _, values := range reader.Header {
+			for _, value := range values {
+				if len(value) > server.MaxHeaderValueLength {
+					http.Error(writer, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLengthLimits(t *testing.T) {
+	long := strings.Repeat("a", 2048)
+
+	tests := []struct {
+		name    string
+		url     string
+		host    string
+		headers map[string]string
+		status  int
+	}{
+		{"within the limits", "/items/" + strings.Repeat("a", 900), "", map[string]string{"X-Data": strings.Repeat("a", 1024)}, http.StatusOK},
+		{"long path", "/items/" + long, "", nil, http.StatusRequestURITooLong},
+		{"long query", "/items/1?q=" + long, "", nil, http.StatusRequestURITooLong},
+		{"long header value", "/items/1", "", map[string]string{"X-Data": long}, http.StatusRequestHeaderFieldsTooLarge},
+		{"long cookie", "/items/1", "", map[string]string{"Cookie": "session=" + long}, http.StatusRequestHeaderFieldsTooLarge},
+		{"long host", "/items/1", strings.Repeat("a", 2040) + ".example.com", nil, http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, test := range tests {
+		ran := false
+
+		server := NewServer()
+		server.MaxURILength = 1024
+		server.MaxHeaderValueLength = 1024
+		server.AddMiddleware(func(c *Context) { ran = true })
+		server.GET("/items/:id", func(c *Context) {
+			ran = true
+			c.String(http.StatusOK, "ok")
+		})
+
+		request := httptest.NewRequest("GET", test.url, nil)
+		if test.host != "" {
+			request.Host = test.host
+		}
+		for name, value := range test.headers {
+			request.Header.Set(name, value)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.status)
+		}
+		if ran != (test.status == http.StatusOK) {
+			t.Errorf("%s: the middleware and handler ran = %v", test.name, ran)
+		}
+	}
+}
+
+func TestRequestLengthLimitsBeforePreRouting(t *testing.T) {
+	ran := false
+
+	server := NewServer()
+	server.MaxURILength = 64
+	server.PreRouting(func(writer http.ResponseWriter, request *http.Request) bool {
+		ran = true
+		return false
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/"+strings.Repeat("a", 100), nil))
+
+	if recorder.Code != http.StatusRequestURITooLong || ran {
+		t.Errorf("status = %d, pre-routing hook ran = %v", recorder.Code, ran)
+	}
+}
+
+func TestRequestLengthLimitsDefaults(t *testing.T) {
+	server := NewServer()
+	server.GET("/*path", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		status int
+	}{
+		{"normal traffic", "/search?q=" + strings.Repeat("a", 4096), strings.Repeat("b", 8192), http.StatusOK},
+		{"target over the default", "/" + strings.Repeat("a", DefaultMaxURILength), "", http.StatusRequestURITooLong},
+		{"header over the default", "/", strings.Repeat("b", DefaultMaxHeaderValueLength+1), http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", test.url, nil)
+		if test.header != "" {
+			request.Header.Set("X-Data", test.header)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.status)
+		}
+	}
+}
+
+func TestRequestLengthLimitsDisabled(t *testing.T) {
+	server := NewServer()
+	server.MaxURILength = 0
+	server.MaxHeaderValueLength = 0
+	server.GET("/*path", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	request := httptest.NewRequest("GET", "/"+strings.Repeat("a", DefaultMaxURILength*2), nil)
+	request.Header.Set("X-Data", strings.Repeat("b", DefaultMaxHeaderValueLength*2))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status = %d with the limits disabled, want 200", recorder.Code)
+	}
+}
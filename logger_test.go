@@ -0,0 +1,136 @@
+package feather
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes the records written by a slog.JSONHandler to buffer.
+func logRecords(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		record := make(map[string]any)
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestContextLoggerAttributes(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := NewServer()
+	server.BaseLogger = slog.New(slog.NewJSONHandler(&buffer, nil)).With("service", "api")
+	server.GET("/users/:id", func(c *Context) {
+		c.Set(RequestIDKey, "req-1")
+		c.Set(TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736")
+		c.Logger().Info("loading user", "user_id", c.Params["id"])
+		c.String(200, "ok")
+	})
+
+	server.TestRequest("GET", "/users/42", nil, nil)
+
+	records := logRecords(t, &buffer)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	want := map[string]any{
+		"msg":        "loading user",
+		"service":    "api",
+		"client_ip":  "192.0.2.1:1234",
+		"route":      "/users/:id",
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"user_id":    "42",
+	}
+	for key, value := range want {
+		if records[0][key] != value {
+			t.Errorf("%s = %v, want %v", key, records[0][key], value)
+		}
+	}
+}
+
+func TestContextLoggerOmitsMissingIDs(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := NewServer()
+	server.BaseLogger = slog.New(slog.NewJSONHandler(&buffer, nil))
+	server.GET("/", func(c *Context) {
+		c.Logger().Info("hello")
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+
+	records := logRecords(t, &buffer)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	for _, key := range []string{"request_id", "trace_id"} {
+		if _, ok := records[0][key]; ok {
+			t.Errorf("the record has a %s attribute", key)
+		}
+	}
+}
+
+func TestContextLoggerIsPerRequest(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := NewServer()
+	server.BaseLogger = slog.New(slog.NewJSONHandler(&buffer, nil))
+	server.AddMiddleware(func(c *Context) {
+		if id := c.Header("X-Request-ID"); id != "" {
+			c.SetLogger(c.Logger().With("request_id", id))
+		}
+	})
+	server.GET("/", func(c *Context) {
+		c.Logger().Info("hello")
+	})
+
+	// The pooled Contexts must not keep the logger of a previous request
+	server.TestRequest("GET", "/", nil, map[string]string{"X-Request-ID": "first"})
+	server.TestRequest("GET", "/", nil, nil)
+
+	records := logRecords(t, &buffer)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0]["request_id"] != "first" {
+		t.Errorf("request_id = %v, want first", records[0]["request_id"])
+	}
+	if _, ok := records[1]["request_id"]; ok {
+		t.Errorf("the second request logged with request_id = %v", records[1]["request_id"])
+	}
+}
+
+func TestContextLoggerDefault(t *testing.T) {
+	var buffer bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buffer, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	server := NewServer()
+	server.GET("/", func(c *Context) {
+		c.Logger().Warn("fallback")
+	})
+
+	server.TestRequest("GET", "/", nil, nil)
+
+	records := logRecords(t, &buffer)
+	if len(records) != 1 || records[0]["msg"] != "fallback" || records[0]["route"] != "/" {
+		t.Errorf("records = %v", records)
+	}
+}
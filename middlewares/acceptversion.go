@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+AcceptVersion is a middleware function that reads the version of the API requested by the client from the vendor
+media type of its Accept header, for the APIs versioned by content negotiation rather than by path prefix: with
+"Accept: application/vnd.myapp.v2+json", the version "v2" is stored in the Context under versionKey.
+
+When the header lists several media types, the version of the one with the highest quality ("q" parameter) is
+used. When no media type carries a version, nothing is stored: c.GetString(versionKey) returns an empty string,
+and the handlers use their default version. A "Vary: Accept" header is added to the responses, so that caches
+keep the responses of the different versions apart.
+
+Paired with the FeatureFlag middleware, the routes can be handled by a handler per version:
+
+		server.AddMiddleware(middlewares.AcceptVersion("api_version"))
+
+		isV2 := func(c *feather.Context) bool {
+			return c.GetString("api_version") == "v2"
+		}
+
+		users := server.Group("/users")
+		users.GET("/", listUsersV1).Use(middlewares.FeatureFlag(isV2, listUsersV2))
+		users.GET("/:id", getUserV1).Use(middlewares.FeatureFlag(isV2, getUserV2))
+
+		orders := server.Group("/orders")
+		orders.GET("/", listOrdersV1).Use(middlewares.FeatureFlag(isV2, listOrdersV2))
+		orders.POST("/", createOrderV1).Use(middlewares.FeatureFlag(isV2, createOrderV2))
+
+Parameters:
+		- versionKey: The key of the Context's Data map under which the version is stored, e.g. "api_version".
+
+Returns:
+		- A feather.HandlerFunc that stores the version requested by the client.
+*/
+func AcceptVersion(versionKey string) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		c.AddHeader("Vary", "Accept")
+
+		if version := acceptedVersion(c.Header("Accept")); version != "" {
+			c.Set(versionKey, version)
+		}
+	}
+}
+
+/*
+acceptedVersion returns the version of the vendor media type of an Accept header with the highest quality,
+e.g. "v2" for "application/vnd.myapp.v2+json".
+
+Parameters:
+		- accept: The value of the Accept header.
+
+Returns:
+		- The version, or an empty string if no accepted media type carries a version.
+*/
+func acceptedVersion(accept string) string {
+	version := ""
+	bestQuality := 0.0
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= bestQuality {
+			continue
+		}
+
+		if candidate := mediaTypeVersion(mediaType); candidate != "" {
+			version = candidate
+			bestQuality = quality
+		}
+	}
+
+	return version
+}
+
+// mediaTypeVersion returns the version of a vendor media type, i.e. its last dot-separated segment when it is
+// "v" followed by digits ("application/vnd.myapp.v2+json" gives "v2"), or an empty string.
+func mediaTypeVersion(mediaType string) string {
+	_, subtype, _ := strings.Cut(mediaType, "/")
+	subtype, _, _ = strings.Cut(subtype, "+")
+	if !strings.HasPrefix(subtype, "vnd.") {
+		return ""
+	}
+
+	segment := subtype[strings.LastIndex(subtype, ".")+1:]
+	if len(segment) < 2 || segment[0] != 'v' {
+		return ""
+	}
+	if _, err := strconv.ParseUint(segment[1:], 10, 64); err != nil {
+		return ""
+	}
+
+	return segment
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// bufferedWriter is an http.ResponseWriter which holds back the status code and the body written by the handler,
+// so that a middleware can inspect or rewrite the response before sending it with flush.
+// The headers are the ones of the wrapped writer, so they are shared with it.
+type bufferedWriter struct {
+	http.ResponseWriter
+
+	status int          // status is the HTTP status code written by the handler, 0 if none.
+	body   bytes.Buffer // body holds the bytes written by the handler.
+}
+
+// isInterim reports whether code is the status of an interim response (e.g. 103 Early Hints), which is sent
+// before the final response. The response writers of the middlewares forward them instead of recording them.
+// 101 Switching Protocols is a final response.
+func isInterim(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
+
+// WriteHeader records the status code without sending it, except the interim responses which are forwarded.
+// Only the first call is taken into account.
+func (writer *bufferedWriter) WriteHeader(code int) {
+	if isInterim(code) {
+		writer.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if writer.status == 0 {
+		writer.status = code
+	}
+}
+
+// Write appends the bytes to the buffered body, recording the implicit 200 status.
+func (writer *bufferedWriter) Write(data []byte) (int, error) {
+	writer.WriteHeader(http.StatusOK)
+	return writer.body.Write(data)
+}
+
+// statusCode returns the buffered status code, defaulting to 200 when the handler wrote nothing.
+func (writer *bufferedWriter) statusCode() int {
+	if writer.status == 0 {
+		return http.StatusOK
+	}
+
+	return writer.status
+}
+
+// flush sends the given status code and body to the wrapped writer, with a matching Content-Length header.
+func (writer *bufferedWriter) flush(status int, body []byte) {
+	writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	writer.ResponseWriter.WriteHeader(status)
+	writer.ResponseWriter.Write(body)
+}
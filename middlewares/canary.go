@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+Canary is a middleware function that routes a subset of the requests to a canary handler, enabling gradual
+rollouts of a new implementation.
+
+A request is routed to the canary when it carries the header `header` with the value `value`, or a cookie named
+`header` with the value `value`. When value is empty, carrying the header or the cookie is enough, whatever its
+value: the requests without them are never routed to the canary. The canary handler then handles the request
+instead of the normal route handler, and the request is aborted.
+
+Parameters:
+		- header: The name of the header (or cookie) selecting the canary.
+		- value: The value of the header (or cookie) selecting the canary, empty to select on its presence only.
+		- canaryHandler: The handler called instead of the route handler for the selected requests.
+
+Returns:
+		- A feather.HandlerFunc that routes the selected requests to the canary handler.
+*/
+func Canary(header, value string, canaryHandler feather.HandlerFunc) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		values := c.Request.Header[http.CanonicalHeaderKey(header)]
+		selected := len(values) > 0 && (value == "" || values[0] == value)
+		if !selected {
+			cookie, err := c.Cookie(header)
+			selected = err == nil && (value == "" || cookie.Value == value)
+		}
+
+		if selected {
+			canaryHandler(c)
+			c.Abort()
+		}
+	}
+}
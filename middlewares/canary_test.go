@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// newCanaryServer returns a server answering "stable" on /, or "canary" for the requests selected by Canary.
+func newCanaryServer(header string, value string) *feather.Server {
+	server := feather.NewServer()
+	server.AddMiddleware(Canary(header, value, func(c *feather.Context) {
+		c.String(http.StatusOK, "canary")
+	}))
+	server.GET("/", func(c *feather.Context) {
+		c.String(http.StatusOK, "stable")
+	})
+
+	return server
+}
+
+func TestCanary(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		header string
+		cookie string
+		want   string
+	}{
+		{"no header", "beta", "", "", "stable"},
+		{"matching header", "beta", "beta", "", "canary"},
+		{"other header value", "beta", "alpha", "", "stable"},
+		{"matching cookie", "beta", "", "beta", "canary"},
+		{"other cookie value", "beta", "", "alpha", "stable"},
+		{"empty value without header", "", "", "", "stable"},
+		{"empty value with header", "", "anything", "", "canary"},
+		{"empty value with cookie", "", "", "anything", "canary"},
+	}
+
+	for _, test := range tests {
+		server := newCanaryServer("X-Canary", test.value)
+
+		request := httptest.NewRequest("GET", "/", nil)
+		if test.header != "" {
+			request.Header.Set("X-Canary", test.header)
+		}
+		if test.cookie != "" {
+			request.AddCookie(&http.Cookie{Name: "X-Canary", Value: test.cookie})
+		}
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if recorder.Body.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, recorder.Body.String(), test.want)
+		}
+	}
+}
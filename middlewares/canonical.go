@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+CanonicalHost is a middleware function that redirects every request whose host differs from
+canonicalHost to the same path and query string on the canonical host. It is meant for multi-domain
+deployments, e.g. redirecting "www.example.com" to "example.com" (or the other way around).
+
+The comparison is case-insensitive and includes the port, if any. The scheme of the redirect is
+"https" when the request came over TLS or with an "X-Forwarded-Proto: https" header, "http" otherwise.
+
+Parameters:
+		- canonicalHost: The host every request should be served from (e.g. "example.com").
+		- code: The HTTP status code of the redirect, either 301 (Moved Permanently) or 308 (Permanent Redirect).
+				Any other value falls back to 301.
+
+Returns:
+		- A feather.HandlerFunc that redirects the requests made to a non-canonical host and aborts them.
+*/
+func CanonicalHost(canonicalHost string, code int) feather.HandlerFunc {
+	if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+		code = http.StatusMovedPermanently
+	}
+
+	return func(c *feather.Context) {
+		if strings.EqualFold(c.Request.Host, canonicalHost) {
+			return
+		}
+
+		scheme := "http"
+		if c.Request.TLS != nil || strings.EqualFold(c.Header("X-Forwarded-Proto"), "https") {
+			scheme = "https"
+		}
+
+		c.Redirect(code, scheme+"://"+canonicalHost+c.Request.URL.RequestURI())
+		c.Abort()
+	}
+}
@@ -12,6 +12,10 @@ CORS is a middleware function that sets Cross-Origin Resource Sharing (CORS) hea
 on HTTP responses. It allows the server to specify which origins, methods, and headers
 are permitted for cross-origin requests.
 
+OPTIONS requests are answered as preflight requests with a 200 status, and aborted so that no other
+handler writes the response. The preflight requests are dispatched to the middlewares even when the
+path has no OPTIONS route.
+
 Parameters:
 		- allowedOrigins: A slice of strings specifying the allowed origins.
 		- allowedMethods: A slice of strings specifying the allowed HTTP methods.
@@ -28,6 +32,7 @@ func CORS(allowedOrigins []string, allowedMethods []string, allowedHeaders []str
 
 		if c.Request.Method == http.MethodOptions {
 			c.Status(http.StatusOK)
+			c.Abort()
 			return
 		}
 	}
@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// newCORSServer returns a server with the CORS middleware and GET and POST routes on /api/users.
+func newCORSServer() *feather.Server {
+	server := feather.NewServer()
+	server.AddMiddleware(CORS([]string{"https://app.example.com"}, []string{"GET", "POST"}, []string{"Content-Type"}))
+	server.GET("/api/users", func(c *feather.Context) {
+		c.String(http.StatusOK, "users")
+	})
+	server.POST("/api/users", func(c *feather.Context) {
+		c.String(http.StatusCreated, "created")
+	})
+
+	return server
+}
+
+// preflight sends a CORS preflight request for url to server.
+func preflight(server *feather.Server, url string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodOptions, url, nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	request.Header.Set("Access-Control-Request-Method", "POST")
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestCORSPreflightWithNoOptionsRoute(t *testing.T) {
+	recorder := preflight(newCORSServer(), "/api/users")
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", recorder.Code)
+	}
+
+	want := map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET,POST",
+		"Access-Control-Allow-Headers": "Content-Type",
+	}
+	for name, value := range want {
+		if got := recorder.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCORSPreflightWithOptionsRoute(t *testing.T) {
+	server := newCORSServer()
+
+	handled := false
+	server.Handle("/api/users", func(c *feather.Context) {
+		handled = true
+	}, http.MethodOptions)
+
+	recorder := preflight(server, "/api/users")
+
+	// The middleware answers the preflight and aborts the request before the route's handler
+	if recorder.Code != http.StatusOK || handled {
+		t.Errorf("status = %d, handler ran = %v", recorder.Code, handled)
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	server := newCORSServer()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusCreated || recorder.Body.String() != "created" {
+		t.Errorf("got %d with %q, want the response of the handler", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+}
+
+func TestCORSMethodNotAllowedStillRejected(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newCORSServer().ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/api/users", nil))
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", recorder.Code)
+	}
+}
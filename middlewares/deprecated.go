@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+Deprecated is a middleware function that marks the responses of deprecated routes with the headers of the IETF
+HTTP deprecation notification, so that the API clients can detect the deprecation and the sunset date of the
+routes programmatically: "Deprecation: true", "Sunset: <date>" (RFC 8594) and
+"Link: <link>; rel=\"deprecation\"" pointing to the documentation of the deprecation.
+
+It is meant to be added to the deprecated routes, e.g. every route of a former version of an API:
+
+		deprecated := middlewares.Deprecated(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), "https://example.com/docs/v1-migration")
+
+		v1 := server.Group("/v1")
+		v1.GET("/users", listUsers).Use(deprecated)
+		v1.GET("/users/:id", getUser).Use(deprecated)
+
+Parameters:
+		- sunsetDate: The date from which the routes may stop answering, sent in the HTTP date format
+				(e.g. "Tue, 30 Jun 2026 00:00:00 GMT"). The Sunset header is omitted when it is the zero time.
+		- link: The URL of the documentation of the deprecation. The Link header is omitted when it is empty.
+
+Returns:
+		- A feather.HandlerFunc that adds the deprecation headers to the responses.
+*/
+func Deprecated(sunsetDate time.Time, link string) feather.HandlerFunc {
+	sunset := ""
+	if !sunsetDate.IsZero() {
+		sunset = sunsetDate.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *feather.Context) {
+		c.SetHeader("Deprecation", "true")
+		if sunset != "" {
+			c.SetHeader("Sunset", sunset)
+		}
+		if link != "" {
+			c.AddHeader("Link", "<"+link+">; rel=\"deprecation\"")
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// rawGet serves server with an httptest.Server, sends a raw GET request for path on a TCP connection and
+// returns every byte of the answer, interim responses included.
+func rawGet(t *testing.T, server *feather.Server, path string) string {
+	t.Helper()
+
+	listener := httptest.NewServer(server)
+	defer listener.Close()
+
+	conn, err := net.Dial("tcp", listener.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read: %v (got %q)", err, answer)
+	}
+
+	return string(answer)
+}
+
+func TestMiddlewaresForwardEarlyHints(t *testing.T) {
+	identity := func(c *feather.Context, status int, body []byte) (int, []byte) {
+		return status, body
+	}
+
+	tests := []struct {
+		name       string
+		middleware feather.HandlerFunc
+	}{
+		{"Logging", Logging(LoggingOptions{})},
+		{"Transform", Transform(func(body []byte, contentType string) ([]byte, string) { return body, contentType })},
+		{"TransformResponse", TransformResponse(identity)},
+		{"MinifyHTML", MinifyHTML()},
+		{"Singleflight", Singleflight(func(c *feather.Context) string { return c.Request.URL.Path })},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := feather.NewServer()
+			server.AddMiddleware(test.middleware)
+			server.GET("/", func(c *feather.Context) {
+				c.Preload("/static/app.css", "style")
+				c.EarlyHints()
+				c.HTML(http.StatusOK, "<p>page</p>")
+			})
+
+			answer := rawGet(t, server, "/")
+
+			interim, final, _ := strings.Cut(answer, "\r\n\r\n")
+			if !strings.HasPrefix(interim, "HTTP/1.1 103 Early Hints\r\n") || !strings.Contains(interim, "Link: </static/app.css>; rel=preload; as=style") {
+				t.Fatalf("answer does not start with the 103 response:\n%s", answer)
+			}
+			if !strings.HasPrefix(final, "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(final, "<p>page</p>") {
+				t.Errorf("final response:\n%s", final)
+			}
+		})
+	}
+}
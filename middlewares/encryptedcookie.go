@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// aesCookieCipher is the feather.CookieCipher of the EncryptedCookie middleware, encrypting the values of
+// the cookies with AES-256-GCM. The name of the cookie is authenticated with its value, so that the value
+// of a cookie cannot be replayed under another name.
+type aesCookieCipher struct {
+	aead cipher.AEAD // aead is the AES-256-GCM cipher.
+}
+
+// Encrypt encrypts value with a random nonce, and returns the nonce followed by the ciphertext, base64url-encoded.
+func (cookieCipher *aesCookieCipher) Encrypt(name string, value string) (string, error) {
+	nonce := make([]byte, cookieCipher.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := cookieCipher.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decodes and decrypts a value returned by Encrypt for the same cookie name.
+func (cookieCipher *aesCookieCipher) Decrypt(name string, encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", feather.ErrInvalidCookie, err)
+	}
+
+	nonceSize := cookieCipher.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("%w: value too short", feather.ErrInvalidCookie)
+	}
+
+	value, err := cookieCipher.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", feather.ErrInvalidCookie, err)
+	}
+
+	return string(value), nil
+}
+
+/*
+EncryptedCookie is a middleware function that enables the encrypted cookies of c.SetEncryptedCookie and
+c.EncryptedCookie, for the cookies whose value must stay confidential and untampered on the client (e.g. a
+user ID or a small session state), without a server-side session store:
+
+	encryptedCookies, err := middlewares.EncryptedCookie(key) // 32 bytes, e.g. decoded from an environment variable
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.AddMiddleware(encryptedCookies)
+
+	server.POST("/login", func(c *feather.Context) {
+		c.SetEncryptedCookie(feather.NewCookie("user", userID, feather.CookieSecure(true)))
+	})
+
+The values are encrypted with AES-256-GCM: a random nonce is prepended to the ciphertext, and the result is
+base64url-encoded as the value of the cookie. The name of the cookie is authenticated along with the value.
+The cipher is stored in the Context under feather.CookieCipherKey.
+
+Parameters:
+		- key: The AES-256 key, which must be exactly 32 bytes long. Rotating it invalidates the existing cookies.
+
+Returns:
+		- A feather.HandlerFunc that enables the encrypted cookies.
+		- An error if the key is not 32 bytes long.
+*/
+func EncryptedCookie(key []byte) (feather.HandlerFunc, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("feather: the key of the encrypted cookies must be 32 bytes long, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	cookieCipher := &aesCookieCipher{aead: aead}
+
+	return func(c *feather.Context) {
+		c.Set(feather.CookieCipherKey, cookieCipher)
+	}, nil
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// envelope is the body of the responses wrapped by JSONEnvelope.
+type envelope struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []any           `json:"errors"`
+	Meta   map[string]any  `json:"meta"`
+}
+
+/*
+JSONEnvelope is a middleware function that wraps the JSON responses of the handlers in a consistent envelope,
+for the APIs standardizing their response format:
+
+	{"data": <the response of the handler>, "errors": [], "meta": {...}}
+
+Only the successful responses (status below 400) whose Content-Type is "application/json" and whose body is a
+valid JSON value are wrapped: the error responses, the other content types and the empty bodies are sent as
+written by the handler. The responses are buffered, see TransformResponse for the size cap and the streamed
+responses, and JSONEnvelope should likewise be registered before the middlewares observing the response.
+
+Parameters:
+		- metaFn: The function returning the "meta" object of a response, e.g. its request ID or pagination.
+				It is called once the handler is done. A nil function, or a nil result, gives an empty object.
+
+Returns:
+		- A feather.HandlerFunc that wraps the JSON responses in the envelope.
+*/
+func JSONEnvelope(metaFn func(c *feather.Context) map[string]any) feather.HandlerFunc {
+	return TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+		if status >= http.StatusBadRequest || !json.Valid(body) {
+			return status, body
+		}
+
+		var meta map[string]any
+		if metaFn != nil {
+			meta = metaFn(c)
+		}
+		if meta == nil {
+			meta = map[string]any{}
+		}
+
+		wrapped, err := json.Marshal(envelope{Data: body, Errors: []any{}, Meta: meta})
+		if err != nil {
+			return status, body
+		}
+
+		return status, append(wrapped, '\n')
+	}, TransformOptions{ContentTypes: []string{"application/json"}})
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+FeatureFlag is a middleware function that decides at request time whether a request is handled by a new handler,
+based on an arbitrary function. It is a more flexible alternative to the Canary middleware for programmatic toggles:
+the flag function can consult the user ID, query a feature flag service, or inspect any request attribute.
+
+When flagFn returns true, newHandler handles the request instead of the normal route handler and the request is
+aborted. Otherwise the request falls through to the next middlewares and the route handler.
+
+Parameters:
+		- flagFn: The function deciding whether the feature is enabled for the request.
+		- newHandler: The handler called instead of the route handler when the feature is enabled.
+
+Returns:
+		- A feather.HandlerFunc that routes the requests to newHandler when the feature is enabled.
+*/
+func FeatureFlag(flagFn func(c *feather.Context) bool, newHandler feather.HandlerFunc) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		if flagFn(c) {
+			newHandler(c)
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// fingerprintRegex matches a fingerprinted file name such as "app.3f2a9c1e.js", capturing the name without its hash.
+var fingerprintRegex = regexp.MustCompile(`^(.+)\.[0-9a-f]{6,}(\.[^.]+)$`)
+
+/*
+AssetFingerprint is a middleware function that serves fingerprinted static assets: a request to a URL such as
+"/static/app.3f2a9c1e.js" is answered with the file "app.js" of fsys, with the header
+"Cache-Control: public, max-age=31536000, immutable", and the request is aborted.
+
+As the fingerprint changes whenever the content of the file changes, the assets can be cached forever by the
+browsers. Use feather.AssetPath in the templates to reference the fingerprinted names from the manifest.
+Requests outside of prefix, or to non-fingerprinted names, fall through to the next middlewares and the routes.
+
+Parameters:
+		- prefix: The URL prefix of the assets (e.g. "/static").
+		- fsys: The filesystem holding the assets, under their non-fingerprinted names.
+
+Returns:
+		- A feather.HandlerFunc that serves the fingerprinted assets.
+*/
+func AssetFingerprint(prefix string, fsys fs.FS) feather.HandlerFunc {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	return func(c *feather.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+
+		name, found := strings.CutPrefix(path.Clean(c.Request.URL.Path), prefix)
+		if !found {
+			return
+		}
+
+		dir, file := path.Split(name)
+		matches := fingerprintRegex.FindStringSubmatch(file)
+		if matches == nil {
+			return
+		}
+
+		name = path.Join(dir, matches[1] + matches[2])
+		if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+			c.Error(http.StatusNotFound, "File not found")
+			c.Abort()
+			return
+		}
+
+		c.SetHeader("Cache-Control", "public, max-age=31536000, immutable")
+		http.ServeFileFS(c.Writer, c.Request, fsys, name)
+		c.Abort()
+	}
+}
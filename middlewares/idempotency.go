@@ -0,0 +1,302 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// DefaultIdempotencyMaxBodySize is the size above which Idempotency rejects the body of a request with a
+// 413 Request Entity Too Large, when IdempotencyOptions.MaxBodySize is not set.
+const DefaultIdempotencyMaxBodySize = 1 << 20
+
+/*
+IdempotencyOptions configures the Idempotency middleware.
+*/
+type IdempotencyOptions struct {
+	// Scope returns the client owning the keys of a request, so that two clients using the same Idempotency-Key
+	// never share a response, e.g. the ID of the authenticated user. Nil scopes the keys by the Authorization
+	// header of the request, or by the IP address of the client when it is absent.
+	Scope func(c *feather.Context) string
+
+	// MaxBodySize is the size, in bytes, above which the body of a request is rejected, as it is read in memory
+	// to be hashed. DefaultIdempotencyMaxBodySize (1MB) is used when it is zero.
+	MaxBodySize int64
+}
+
+/*
+IdempotencyRecord is the state stored by the Idempotency middleware for a single Idempotency-Key.
+*/
+type IdempotencyRecord struct {
+	RequestHash string      // RequestHash is the hash of the method, path and body of the request which first used the key.
+	Done        bool        // Done is false while the first request is still being processed, true once its response is recorded.
+	Status      int         // Status is the HTTP status code of the recorded response.
+	Header      http.Header // Header is a copy of the headers of the recorded response, without its cookies.
+	Body        []byte      // Body is the body of the recorded response.
+}
+
+/*
+IdempotencyStore is the storage used by the Idempotency middleware to remember the responses sent
+for each Idempotency-Key. Implementations must be safe for concurrent use.
+*/
+type IdempotencyStore interface {
+	/*
+		Reserve atomically creates a pending record (Done is false) for key if no live record exists.
+
+		It returns (nil, true) when the key has been reserved by the caller, or the existing record and false
+		when the key is already used by a pending or completed request.
+	*/
+	Reserve(key string, requestHash string, ttl time.Duration) (*IdempotencyRecord, bool)
+
+	// Save stores the completed record for key, replacing the pending one. The record expires after ttl.
+	Save(key string, record *IdempotencyRecord, ttl time.Duration)
+
+	// Delete removes the record of key, so the next request using it is processed again.
+	Delete(key string)
+}
+
+/*
+MemoryIdempotencyStore is an in-memory IdempotencyStore. Records are dropped once their TTL has expired.
+It is suitable for a single instance; deployments with several instances need a shared store.
+*/
+type MemoryIdempotencyStore struct {
+	mutex   sync.Mutex
+	records map[string]memoryIdempotencyEntry
+}
+
+// memoryIdempotencyEntry is a record of the MemoryIdempotencyStore along with its expiration date.
+type memoryIdempotencyEntry struct {
+	record    *IdempotencyRecord
+	expiresAt time.Time
+}
+
+/*
+NewMemoryIdempotencyStore creates an empty in-memory IdempotencyStore.
+
+Returns:
+		- A pointer to the newly created MemoryIdempotencyStore.
+*/
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]memoryIdempotencyEntry),
+	}
+}
+
+// Reserve implements IdempotencyStore.
+func (store *MemoryIdempotencyStore) Reserve(key string, requestHash string, ttl time.Duration) (*IdempotencyRecord, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+
+	if entry, ok := store.records[key]; ok {
+		return entry.record, false
+	}
+
+	store.records[key] = memoryIdempotencyEntry{
+		record:    &IdempotencyRecord{RequestHash: requestHash},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, true
+}
+
+// Save implements IdempotencyStore.
+func (store *MemoryIdempotencyStore) Save(key string, record *IdempotencyRecord, ttl time.Duration) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.records[key] = memoryIdempotencyEntry{
+		record:    record,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+// Delete implements IdempotencyStore.
+func (store *MemoryIdempotencyStore) Delete(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.records, key)
+}
+
+// sweep removes the expired records. The caller must hold the mutex.
+func (store *MemoryIdempotencyStore) sweep(now time.Time) {
+	for key, entry := range store.records {
+		if now.After(entry.expiresAt) {
+			delete(store.records, key)
+		}
+	}
+}
+
+// bodyRecorder is an http.ResponseWriter which writes through to the wrapped writer while keeping
+// a copy of the status code and body, so that the response can be stored once the handler is done.
+type bodyRecorder struct {
+	http.ResponseWriter
+
+	status int          // status is the HTTP status code written by the handler.
+	body   bytes.Buffer // body is a copy of every byte written by the handler.
+	wrote  bool         // wrote is set once the handler writes the status code or the body.
+}
+
+// WriteHeader records the status code, except the one of an interim response, and forwards it to the wrapped writer.
+func (recorder *bodyRecorder) WriteHeader(code int) {
+	if !isInterim(code) {
+		recorder.status = code
+		recorder.wrote = true
+	}
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the bytes and forwards them to the wrapped writer.
+func (recorder *bodyRecorder) Write(data []byte) (int, error) {
+	recorder.wrote = true
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+/*
+Idempotency is a middleware function that deduplicates retried requests carrying an "Idempotency-Key" header,
+as used by payment-style APIs.
+
+Only unsafe methods (POST, PUT, PATCH, DELETE, ...) are considered. The first request using a key is processed
+normally and its response (status, headers and body) is recorded in the store. Subsequent requests of the same
+client using the same key then receive the recorded response, with an "Idempotent-Replayed: true" header, without
+running the handler. The keys are scoped by client (see IdempotencyOptions.Scope), and the "Set-Cookie" headers
+are never recorded, as the cookies of a response belong to the client which received it.
+
+A request reusing a key with a different method, path or body receives a 409 Conflict. A request reusing a key
+while the first request is still being processed also receives a 409 Conflict, with a "Retry-After" header.
+Responses with a 5xx status are not recorded, so the client can retry them, nor are the requests ending without
+a response (e.g. when the client disconnected before the handler ran): their key is released instead.
+A request whose body exceeds the size cap receives a 413 Request Entity Too Large.
+
+Parameters:
+		- store: The IdempotencyStore keeping the responses, e.g. NewMemoryIdempotencyStore().
+		- ttl: How long a key and its recorded response are kept.
+		- options: Optional settings, to change how the keys are scoped or the size cap of the bodies.
+
+Returns:
+		- A feather.HandlerFunc that replays the responses of already processed requests.
+*/
+func Idempotency(store IdempotencyStore, ttl time.Duration, options ...IdempotencyOptions) feather.HandlerFunc {
+	var opts IdempotencyOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Scope == nil {
+		opts.Scope = defaultIdempotencyScope
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultIdempotencyMaxBodySize
+	}
+
+	return func(c *feather.Context) {
+		key := c.Header("Idempotency-Key")
+		if key == "" || isSafeMethod(c.Request.Method) {
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.Error(http.StatusRequestEntityTooLarge, "Request body too large")
+			} else {
+				c.Error(http.StatusBadRequest, err.Error())
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		// The stored key is hashed, so that the scope (e.g. an Authorization header) is not kept in the store
+		scoped := sha256.Sum256([]byte(opts.Scope(c) + "\n" + key))
+		key = hex.EncodeToString(scoped[:])
+
+		record, reserved := store.Reserve(key, requestHash, ttl)
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				c.Error(http.StatusConflict, "Idempotency-Key reused with a different request")
+			case !record.Done:
+				c.SetHeader("Retry-After", "1")
+				c.Error(http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			default:
+				for name, values := range record.Header {
+					if name != "Set-Cookie" {
+						c.Writer.Header()[name] = values
+					}
+				}
+				c.Writer.Header().Set("Idempotent-Replayed", "true")
+				c.Writer.WriteHeader(record.Status)
+				c.Writer.Write(record.Body)
+			}
+
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{
+			ResponseWriter: c.Writer,
+			status:         http.StatusOK,
+		}
+		c.Writer = recorder
+
+		c.Post(func(*feather.Context) {
+			if !recorder.wrote || recorder.status >= http.StatusInternalServerError {
+				store.Delete(key)
+				return
+			}
+
+			header := recorder.Header().Clone()
+			header.Del("Set-Cookie")
+
+			store.Save(key, &IdempotencyRecord{
+				RequestHash: requestHash,
+				Done:        true,
+				Status:      recorder.status,
+				Header:      header,
+				Body:        recorder.body.Bytes(),
+			}, ttl)
+		})
+	}
+}
+
+// defaultIdempotencyScope scopes the keys by the Authorization header of the request, or by the IP address
+// of the client when it is absent, see IdempotencyOptions.Scope.
+func defaultIdempotencyScope(c *feather.Context) string {
+	if authorization := c.Header("Authorization"); authorization != "" {
+		return "authorization " + authorization
+	}
+
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+
+	return "ip " + host
+}
+
+// isSafeMethod reports whether method is a safe HTTP method, which never needs idempotency handling.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,237 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// newIdempotentServer returns a server answering POST /payments with the number of times the handler ran.
+func newIdempotentServer(store IdempotencyStore, ttl time.Duration, calls *atomic.Int32) *feather.Server {
+	server := feather.NewServer()
+	server.AddMiddleware(Idempotency(store, ttl))
+	server.POST("/payments", func(c *feather.Context) {
+		c.String(http.StatusCreated, "payment "+strconv.Itoa(int(calls.Add(1))))
+	})
+
+	return server
+}
+
+// postPayment sends a payment with the given key and body.
+func postPayment(server *feather.Server, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+	request.Header.Set("Idempotency-Key", key)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls atomic.Int32
+	server := newIdempotentServer(NewMemoryIdempotencyStore(), time.Minute, &calls)
+
+	first := postPayment(server, "key-1", `{"amount":10}`)
+	second := postPayment(server, "key-1", `{"amount":10}`)
+
+	if calls.Load() != 1 {
+		t.Errorf("the handler ran %d times, want 1", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay: got %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("the replayed response misses the Idempotent-Replayed header")
+	}
+}
+
+func TestIdempotencyConflict(t *testing.T) {
+	var calls atomic.Int32
+	server := newIdempotentServer(NewMemoryIdempotencyStore(), time.Minute, &calls)
+
+	postPayment(server, "key-1", `{"amount":10}`)
+	recorder := postPayment(server, "key-1", `{"amount":20}`)
+
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("got %d, want 409", recorder.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("the handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestIdempotencyConflictWhileProcessing(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	server := feather.NewServer()
+	server.AddMiddleware(Idempotency(NewMemoryIdempotencyStore(), time.Minute))
+	server.POST("/payments", func(c *feather.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusCreated, "payment")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postPayment(server, "key-1", `{"amount":10}`)
+	}()
+
+	<-started
+	recorder := postPayment(server, "key-1", `{"amount":10}`)
+	close(release)
+	<-done
+
+	if recorder.Code != http.StatusConflict || recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("got %d with Retry-After %q, want 409 with a Retry-After header", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	var calls atomic.Int32
+	server := newIdempotentServer(NewMemoryIdempotencyStore(), 20*time.Millisecond, &calls)
+
+	postPayment(server, "key-1", `{"amount":10}`)
+	time.Sleep(40 * time.Millisecond)
+	recorder := postPayment(server, "key-1", `{"amount":10}`)
+
+	if calls.Load() != 2 {
+		t.Errorf("the handler ran %d times, want 2", calls.Load())
+	}
+	if recorder.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("an expired response has been replayed")
+	}
+}
+
+func TestIdempotencyReleasesKeyWithoutResponse(t *testing.T) {
+	var calls atomic.Int32
+	store := NewMemoryIdempotencyStore()
+	server := newIdempotentServer(store, time.Minute, &calls)
+
+	// The client disconnects before the handler runs
+	requestContext, cancel := context.WithCancel(context.Background())
+	server.AddMiddleware(func(c *feather.Context) {
+		if c.Request.Context() == requestContext {
+			cancel()
+		}
+	})
+
+	request := httptest.NewRequest("POST", "/payments", strings.NewReader(`{"amount":10}`)).WithContext(requestContext)
+	request.Header.Set("Idempotency-Key", "key-1")
+	server.ServeHTTP(httptest.NewRecorder(), request)
+
+	recorder := postPayment(server, "key-1", `{"amount":10}`)
+	if recorder.Code != http.StatusCreated || calls.Load() != 1 {
+		t.Errorf("got %d after %d calls, want the retry to be processed", recorder.Code, calls.Load())
+	}
+}
+
+// postPaymentFrom sends a payment with the given key from the client at remoteAddr, with the given headers.
+func postPaymentFrom(server *feather.Server, remoteAddr string, key string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("POST", "/payments", strings.NewReader(`{"amount":10}`))
+	request.RemoteAddr = remoteAddr
+	request.Header.Set("Idempotency-Key", key)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyDoesNotReplayCookies(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(Idempotency(NewMemoryIdempotencyStore(), time.Minute))
+	server.POST("/payments", func(c *feather.Context) {
+		http.SetCookie(c.Writer, &http.Cookie{Name: "session", Value: "first-client"})
+		c.SetHeader("X-Payment", "1")
+		c.String(http.StatusCreated, "payment")
+	})
+
+	first := postPayment(server, "key-1", `{"amount":10}`)
+	second := postPayment(server, "key-1", `{"amount":10}`)
+
+	if first.Header().Get("Set-Cookie") == "" {
+		t.Fatal("the first response misses its cookie")
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || second.Header().Get("X-Payment") != "1" {
+		t.Fatalf("the second response has not been replayed: %v", second.Header())
+	}
+	if cookie := second.Header().Get("Set-Cookie"); cookie != "" {
+		t.Errorf("the replayed response sets the cookie %q of the first client", cookie)
+	}
+}
+
+func TestIdempotencyScopesKeysByClient(t *testing.T) {
+	var calls atomic.Int32
+	server := newIdempotentServer(NewMemoryIdempotencyStore(), time.Minute, &calls)
+
+	postPaymentFrom(server, "203.0.113.1:1000", "key-1", nil)
+
+	// The same client retrying on another connection gets the recorded response
+	if retry := postPaymentFrom(server, "203.0.113.1:2000", "key-1", nil); retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("the retry of the same client has not been replayed")
+	}
+
+	// Another client using the same key is processed normally
+	if other := postPaymentFrom(server, "203.0.113.2:1000", "key-1", nil); other.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("the response of another client has been replayed")
+	}
+
+	// So are the requests of two users behind the same address
+	postPaymentFrom(server, "198.51.100.1:1000", "key-2", map[string]string{"Authorization": "Bearer alice"})
+	if bob := postPaymentFrom(server, "198.51.100.1:1000", "key-2", map[string]string{"Authorization": "Bearer bob"}); bob.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("the response of another user has been replayed")
+	}
+
+	if calls.Load() != 4 {
+		t.Errorf("the handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestIdempotencyCustomScope(t *testing.T) {
+	var calls atomic.Int32
+
+	server := feather.NewServer()
+	server.AddMiddleware(Idempotency(NewMemoryIdempotencyStore(), time.Minute, IdempotencyOptions{
+		Scope: func(c *feather.Context) string { return c.Header("X-Account") },
+	}))
+	server.POST("/payments", func(c *feather.Context) {
+		c.String(http.StatusCreated, "payment "+strconv.Itoa(int(calls.Add(1))))
+	})
+
+	postPaymentFrom(server, "203.0.113.1:1000", "key-1", map[string]string{"X-Account": "acme"})
+	replay := postPaymentFrom(server, "203.0.113.9:1000", "key-1", map[string]string{"X-Account": "acme"})
+
+	if replay.Header().Get("Idempotent-Replayed") != "true" || calls.Load() != 1 {
+		t.Errorf("the request of the same account has not been replayed (%d calls)", calls.Load())
+	}
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	var calls atomic.Int32
+
+	server := feather.NewServer()
+	server.AddMiddleware(Idempotency(NewMemoryIdempotencyStore(), time.Minute, IdempotencyOptions{MaxBodySize: 16}))
+	server.POST("/payments", func(c *feather.Context) {
+		calls.Add(1)
+	})
+
+	recorder := postPayment(server, "key-1", strings.Repeat("x", 17))
+
+	if recorder.Code != http.StatusRequestEntityTooLarge || calls.Load() != 0 {
+		t.Errorf("got %d after %d calls, want 413 without running the handler", recorder.Code, calls.Load())
+	}
+	if ok := postPayment(server, "key-2", strings.Repeat("x", 16)); ok.Code != http.StatusOK {
+		t.Errorf("a body of exactly MaxBodySize bytes got %d, want 200", ok.Code)
+	}
+}
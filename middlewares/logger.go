@@ -1,11 +1,17 @@
 package middlewares
 
 import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"time"
 	"fmt"
 	"strings"
+	"os"
 	"runtime"
+	"slices"
 	
 	"github.com/esmyxvatu/feather"
 )
@@ -35,32 +41,120 @@ type responseRecorder struct {
 	- None
 */
 func (recorder *responseRecorder) WriteHeader(code int) {
-	recorder.status = code
+	// The interim responses (e.g. 103 Early Hints) are followed by the final one
+	if !isInterim(code) {
+		recorder.status = code
+	}
 	recorder.ResponseWriter.WriteHeader(code)
 }
 
+/*
+	Hijack lets the caller take over the connection, delegating to the wrapped writer, so that
+	WebSocket upgrades keep working behind the middlewares using a responseRecorder.
+
+	Parameters:
+	- None
+
+	Returns:
+	- net.Conn: The hijacked connection.
+	- *bufio.ReadWriter: The buffered reader and writer of the connection.
+	- error: An error if the wrapped writer does not implement http.Hijacker, or if the hijack fails.
+*/
+func (recorder *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("feather: the response writer %T does not support hijacking", recorder.ResponseWriter)
+	}
+
+	// A hijacked connection answers with 101 Switching Protocols, not with the default status
+	recorder.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+/*
+	Flush sends the buffered data of the response to the client, delegating to the wrapped writer, so that
+	Server-Sent Events and streamed responses keep working behind the middlewares using a responseRecorder.
+	It does nothing if the wrapped writer does not implement http.Flusher.
+
+	Parameters:
+	- None
+
+	Returns:
+	- None
+*/
+func (recorder *responseRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/*
+	Push initiates an HTTP/2 server push, delegating to the wrapped writer, so that Context.Push keeps
+	working behind the middlewares using a responseRecorder.
+
+	Parameters:
+	- target (string): The path of the resource to push.
+	- opts (*http.PushOptions): The options of the push request, nil for the defaults.
+
+	Returns:
+	- error: feather.ErrPushNotSupported if the wrapped writer does not implement http.Pusher, or the error of the push.
+*/
+func (recorder *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := recorder.ResponseWriter.(http.Pusher)
+	if !ok {
+		return feather.ErrPushNotSupported
+	}
+
+	return pusher.Push(target, opts)
+}
+
+/*
+	LoggingOptions configures the Logging middleware.
+*/
+type LoggingOptions struct {
+	/*
+		LogInit enables the "Logger initialized" line printed when the middleware is created.
+		The source of the line is the location of the Logging() call (file and line), computed once
+		at initialization: it is not related to the requests logged afterwards.
+	*/
+	LogInit bool
+
+	/*
+		Output is the writer of the log lines, e.g. a file or a buffer in the tests. The lines are written
+		to the standard output when it is nil.
+	*/
+	Output io.Writer
+}
+
 /*
 	Logging is a middleware function that logs HTTP requests and responses in a structured format.
 	It provides details such as the timestamp, HTTP status code, client IP, HTTP method, request path, and response time.
 
 	Parameters:
-	- None
+	- options (...LoggingOptions): The options of the middleware. Without options, the initialization line is
+	printed (LogInit is true); passing LoggingOptions{} disables it.
 
 	Returns:
 	- feather.HandlerFunc: A function that can be used as middleware in a Feather application.
 */
-func Logging() feather.HandlerFunc {
-	_, filepath, line, _ := runtime.Caller(1)
-	file := strings.Split(filepath, "/")[len(strings.Split(filepath, "/"))-1]
-	fileName := strings.Split(file, ".")[0]
-
-	date := time.Now()
-	fmt.Printf("\033[1m%s\033[0m │\033[44m %s \033[0m│ %-20s │ %s\n",
-		date.Format("2006/01/02 15:04:05.000"),
-		"DEBUG",
-		fileName + ":" + fmt.Sprint(line),
-		"Logger initialized, using Feather v" + feather.VERSION,
-	)
+func Logging(options ...LoggingOptions) feather.HandlerFunc {
+	config := LoggingOptions{LogInit: true}
+	if len(options) > 0 {
+		config = options[0]
+	}
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	if config.LogInit {
+		// The source of the initialization line is the caller of Logging, not a request
+		_, filepath, line, _ := runtime.Caller(1)
+		file := strings.Split(filepath, "/")[len(strings.Split(filepath, "/"))-1]
+		fileName := strings.Split(file, ".")[0]
+
+		printMessage(output, "DEBUG", "\033[44m", fileName + ":" + fmt.Sprint(line), "Logger initialized, using Feather v" + feather.VERSION)
+	}
 
 	return func(c *feather.Context) {
 		start := time.Now()
@@ -81,29 +175,75 @@ func Logging() feather.HandlerFunc {
 					duration = 0
 				}
 
-				padding := (7 - len(fmt.Sprint(recorder.status))) / 2
+				// The requests whose client disconnected during the upload are shown with the 499 status
+				code := recorder.status
+				if slices.ContainsFunc(c.Errors, func(err error) bool { return errors.Is(err, feather.ErrClientAborted) }) {
+					code = feather.StatusClientClosedRequest
+				}
+
+				padding := (7 - len(fmt.Sprint(code))) / 2
 				status := fmt.Sprintf("%s%s%s",
 					strings.Repeat(" ", padding),
-					fmt.Sprint(recorder.status),
-					strings.Repeat(" ", 7-len(fmt.Sprint(recorder.status))-padding),
+					fmt.Sprint(code),
+					strings.Repeat(" ", 7-len(fmt.Sprint(code))-padding),
 				)
-				status = fmt.Sprintf("%s%s%s", getStatusColor(recorder.status), status, "\033[0m") // Color of the HTTP status
+				status = fmt.Sprintf("%s%s%s", getStatusColor(code), status, "\033[0m") // Color of the HTTP status
 				method := fmt.Sprintf("%s%s%s", getMethodColor(c.Request.Method), c.Request.Method, "\033[0m")   // Color of the method
 
+				// In development mode, show the full request URI and the user agent
+				path := c.Request.URL.Path
+				details := fmt.Sprint(duration)
+				if c.IsDevelopmentMode() {
+					path = c.Request.URL.RequestURI()
+					details = fmt.Sprintf("%s %s", duration, c.Request.UserAgent())
+				}
+
+				// Show the errors recorded on the Context: the first and the last one when there are several
+				switch len(c.Errors) {
+				case 0:
+				case 1:
+					details = fmt.Sprintf("%s \033[31m%v\033[0m", details, c.Errors[0])
+				default:
+					details = fmt.Sprintf("%s \033[31m%v … %v (%d errors)\033[0m", details, c.Errors[0], c.Errors[len(c.Errors)-1], len(c.Errors))
+				}
+
 				// Show the log in the format wanted
-				fmt.Printf("\033[1m%s\033[0m │%s│ %-20s │ %s '%s' \033[2m%s\033[0m\n",
+				fmt.Fprintf(output, "\033[1m%s\033[0m │%s│ %-20s │ %s '%s' \033[2m%s\033[0m\n",
 					start.Format("2006/01/02 15:04:05.000"), // Date/Hour
 					status,                                  // Code HTTP
 					c.ClientIP(),                            // IP
 					method,                                  // Method
-					c.Request.URL.Path,                      // Path
-					duration,                                // Duration
+					path,                                    // Path
+					details,                                 // Duration
 				)
 			},
 		)
 	}
 }
 
+/*
+	printMessage prints a message line in the same format as the request lines of the Logging middleware.
+
+	Parameters:
+	- output (io.Writer): The writer of the line.
+	- level (string): The level of the message (e.g. "DEBUG", "WARN").
+	- color (string): The ANSI background color code of the level.
+	- source (string): The origin of the message (e.g. "main:12" or the name of a middleware).
+	- message (string): The message itself.
+
+	Returns:
+	- None
+*/
+func printMessage(output io.Writer, level string, color string, source string, message string) {
+	fmt.Fprintf(output, "\033[1m%s\033[0m │%s %s \033[0m│ %-20s │ %s\n",
+		time.Now().Format("2006/01/02 15:04:05.000"),
+		color,
+		level,
+		source,
+		message,
+	)
+}
+
 /*
 	getStatusColor determines the appropriate ANSI color code for a given HTTP status code.
 
@@ -115,6 +255,8 @@ func Logging() feather.HandlerFunc {
 */
 func getStatusColor(statusCode int) string {
 	switch {
+	case statusCode == feather.StatusClientClosedRequest:
+		return "\033[100m" // Grey
 	case statusCode >= 200 && statusCode < 300:
 		return "\033[42m" // Green
 	case statusCode >= 300 && statusCode < 400:
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+func TestLoggingOutput(t *testing.T) {
+	var buffer bytes.Buffer
+	server := feather.NewServer()
+	server.AddMiddleware(Logging(LoggingOptions{Output: &buffer}))
+	server.GET("/users", func(c *feather.Context) {
+		c.String(http.StatusOK, "users")
+	})
+
+	server.TestRequest("GET", "/users", nil, nil)
+
+	if lines := strings.Count(buffer.String(), "\n"); lines != 1 {
+		t.Fatalf("logged %d lines, want 1:\n%s", lines, buffer.String())
+	}
+	if !strings.Contains(buffer.String(), "'/users'") || !strings.Contains(buffer.String(), " 200 ") {
+		t.Errorf("log line misses the path or the status:\n%s", buffer.String())
+	}
+}
+
+func TestLoggingErrors(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := feather.NewServer()
+	server.AddMiddleware(Logging(LoggingOptions{Output: &buffer}))
+	server.GET("/import", func(c *feather.Context) {
+		c.PushError(errors.New("row 3: invalid date"))
+		c.PushError(errors.New("row 7: missing amount"))
+		c.String(http.StatusUnprocessableEntity, "invalid rows")
+	})
+
+	server.TestRequest("GET", "/import", nil, nil)
+
+	for _, want := range []string{"row 3: invalid date", "row 7: missing amount", "(2 errors)", " 422 "} {
+		if !strings.Contains(buffer.String(), want) {
+			t.Errorf("log line misses %q:\n%s", want, buffer.String())
+		}
+	}
+}
+
+func TestLoggingInitLineOutput(t *testing.T) {
+	var buffer bytes.Buffer
+	Logging(LoggingOptions{LogInit: true, Output: &buffer})
+
+	if !strings.Contains(buffer.String(), "Logger initialized") || !strings.Contains(buffer.String(), "logger_test:") {
+		t.Errorf("initialization line = %q, want it written to Output with the location of the call", buffer.String())
+	}
+}
@@ -0,0 +1,152 @@
+package middlewares
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// optionalClosingTags lists the elements whose closing tag can be omitted (HTML Living Standard, "Optional tags").
+var optionalClosingTags = map[string]bool{
+	"li": true, "dt": true, "dd": true, "option": true,
+	"thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+	"html": true, "body": true,
+}
+
+// rawTextElements lists the elements whose content is kept untouched by the minifier.
+var rawTextElements = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+
+/*
+MinifyHTML is a middleware function that minifies the HTML responses: comments are stripped (except conditional
+comments), whitespace runs are collapsed into a single space, and optional closing tags (</li>, </td>, </tr>, ...)
+are removed. The content of <pre>, <textarea>, <script> and <style> elements is left untouched.
+
+Responses are buffered, and only the ones whose Content-Type is "text/html" (with any parameter, such as a charset)
+once the handler has run are minified; the others are sent unchanged. Like Transform, MinifyHTML should be registered
+before the middlewares observing the response, such as Logging.
+
+Parameters:
+		- None
+
+Returns:
+		- A feather.HandlerFunc that minifies the HTML responses.
+*/
+func MinifyHTML() feather.HandlerFunc {
+	return func(c *feather.Context) {
+		buffer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = buffer
+
+		c.Post(func(*feather.Context) {
+			body := buffer.body.Bytes()
+
+			mediaType, _, err := mime.ParseMediaType(buffer.Header().Get("Content-Type"))
+			if err == nil && mediaType == "text/html" {
+				body = []byte(minifyHTML(string(body)))
+			}
+
+			buffer.flush(buffer.statusCode(), body)
+		})
+	}
+}
+
+// minifyHTML returns the minified version of an HTML document, see MinifyHTML.
+func minifyHTML(document string) string {
+	var builder strings.Builder
+	builder.Grow(len(document))
+
+	lastSpace := false
+	for i := 0; i < len(document); {
+		char := document[i]
+
+		switch {
+		case strings.HasPrefix(document[i:], "<!--"):
+			end := strings.Index(document[i+4:], "-->")
+			if end < 0 {
+				// Unterminated comment: drop the rest of the document, as a browser would
+				i = len(document)
+				continue
+			}
+			comment := document[i : i+4+end+3]
+
+			// Conditional comments are meaningful for old browsers
+			if strings.HasPrefix(comment, "<!--[if") || strings.HasPrefix(comment, "<!--<![endif]") {
+				builder.WriteString(comment)
+				lastSpace = false
+			}
+			i += len(comment)
+
+		case char == '<':
+			end := tagEnd(document, i)
+			tag := document[i:end]
+			name, closing := tagName(tag)
+
+			if closing && optionalClosingTags[name] {
+				i = end
+				continue
+			}
+
+			builder.WriteString(tag)
+			lastSpace = false
+			i = end
+
+			if !closing && rawTextElements[name] {
+				closeTag := "</" + name
+				index := strings.Index(strings.ToLower(document[i:]), closeTag)
+				if index < 0 {
+					index = len(document) - i
+				}
+
+				builder.WriteString(document[i : i+index])
+				i += index
+			}
+
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\f':
+			if !lastSpace {
+				builder.WriteByte(' ')
+				lastSpace = true
+			}
+			i++
+
+		default:
+			builder.WriteByte(char)
+			lastSpace = false
+			i++
+		}
+	}
+
+	return strings.TrimSpace(builder.String())
+}
+
+// tagEnd returns the index following the '>' closing the tag starting at start, skipping quoted attribute values.
+func tagEnd(document string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(document); i++ {
+		switch char := document[i]; {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '>':
+			return i + 1
+		}
+	}
+
+	return len(document)
+}
+
+// tagName returns the lowercase name of a tag, and whether it is a closing tag.
+func tagName(tag string) (string, bool) {
+	name := strings.TrimPrefix(tag, "<")
+	closing := strings.HasPrefix(name, "/")
+	name = strings.TrimPrefix(name, "/")
+
+	end := strings.IndexAny(name, " \t\n\r\f/>")
+	if end >= 0 {
+		name = name[:end]
+	}
+
+	return strings.ToLower(name), closing
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+RequestID is a middleware function that assigns an ID to every request, to correlate the logs of a request
+across handlers and services.
+
+The ID sent by the client (or a proxy) in the "X-Request-ID" header is reused when present and at most 128
+characters long, otherwise a random 32-character hexadecimal ID is generated. The ID is stored in the Context
+under feather.RequestIDKey, retrievable with c.RequestID(), and sent back in the "X-Request-ID" response header.
+It is also added as the "request_id" attribute of the request logger returned by c.Logger().
+
+Parameters:
+		- None
+
+Returns:
+		- A feather.HandlerFunc that assigns an ID to the request.
+*/
+func RequestID() feather.HandlerFunc {
+	return func(c *feather.Context) {
+		id := c.Header("X-Request-ID")
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+
+		c.SetLogger(c.Logger().With("request_id", id))
+		c.Set(feather.RequestIDKey, id)
+		c.SetHeader("X-Request-ID", id)
+	}
+}
+
+// newRequestID generates a random 32-character hexadecimal request ID.
+func newRequestID() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+
+	return hex.EncodeToString(buffer)
+}
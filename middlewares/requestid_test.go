@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// lastRecord decodes the last record written by a slog.JSONHandler to buffer.
+func lastRecord(t *testing.T, buffer *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	record := make(map[string]any)
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("invalid record %q: %v", lines[len(lines)-1], err)
+	}
+
+	return record
+}
+
+func TestRequestIDEnrichesLogger(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := feather.NewServer()
+	server.BaseLogger = slog.New(slog.NewJSONHandler(&buffer, nil))
+	server.AddMiddleware(RequestID())
+	server.GET("/", func(c *feather.Context) {
+		c.Logger().Info("handled")
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, map[string]string{"X-Request-ID": "abc-123"})
+	if got := recorder.Header().Get("X-Request-ID"); got != "abc-123" {
+		t.Errorf("X-Request-ID = %q", got)
+	}
+	if record := lastRecord(t, &buffer); record["request_id"] != "abc-123" {
+		t.Errorf("request_id = %v, want abc-123", record["request_id"])
+	}
+
+	// A generated ID is logged too
+	recorder = server.TestRequest("GET", "/", nil, nil)
+	id := recorder.Header().Get("X-Request-ID")
+	if len(id) != 32 {
+		t.Fatalf("generated ID = %q", id)
+	}
+	if record := lastRecord(t, &buffer); record["request_id"] != id {
+		t.Errorf("request_id = %v, want %s", record["request_id"], id)
+	}
+}
+
+func TestTraceContextEnrichesLogger(t *testing.T) {
+	var buffer bytes.Buffer
+
+	server := feather.NewServer()
+	server.BaseLogger = slog.New(slog.NewJSONHandler(&buffer, nil))
+	server.AddMiddleware(RequestID())
+	server.AddMiddleware(TraceContext())
+	server.GET("/orders/:id", func(c *feather.Context) {
+		c.Logger().Info("handled")
+	})
+
+	server.TestRequest("GET", "/orders/7", nil, map[string]string{
+		"X-Request-ID": "abc-123",
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+
+	record := lastRecord(t, &buffer)
+	want := map[string]any{
+		"request_id": "abc-123",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"route":      "/orders/:id",
+		"client_ip":  "192.0.2.1:1234",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	// Each attribute is logged once, even though the logger is derived after the IDs are stored
+	if count := strings.Count(buffer.String(), `"request_id"`); count != 1 {
+		t.Errorf("request_id appears %d times: %s", count, buffer.String())
+	}
+}
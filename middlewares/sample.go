@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+SampleOption configures the Sample middleware.
+*/
+type SampleOption func(config *sampleConfig)
+
+// sampleConfig holds the options of the Sample middleware.
+type sampleConfig struct {
+	byRequestID bool // byRequestID enables the deterministic sampling based on the request ID.
+}
+
+/*
+SampleByRequestID makes the sampling decision deterministic: it is derived from a hash of the request ID
+(see the RequestID middleware) instead of a random number, so that every service seeing the same request ID
+takes the same decision. Requests without a request ID are sampled randomly.
+
+Returns:
+		- A SampleOption enabling the deterministic sampling.
+*/
+func SampleByRequestID() SampleOption {
+	return func(config *sampleConfig) {
+		config.byRequestID = true
+	}
+}
+
+/*
+Sample is a middleware function that only invokes the inner middleware for a fraction of the requests,
+e.g. to log a sample of the traffic of a high-traffic service: Sample(0.1, Logging()).
+
+Parameters:
+		- rate: The fraction of requests for which inner is invoked, between 0.0 (never) and 1.0 (always).
+		- inner: The middleware to invoke for the sampled requests.
+		- options: The SampleOption configuring the sampling, such as SampleByRequestID().
+
+Returns:
+		- A feather.HandlerFunc that invokes inner for the sampled requests only.
+*/
+func Sample(rate float64, inner feather.HandlerFunc, options ...SampleOption) feather.HandlerFunc {
+	config := &sampleConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	return func(c *feather.Context) {
+		if sampleValue(c, config) < rate {
+			inner(c)
+		}
+	}
+}
+
+// sampleValue returns the number in [0, 1) compared to the sampling rate for the request.
+func sampleValue(c *feather.Context, config *sampleConfig) float64 {
+	if id := c.RequestID(); config.byRequestID && id != "" {
+		hash := fnv.New32a()
+		hash.Write([]byte(id))
+
+		return float64(hash.Sum32()) / (1 << 32)
+	}
+
+	return rand.Float64()
+}
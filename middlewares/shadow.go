@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// shadowTimeout is the hard timeout of the requests dispatched to the secondary server by Shadow.
+const shadowTimeout = 500 * time.Millisecond
+
+// discardRecorder is an http.ResponseWriter which only records the status code, discarding the body.
+type discardRecorder struct {
+	header http.Header // header holds the headers set by the handler.
+	status int         // status is the HTTP status code written by the handler.
+}
+
+// Header returns the headers of the response.
+func (recorder *discardRecorder) Header() http.Header {
+	return recorder.header
+}
+
+// WriteHeader records the status code, ignoring the interim responses.
+func (recorder *discardRecorder) WriteHeader(code int) {
+	if recorder.status == 0 && !isInterim(code) {
+		recorder.status = code
+	}
+}
+
+// Write discards the bytes, recording the implicit 200 status when no status has been written.
+func (recorder *discardRecorder) Write(data []byte) (int, error) {
+	recorder.WriteHeader(http.StatusOK)
+	return len(data), nil
+}
+
+/*
+Shadow is a middleware function that duplicates every request to a secondary server, e.g. the new version of an
+API during a migration, and logs the requests for which both servers answered with different status codes.
+
+The copy of the request is dispatched once the primary server has answered, so that both servers never handle
+the same request at the same time, and the client only ever receives the response of the primary server, which
+is not delayed. The copy runs in its own goroutine with a detached context, cancelled after 500ms. A secondary
+server answering later is reported as timed out and its response is ignored: the limit bounds how long the
+comparison waits, but a secondary handler ignoring the cancellation of its context keeps running until it returns.
+
+Parameters:
+		- secondary: The server receiving the copies of the requests.
+
+Returns:
+		- A feather.HandlerFunc that shadows the requests to the secondary server.
+*/
+func Shadow(secondary *feather.Server) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		// The copy is taken before the handlers of the primary server modify the request
+		clone := c.Request.Clone(context.Background())
+
+		recorder := &responseRecorder{
+			ResponseWriter: c.Writer,
+			status:         http.StatusOK,
+		}
+		c.Writer = recorder
+
+		method, path := c.Request.Method, c.Request.URL.Path
+		c.Post(func(*feather.Context) {
+			primary := recorder.status
+
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+				defer cancel()
+
+				status, ok := shadowStatus(ctx, secondary, clone.WithContext(ctx), body)
+				switch {
+				case !ok:
+					printMessage(os.Stdout, "WARN", "\033[43m", "Shadow", fmt.Sprintf("%s '%s': secondary timed out after %s", method, path, shadowTimeout))
+				case status != primary:
+					printMessage(os.Stdout, "WARN", "\033[43m", "Shadow", fmt.Sprintf("%s '%s': primary answered %d, secondary answered %d", method, path, primary, status))
+				}
+			}()
+		})
+	}
+}
+
+// shadowStatus serves the request on the secondary server in a new goroutine and returns the status code of its
+// response, or false when ctx is done first.
+func shadowStatus(ctx context.Context, secondary *feather.Server, request *http.Request, body []byte) (int, bool) {
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	result := make(chan int, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				result <- http.StatusInternalServerError
+			}
+		}()
+
+		recorder := &discardRecorder{header: make(http.Header)}
+		secondary.ServeHTTP(recorder, request)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		result <- recorder.status
+	}()
+
+	select {
+	case status := <-result:
+		return status, true
+	case <-ctx.Done():
+		return 0, false
+	}
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+func TestShadowDuplicatesRequestAfterPrimary(t *testing.T) {
+	var primaryDone atomic.Bool
+	received := make(chan string, 1)
+
+	secondary := feather.NewServer()
+	secondary.POST("/orders", func(c *feather.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if !primaryDone.Load() {
+			received <- "started before the primary answered"
+			return
+		}
+		received <- string(body)
+	})
+
+	server := feather.NewServer()
+	server.AddMiddleware(Shadow(secondary))
+	server.POST("/orders", func(c *feather.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, string(body))
+		primaryDone.Store(true)
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("POST", "/orders", strings.NewReader("order")))
+
+	if recorder.Code != http.StatusCreated || recorder.Body.String() != "order" {
+		t.Errorf("primary: got %d %q, want 201 %q", recorder.Code, recorder.Body.String(), "order")
+	}
+
+	select {
+	case got := <-received:
+		if got != "order" {
+			t.Errorf("secondary: got %q, want the body %q", got, "order")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the secondary server did not receive the request")
+	}
+}
+
+func TestShadowTimeout(t *testing.T) {
+	cancelled := make(chan time.Duration, 1)
+
+	secondary := feather.NewServer()
+	secondary.GET("/", func(c *feather.Context) {
+		start := time.Now()
+		<-c.Request.Context().Done()
+		cancelled <- time.Since(start)
+	})
+
+	server := feather.NewServer()
+	server.AddMiddleware(Shadow(secondary))
+	server.GET("/", func(c *feather.Context) {
+		c.String(http.StatusOK, "primary")
+	})
+
+	start := time.Now()
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("the primary response took %s, the secondary server must not delay it", elapsed)
+	}
+
+	select {
+	case elapsed := <-cancelled:
+		if elapsed < shadowTimeout-50*time.Millisecond {
+			t.Errorf("the secondary request was cancelled after %s, want %s", elapsed, shadowTimeout)
+		}
+	case <-time.After(2 * shadowTimeout):
+		t.Fatal("the secondary request was not cancelled")
+	}
+}
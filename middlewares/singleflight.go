@@ -0,0 +1,215 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+SingleflightOptions configures the Singleflight middleware.
+*/
+type SingleflightOptions struct {
+	// MaxWait is the maximum duration a request waits for the identical request being handled. Once exceeded,
+	// the request is handled normally. Zero means waiting as long as the client does.
+	MaxWait time.Duration
+
+	// Bypass selects the requests which are never coalesced, e.g. the authenticated ones. Nil bypasses none.
+	Bypass func(c *feather.Context) bool
+}
+
+// flight is a request being handled on behalf of the identical requests waiting for its response.
+type flight struct {
+	done chan struct{} // done is closed once the response is recorded, or once the request ends without one.
+	once sync.Once     // once guards the closing of done.
+
+	shared bool        // shared is true when the response can be sent to the waiting requests.
+	status int         // status is the HTTP status code of the response.
+	header http.Header // header is a copy of the headers of the response.
+	body   []byte      // body is the body of the response.
+}
+
+// finish records the response of the flight, if it can be shared, and releases the waiting requests.
+// Only the first call is taken into account.
+func (f *flight) finish(recorder *flightRecorder) {
+	f.once.Do(func() {
+		if recorder != nil && recorder.shareable() {
+			f.shared = true
+			f.status = recorder.status
+			f.header = recorder.Header().Clone()
+			f.body = recorder.body.Bytes()
+		}
+		close(f.done)
+	})
+}
+
+// flightRecorder is an http.ResponseWriter which writes through to the wrapped writer while keeping a copy of the
+// status code and body, so that the response can be shared with the waiting requests. A response which is flushed
+// is considered as streamed, and is not shared.
+type flightRecorder struct {
+	http.ResponseWriter
+
+	status   int          // status is the HTTP status code written by the handler.
+	body     bytes.Buffer // body is a copy of every byte written by the handler.
+	wrote    bool         // wrote is set once the handler writes the status code or the body.
+	streamed bool         // streamed is set once the handler flushes the response.
+}
+
+// shareable reports whether the recorded response can be sent to the waiting requests: the handler must have
+// written a response, which is neither streamed nor setting cookies, since the cookies belong to one client.
+func (recorder *flightRecorder) shareable() bool {
+	_, cookies := recorder.Header()["Set-Cookie"]
+	return recorder.wrote && !recorder.streamed && !cookies
+}
+
+// WriteHeader records the status code, except the one of an interim response, and forwards it to the wrapped writer.
+func (recorder *flightRecorder) WriteHeader(code int) {
+	if !isInterim(code) {
+		recorder.status = code
+		recorder.wrote = true
+	}
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the bytes and forwards them to the wrapped writer.
+func (recorder *flightRecorder) Write(data []byte) (int, error) {
+	recorder.wrote = true
+	if !recorder.streamed {
+		recorder.body.Write(data)
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Flush marks the response as streamed and flushes the wrapped writer.
+func (recorder *flightRecorder) Flush() {
+	recorder.streamed = true
+	recorder.body.Reset()
+	http.NewResponseController(recorder.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (recorder *flightRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+/*
+Singleflight is a middleware function that coalesces the concurrent identical GET and HEAD requests, e.g. on an
+expensive endpoint hit by many clients at once: only the first request runs the handler, while the others wait
+for its response and receive a copy of it (status, headers and body), without running the handler.
+
+The waiting requests keep the headers already set by their own middlewares (e.g. a request ID). A response
+which is flushed by the handler (a streamed response) or which sets cookies is not shared: the waiting requests
+are then handled normally, as are the requests whose identical request ended without a response (e.g. when its
+client disconnected before the handler ran).
+
+Parameters:
+		- keyFunc: The function returning the key identifying the identical requests, e.g. their URL.
+				The requests with an empty key are not coalesced.
+		- options: Optional settings, to limit the waiting duration or to bypass some requests.
+
+Returns:
+		- A feather.HandlerFunc that coalesces the identical requests.
+*/
+func Singleflight(keyFunc func(c *feather.Context) string, options ...SingleflightOptions) feather.HandlerFunc {
+	var opts SingleflightOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	var mutex sync.Mutex
+	flights := make(map[string]*flight)
+
+	return func(c *feather.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		if opts.Bypass != nil && opts.Bypass(c) {
+			return
+		}
+
+		key := keyFunc(c)
+		if key == "" {
+			return
+		}
+		key = c.Request.Method + " " + key
+
+		mutex.Lock()
+		current, waiting := flights[key]
+		if !waiting {
+			current = &flight{done: make(chan struct{})}
+			flights[key] = current
+		}
+		mutex.Unlock()
+
+		if waiting {
+			waitFlight(c, current, opts.MaxWait)
+			return
+		}
+
+		recorder := &flightRecorder{
+			ResponseWriter: c.Writer,
+			status:         http.StatusOK,
+		}
+		c.Writer = recorder
+
+		finish := func(recorder *flightRecorder) {
+			// The flight may already be replaced by a new leader when the client disconnected before
+			mutex.Lock()
+			if flights[key] == current {
+				delete(flights, key)
+			}
+			mutex.Unlock()
+
+			current.finish(recorder)
+		}
+
+		// Release the waiting requests as soon as the client disconnects, without waiting for the handler to return
+		stop := context.AfterFunc(c.Request.Context(), func() {
+			finish(nil)
+		})
+
+		c.Post(func(*feather.Context) {
+			stop()
+			finish(recorder)
+		})
+	}
+}
+
+// waitFlight waits for the response of the identical request f and sends a copy of it, aborting the request.
+// The request is handled normally when the response cannot be shared or is not received in time.
+func waitFlight(c *feather.Context, f *flight, maxWait time.Duration) {
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-f.done:
+	case <-timeout:
+		return
+	case <-c.Done():
+		c.Abort()
+		return
+	}
+
+	if !f.shared {
+		return
+	}
+
+	header := c.Writer.Header()
+	for name, values := range f.header {
+		if _, ok := header[name]; !ok {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+
+	c.Writer.WriteHeader(f.status)
+	c.Writer.Write(f.body)
+	c.Abort()
+}
@@ -0,0 +1,212 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// urlKey coalesces the requests having the same URL.
+func urlKey(c *feather.Context) string {
+	return c.Request.URL.String()
+}
+
+func TestSingleflightCoalescesParallelRequests(t *testing.T) {
+	const parallel = 100
+
+	var arrived, calls atomic.Int32
+	server := feather.NewServer()
+	server.AddMiddleware(Singleflight(func(c *feather.Context) string {
+		arrived.Add(1)
+		return urlKey(c)
+	}))
+	server.GET("/report", func(c *feather.Context) {
+		calls.Add(1)
+
+		// Wait for every request to join the flight before answering
+		for arrived.Load() < parallel {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		c.Writer.Header().Set("X-Report", "1")
+		c.String(http.StatusAccepted, "report")
+	})
+
+	var wg sync.WaitGroup
+	recorders := make([]*httptest.ResponseRecorder, parallel)
+	for i := range recorders {
+		recorders[i] = httptest.NewRecorder()
+
+		wg.Add(1)
+		go func(recorder *httptest.ResponseRecorder) {
+			defer wg.Done()
+			server.ServeHTTP(recorder, httptest.NewRequest("GET", "/report", nil))
+		}(recorders[i])
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("the handler ran %d times, want 1", got)
+	}
+	for i, recorder := range recorders {
+		if recorder.Code != http.StatusAccepted || recorder.Body.String() != "report" || recorder.Header().Get("X-Report") != "1" {
+			t.Fatalf("request %d: got %d %q, want the shared response", i, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+// serveAfterFirst serves first, then second once the first request is running its handler, and returns their responses.
+func serveAfterFirst(server *feather.Server, started <-chan struct{}, first *http.Request, second *http.Request) (*httptest.ResponseRecorder, *httptest.ResponseRecorder) {
+	firstRecorder, secondRecorder := httptest.NewRecorder(), httptest.NewRecorder()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		server.ServeHTTP(firstRecorder, first)
+	}()
+
+	<-started
+	go func() {
+		defer wg.Done()
+		server.ServeHTTP(secondRecorder, second)
+	}()
+	wg.Wait()
+
+	return firstRecorder, secondRecorder
+}
+
+func TestSingleflightDoesNotShareCookies(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+
+	server := feather.NewServer()
+	server.AddMiddleware(Singleflight(urlKey))
+	server.GET("/session", func(c *feather.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		http.SetCookie(c.Writer, &http.Cookie{Name: "session", Value: "secret"})
+		c.String(http.StatusOK, "hello")
+	})
+
+	_, second := serveAfterFirst(server, started,
+		httptest.NewRequest("GET", "/session", nil), httptest.NewRequest("GET", "/session", nil))
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("the handler ran %d times, want 2", got)
+	}
+	if second.Body.String() != "hello" {
+		t.Errorf("second body = %q, want %q", second.Body.String(), "hello")
+	}
+}
+
+func TestSingleflightDoesNotShareSkippedHandler(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+
+	firstContext, cancel := context.WithCancel(context.Background())
+	first := httptest.NewRequest("GET", "/report", nil).WithContext(firstContext)
+
+	server := feather.NewServer()
+	server.AddMiddleware(Singleflight(urlKey))
+	server.AddMiddleware(func(c *feather.Context) {
+		// The first client disconnects before the handler runs
+		if c.Request.Context() == firstContext {
+			close(started)
+			<-proceed
+		}
+	})
+	server.GET("/report", func(c *feather.Context) {
+		calls.Add(1)
+		c.String(http.StatusOK, "report")
+	})
+
+	go func() {
+		<-started
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		close(proceed)
+	}()
+
+	_, second := serveAfterFirst(server, started, first, httptest.NewRequest("GET", "/report", nil))
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("the handler ran %d times, want 1", got)
+	}
+	if second.Code != http.StatusOK || second.Body.String() != "report" {
+		t.Errorf("second: got %d %q, want 200 %q", second.Code, second.Body.String(), "report")
+	}
+}
+
+func TestSingleflightKeepsNewFlightAfterDisconnect(t *testing.T) {
+	var calls atomic.Int32
+	startedA, releaseA := make(chan struct{}), make(chan struct{})
+	startedB, releaseB := make(chan struct{}), make(chan struct{})
+
+	server := feather.NewServer()
+	server.AddMiddleware(Singleflight(urlKey))
+	server.GET("/report", func(c *feather.Context) {
+		n := calls.Add(1)
+		switch n {
+		case 1:
+			close(startedA)
+			<-releaseA
+		case 2:
+			close(startedB)
+			<-releaseB
+		}
+
+		c.String(http.StatusOK, "response "+strconv.Itoa(int(n)))
+	})
+
+	serve := func(request *http.Request) (*httptest.ResponseRecorder, <-chan struct{}) {
+		recorder, done := httptest.NewRecorder(), make(chan struct{})
+		go func() {
+			defer close(done)
+			server.ServeHTTP(recorder, request)
+		}()
+
+		return recorder, done
+	}
+
+	// 1. The client of A disconnects while its handler runs, which ends the flight of A
+	contextA, cancel := context.WithCancel(context.Background())
+	_, doneA := serve(httptest.NewRequest("GET", "/report", nil).WithContext(contextA))
+	<-startedA
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// 2. B starts a new flight for the same key
+	recorderB, doneB := serve(httptest.NewRequest("GET", "/report", nil))
+	<-startedB
+
+	// 3. The handler of A returns, and must not end the flight of B
+	close(releaseA)
+	<-doneA
+
+	// C arrives while B is running, and waits for its response
+	recorderC, doneC := serve(httptest.NewRequest("GET", "/report", nil))
+	time.Sleep(50 * time.Millisecond)
+	close(releaseB)
+	<-doneB
+	<-doneC
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("the handler ran %d times, want 2", got)
+	}
+	if recorderB.Body.String() != "response 2" || recorderC.Body.String() != "response 2" {
+		t.Errorf("B got %q and C got %q, want the response of B for both", recorderB.Body.String(), recorderC.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// loggedServer returns a server logging its requests to buffer with the Logging middleware wrapped by wrap.
+// It answers every request with a 200.
+func loggedServer(buffer *bytes.Buffer, wrap func(feather.HandlerFunc) feather.HandlerFunc) *feather.Server {
+	server := feather.NewServer()
+	server.AddMiddleware(wrap(Logging(LoggingOptions{Output: buffer})))
+	server.GET("/*path", func(c *feather.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return server
+}
+
+func TestSkipLogging(t *testing.T) {
+	tests := []struct {
+		name string
+		wrap func(feather.HandlerFunc) feather.HandlerFunc
+	}{
+		{"SkipPaths", func(mw feather.HandlerFunc) feather.HandlerFunc {
+			return feather.SkipPaths(mw, "/health", "/metrics")
+		}},
+		{"Skip", func(mw feather.HandlerFunc) feather.HandlerFunc {
+			return feather.Skip(mw, func(c *feather.Context) bool {
+				return c.Request.URL.Path == "/health"
+			})
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			server := loggedServer(&buffer, test.wrap)
+
+			if recorder := server.TestRequest("GET", "/health", nil, nil); recorder.Code != http.StatusOK {
+				t.Fatalf("GET /health = %d, want 200: the skipped middleware must not stop the request", recorder.Code)
+			}
+			if buffer.Len() != 0 {
+				t.Errorf("GET /health was logged:\n%s", buffer.String())
+			}
+
+			server.TestRequest("GET", "/users", nil, nil)
+			if lines := strings.Count(buffer.String(), "\n"); lines != 1 || !strings.Contains(buffer.String(), "'/users'") {
+				t.Errorf("GET /users: log = %q, want one line for the path", buffer.String())
+			}
+		})
+	}
+}
+
+func TestSkipPredicateSeesTheRequest(t *testing.T) {
+	var buffer bytes.Buffer
+	server := loggedServer(&buffer, func(mw feather.HandlerFunc) feather.HandlerFunc {
+		return feather.Skip(mw, func(c *feather.Context) bool {
+			return c.Request.Header.Get("X-Probe") != ""
+		})
+	})
+
+	server.TestRequest("GET", "/users", nil, map[string]string{"X-Probe": "kubelet"})
+	if buffer.Len() != 0 {
+		t.Errorf("the probe was logged:\n%s", buffer.String())
+	}
+
+	server.TestRequest("GET", "/users", nil, nil)
+	if lines := strings.Count(buffer.String(), "\n"); lines != 1 {
+		t.Errorf("logged %d lines for the request without the header, want 1:\n%s", lines, buffer.String())
+	}
+}
+
+func TestSkipPathsExactMatch(t *testing.T) {
+	var buffer bytes.Buffer
+	server := loggedServer(&buffer, func(mw feather.HandlerFunc) feather.HandlerFunc {
+		return feather.SkipPaths(mw, "/health")
+	})
+
+	// Only the exact paths are skipped, not the paths beginning with them
+	for _, path := range []string{"/health/details", "/healthz"} {
+		buffer.Reset()
+		server.TestRequest("GET", path, nil, nil)
+
+		if !strings.Contains(buffer.String(), "'"+path+"'") {
+			t.Errorf("GET %s was not logged", path)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// timeoutWriter is an http.ResponseWriter buffering the response of a handler run by the Timeout middleware.
+// Once the deadline is exceeded, every write fails with http.ErrHandlerTimeout, so the handler goroutine
+// cannot corrupt the timeout response.
+type timeoutWriter struct {
+	mutex    sync.Mutex
+	header   http.Header  // header holds the headers set by the handler.
+	body     bytes.Buffer // body holds the bytes written by the handler.
+	status   int          // status is the HTTP status code written by the handler, 0 if none.
+	timedOut bool         // timedOut is set once the deadline is exceeded.
+}
+
+// Header returns the headers of the buffered response.
+func (writer *timeoutWriter) Header() http.Header {
+	return writer.header
+}
+
+// WriteHeader records the status code of the buffered response. The interim responses are dropped, since
+// the response is only sent once the handler is done.
+func (writer *timeoutWriter) WriteHeader(code int) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.timedOut || writer.status != 0 || isInterim(code) {
+		return
+	}
+	writer.status = code
+}
+
+// Write appends data to the buffered response.
+func (writer *timeoutWriter) Write(data []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+	return writer.body.Write(data)
+}
+
+/*
+Timeout is a middleware function that runs the route handler with a deadline, following the semantics of
+http.TimeoutHandler.
+
+The handler runs in a dedicated goroutine with a request context cancelled after d, and its response is buffered.
+If it completes in time, the buffered response is sent. Otherwise a 503 Service Unavailable is sent with msg as
+its JSON body, without waiting for the handler: a handler blocked in I/O no longer holds the response, and its
+later writes fail with http.ErrHandlerTimeout. A panic of the handler is propagated to the request goroutine.
+
+Timeout must be the last middleware to run. Since it runs the handler itself and then aborts the request, the
+middlewares added after it are skipped, including the middlewares of the routes (RouteBuilder.Use) when Timeout
+is added to the server. Add it as the last middleware of each route instead when the routes have their own
+middlewares:
+
+	server.GET("/report", report).Use(auth, middlewares.Timeout(5*time.Second, `{"error":"timeout"}`))
+
+The handler runs on a copy of the Context, whose Request carries the deadline. When the handler returns in time,
+what it recorded on the copy (errors, post functions, ...) is kept on the Context of the request.
+
+Parameters:
+		- d: The maximum duration of the handler.
+		- msg: The JSON body of the timeout response, e.g. `{"error":"request timed out"}`.
+
+Returns:
+		- A feather.HandlerFunc that interrupts the handlers exceeding d.
+*/
+func Timeout(d time.Duration, msg string) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		// The requests answered without a route (e.g. automatic OPTIONS) have no handler to run
+		if c.Route == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		writer := c.Writer
+		request := c.Request
+		buffered := &timeoutWriter{header: make(http.Header)}
+
+		// The handler runs on a copy of the Context, so that an abandoned handler never touches the Context
+		// used by the post functions
+		handled := *c
+		handled.Request = request.WithContext(ctx)
+		handled.Writer = buffered
+
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					panicked <- err
+				}
+			}()
+
+			c.Route.Handler(&handled)
+			close(done)
+		}()
+
+		select {
+		case err := <-panicked:
+			panic(err)
+		case <-done:
+			// The handler has returned: keep what it recorded (errors, post functions, ...) on the Context
+			*c = handled
+			c.Writer = writer
+			c.Request = request
+
+			for name, values := range buffered.header {
+				writer.Header()[name] = values
+			}
+			if buffered.status == 0 {
+				buffered.status = http.StatusOK
+			}
+
+			writer.WriteHeader(buffered.status)
+			writer.Write(buffered.body.Bytes())
+		case <-ctx.Done():
+			// The abandoned handler still shares the Params and Data of the Context after the request is handled
+			c.Retain()
+
+			buffered.mutex.Lock()
+			buffered.timedOut = true
+			buffered.mutex.Unlock()
+
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			writer.Write([]byte(msg))
+		}
+
+		// The handler has already run, or is abandoned: skip it in ServeHTTP
+		c.Abort()
+	}
+}
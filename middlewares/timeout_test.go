@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/esmyxvatu/feather"
+)
+
+func TestTimeoutSendsHandlerResponse(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(Timeout(time.Second, `{"error":"timeout"}`))
+	server.GET("/fast", func(c *feather.Context) {
+		c.SetHeader("X-Fast", "1")
+		c.String(http.StatusCreated, "done")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/fast", nil))
+
+	if recorder.Code != http.StatusCreated || recorder.Body.String() != "done" || recorder.Header().Get("X-Fast") != "1" {
+		t.Errorf("got %d %q, want 201 %q with X-Fast", recorder.Code, recorder.Body.String(), "done")
+	}
+}
+
+func TestTimeoutAbandonsSlowHandler(t *testing.T) {
+	server := feather.NewServer()
+
+	release := make(chan struct{})
+	var restored atomic.Bool
+	server.AddMiddleware(func(c *feather.Context) {
+		writer := c.Writer
+		c.Post(func(c *feather.Context) {
+			restored.Store(c.Writer == writer)
+			close(release)
+		})
+	})
+	server.AddMiddleware(Timeout(20*time.Millisecond, `{"error":"timeout"}`))
+
+	handlerDone := make(chan struct{})
+	server.GET("/slow", func(c *feather.Context) {
+		defer close(handlerDone)
+		<-release
+
+		// The abandoned handler keeps using its Context while the request is completed
+		c.Set("late", true)
+		c.String(http.StatusOK, "too late")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/slow", nil))
+	<-handlerDone
+
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Body.String() != `{"error":"timeout"}` {
+		t.Errorf("got %d %q, want 503 with the timeout message", recorder.Code, recorder.Body.String())
+	}
+	if !restored.Load() {
+		t.Error("the post functions do not see the original writer")
+	}
+}
+
+func TestTimeoutKeepsBackgroundFunctions(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(Timeout(time.Second, `{"error":"timeout"}`))
+
+	var ran atomic.Bool
+	server.GET("/", func(c *feather.Context) {
+		c.Background(func() { ran.Store(true) })
+		c.String(http.StatusOK, "ok")
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	server.BackgroundTasks.Wait()
+
+	if !ran.Load() {
+		t.Error("the background function registered by the handler did not run")
+	}
+}
+
+func TestTimeoutAsLastRouteMiddleware(t *testing.T) {
+	server := feather.NewServer()
+
+	var authenticated atomic.Bool
+	auth := func(c *feather.Context) { authenticated.Store(true) }
+
+	server.GET("/report", func(c *feather.Context) {
+		c.String(http.StatusOK, "report")
+	}).Use(auth, Timeout(time.Second, `{"error":"timeout"}`))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/report", nil))
+
+	if !authenticated.Load() || recorder.Body.String() != "report" {
+		t.Errorf("got %q with authenticated = %v", recorder.Body.String(), authenticated.Load())
+	}
+}
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+TraceContext is a middleware function that correlates the requests across services with the W3C Trace Context
+"traceparent" header, for the applications which do not use a full tracing library.
+
+A valid "traceparent" header sent by the client (or by an upstream service) is continued: its trace ID and
+flags are kept, and a new span ID is generated for the request. When the header is absent or malformed, a new
+trace is started with random IDs and the "00" (not sampled) flags. The trace and span IDs are stored in the
+Context under feather.TraceIDKey and feather.SpanIDKey, retrievable with c.TraceID() and c.SpanID(), and the
+traceparent of the request is sent back in the "traceparent" response header, to be forwarded to the services
+called by the handler. The trace ID is also added as the "trace_id" attribute of the request logger returned
+by c.Logger().
+
+Parameters:
+		- None
+
+Returns:
+		- A feather.HandlerFunc that assigns a trace context to the request.
+*/
+func TraceContext() feather.HandlerFunc {
+	return func(c *feather.Context) {
+		traceID, flags, ok := parseTraceparent(c.Header("traceparent"))
+		if !ok {
+			traceID = newTraceID(16)
+			flags = "00"
+		}
+		spanID := newTraceID(8)
+
+		c.SetLogger(c.Logger().With("trace_id", traceID))
+		c.Set(feather.TraceIDKey, traceID)
+		c.Set(feather.SpanIDKey, spanID)
+		c.SetHeader("traceparent", "00-"+traceID+"-"+spanID+"-"+flags)
+	}
+}
+
+/*
+parseTraceparent parses a "traceparent" header according to the W3C Trace Context specification:
+"version-traceid-parentid-flags", made of lowercase hexadecimal fields of 2, 32, 16 and 2 characters.
+The version "ff" and the all-zero IDs are invalid. The headers of the future versions may carry additional
+fields after the flags, which are ignored.
+
+Parameters:
+		- header: The value of the header.
+
+Returns:
+		- The trace ID and the flags of the header, and whether the header is valid.
+*/
+func parseTraceparent(header string) (traceID string, flags string, ok bool) {
+	if len(header) < 55 {
+		return "", "", false
+	}
+
+	version := header[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return "", "", false
+	}
+	if len(header) > 55 && (version == "00" || header[55] != '-') {
+		return "", "", false
+	}
+
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return "", "", false
+	}
+
+	traceID, parentID, flags := header[3:35], header[36:52], header[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", "", false
+	}
+
+	return traceID, flags, true
+}
+
+// isLowerHex reports whether value only contains lowercase hexadecimal characters.
+func isLowerHex(value string) bool {
+	for _, char := range value {
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newTraceID generates a random hexadecimal ID of size bytes, which is never all zeros.
+func newTraceID(size int) string {
+	buffer := make([]byte, size)
+	rand.Read(buffer)
+	buffer[size-1] |= 1
+
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// traceparentPattern matches the traceparent headers of version 00 with non-zero IDs.
+var traceparentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// traceRequest sends a request with the traceparent header to a server using TraceContext, and returns
+// the traceparent response header along with the trace and span IDs seen by the handler.
+func traceRequest(t *testing.T, traceparent string) (header string, traceID string, spanID string) {
+	t.Helper()
+
+	server := feather.NewServer()
+	server.AddMiddleware(TraceContext())
+	server.GET("/", func(c *feather.Context) {
+		traceID, spanID = c.TraceID(), c.SpanID()
+		c.String(http.StatusOK, "ok")
+	})
+
+	headers := map[string]string{}
+	if traceparent != "" {
+		headers["traceparent"] = traceparent
+	}
+	recorder := server.TestRequest("GET", "/", nil, headers)
+
+	return recorder.Header().Get("traceparent"), traceID, spanID
+}
+
+func TestTraceContextContinuesTrace(t *testing.T) {
+	header, traceID, spanID := traceRequest(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %q, want the one of the request", traceID)
+	}
+
+	match := traceparentPattern.FindStringSubmatch(header)
+	if match == nil {
+		t.Fatalf("traceparent = %q, want a valid header", header)
+	}
+	if match[1] != traceID || match[2] != spanID || match[3] != "01" {
+		t.Errorf("traceparent = %q, want the trace ID %s, the span ID %s and the flags 01", header, traceID, spanID)
+	}
+	if spanID == "00f067aa0ba902b7" {
+		t.Error("span ID is the parent ID of the request, want a new span")
+	}
+}
+
+func TestTraceContextFutureVersion(t *testing.T) {
+	_, traceID, _ := traceRequest(t, "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %q, want the one of the request", traceID)
+	}
+}
+
+func TestTraceContextGeneratesWhenInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+	}{
+		{"absent", ""},
+		{"too short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0"},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01"},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"non-hexadecimal version", "0x-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{"zero parent ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{"wrong separator", "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"non-hexadecimal trace ID", "00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01"},
+		{"non-hexadecimal flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0z"},
+		{"trailing data in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{"future version without separator", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01extra"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header, traceID, spanID := traceRequest(t, test.traceparent)
+
+			match := traceparentPattern.FindStringSubmatch(header)
+			if match == nil {
+				t.Fatalf("traceparent = %q, want a valid header", header)
+			}
+			if match[1] != traceID || match[2] != spanID || match[3] != "00" {
+				t.Errorf("traceparent = %q, want the trace ID %s, the span ID %s and the flags 00", header, traceID, spanID)
+			}
+			if strings.Contains(test.traceparent, traceID) || strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+				t.Errorf("trace ID = %q, span ID = %q, want new non-zero IDs", traceID, spanID)
+			}
+		})
+	}
+}
+
+func TestTraceContextGeneratesUniqueIDs(t *testing.T) {
+	_, first, _ := traceRequest(t, "")
+	_, second, _ := traceRequest(t, "")
+
+	if first == second {
+		t.Errorf("two requests share the trace ID %s", first)
+	}
+}
@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/esmyxvatu/feather"
+)
+
+/*
+Transform is a middleware function that post-processes the body of the responses: the body written by the
+handler is buffered and passed to fn along with its Content-Type, and the result of fn is sent instead.
+Use cases include injecting a script in HTML pages, redacting JSON fields, or minifying the body.
+
+As the response is only sent once the handler is done, Transform should be registered before the middlewares
+observing the response, such as Logging, so that its post function runs first.
+
+Parameters:
+		- fn: The function transforming the body. It receives the buffered body and the Content-Type of the
+				response, and returns the new body and Content-Type.
+
+Returns:
+		- A feather.HandlerFunc that transforms the responses.
+*/
+func Transform(fn func(body []byte, contentType string) ([]byte, string)) feather.HandlerFunc {
+	return func(c *feather.Context) {
+		buffer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = buffer
+
+		c.Post(func(*feather.Context) {
+			body, contentType := fn(buffer.body.Bytes(), buffer.Header().Get("Content-Type"))
+			if contentType != "" {
+				buffer.Header().Set("Content-Type", contentType)
+			}
+
+			buffer.flush(buffer.statusCode(), body)
+		})
+	}
+}
+
+// DefaultTransformMaxBodySize is the size above which TransformResponse sends the body untransformed,
+// when TransformOptions.MaxBodySize is not set.
+const DefaultTransformMaxBodySize = 1 << 20
+
+/*
+TransformOptions configures the TransformResponse middleware.
+*/
+type TransformOptions struct {
+	// ContentTypes are the media types of the transformed responses (e.g. "text/html", "application/json"),
+	// compared without their parameters. Every response is transformed when it is empty.
+	ContentTypes []string
+
+	// MaxBodySize is the size, in bytes, above which the body is sent untransformed.
+	// DefaultTransformMaxBodySize (1MB) is used when it is zero.
+	MaxBodySize int
+}
+
+// transformWriter is an http.ResponseWriter which buffers the response of the handler for TransformResponse.
+// It switches to writing through to the wrapped writer, sending what it buffered, when the Content-Type does not
+// match, when the body exceeds the size cap, or when the handler flushes the response.
+type transformWriter struct {
+	http.ResponseWriter
+
+	options     TransformOptions // options holds the skip conditions.
+	status      int              // status is the HTTP status code written by the handler, 0 if none.
+	body        bytes.Buffer     // body holds the bytes written by the handler while buffering.
+	passthrough bool             // passthrough is set once the response is sent untransformed.
+}
+
+// WriteHeader records the status code, or forwards it when the Content-Type of the response is not transformed
+// or when it is the status of an interim response.
+func (writer *transformWriter) WriteHeader(code int) {
+	if writer.passthrough || isInterim(code) {
+		writer.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if writer.status != 0 {
+		return
+	}
+
+	writer.status = code
+	if !writer.matches(writer.Header().Get("Content-Type")) {
+		writer.release()
+	}
+}
+
+// Write buffers the bytes, or forwards them once the response is sent untransformed or exceeds the size cap.
+func (writer *transformWriter) Write(data []byte) (int, error) {
+	writer.WriteHeader(http.StatusOK)
+
+	if !writer.passthrough && writer.body.Len()+len(data) > writer.options.MaxBodySize {
+		writer.release()
+	}
+	if writer.passthrough {
+		return writer.ResponseWriter.Write(data)
+	}
+
+	return writer.body.Write(data)
+}
+
+// Flush sends the response untransformed, since it is streamed, and flushes the wrapped writer.
+func (writer *transformWriter) Flush() {
+	writer.WriteHeader(http.StatusOK)
+	if !writer.passthrough {
+		writer.release()
+	}
+
+	http.NewResponseController(writer.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (writer *transformWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// release switches to writing through, sending the status code and the bytes buffered so far.
+func (writer *transformWriter) release() {
+	writer.passthrough = true
+	writer.ResponseWriter.WriteHeader(writer.status)
+	writer.ResponseWriter.Write(writer.body.Bytes())
+	writer.body.Reset()
+}
+
+// matches reports whether a response with the given Content-Type is transformed.
+func (writer *transformWriter) matches(contentType string) bool {
+	if len(writer.options.ContentTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	for _, accepted := range writer.options.ContentTypes {
+		if strings.EqualFold(strings.TrimSpace(mediaType), accepted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+TransformResponse is a middleware function that post-processes the status and body of the responses without
+touching the handlers, e.g. to inject an HTML snippet before </body>, to minify JSON, or to wrap every API
+response in an envelope:
+
+	server.AddMiddleware(middlewares.TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+		envelope, _ := json.Marshal(map[string]any{"data": json.RawMessage(body), "requestId": c.RequestID()})
+		return status, envelope
+	}, middlewares.TransformOptions{ContentTypes: []string{"application/json"}}))
+
+The response written by the handler is buffered and passed to fn once the handler is done, then the result of
+fn is sent with a recomputed Content-Length. The response is sent untransformed, as written by the handler,
+when its Content-Type does not match, when its body exceeds the size cap, or when the handler flushes it
+(a streamed response). Unlike Transform, fn can read the Context and change the status code.
+
+As the response is only sent once the handler is done, TransformResponse should be registered before the
+middlewares observing the response, such as Logging, so that its post function runs first.
+
+Parameters:
+		- fn: The function transforming the response. It receives the status code and the buffered body,
+				and returns the new status code and body.
+		- options: Optional settings, to select the transformed content types or to change the size cap.
+
+Returns:
+		- A feather.HandlerFunc that transforms the responses.
+*/
+func TransformResponse(fn func(c *feather.Context, status int, body []byte) (int, []byte), options ...TransformOptions) feather.HandlerFunc {
+	var opts TransformOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultTransformMaxBodySize
+	}
+
+	return func(c *feather.Context) {
+		writer := &transformWriter{
+			ResponseWriter: c.Writer,
+			options:        opts,
+		}
+		c.Writer = writer
+
+		c.Post(func(*feather.Context) {
+			// Nothing to transform when the response is already sent or when the handler wrote nothing
+			if writer.passthrough || writer.status == 0 {
+				return
+			}
+
+			status, body := fn(c, writer.status, writer.body.Bytes())
+
+			writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			writer.ResponseWriter.WriteHeader(status)
+			writer.ResponseWriter.Write(body)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/esmyxvatu/feather"
+)
+
+// wrapEnvelope wraps body in a {"data": ..., "requestId": ...} envelope.
+func wrapEnvelope(c *feather.Context, status int, body []byte) (int, []byte) {
+	wrapped, err := json.Marshal(map[string]any{"data": json.RawMessage(body), "requestId": c.RequestID()})
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+
+	return status, wrapped
+}
+
+func TestTransformResponseEnvelope(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(RequestID())
+	server.AddMiddleware(TransformResponse(wrapEnvelope, TransformOptions{ContentTypes: []string{"application/json"}}))
+	server.GET("/users/1", func(c *feather.Context) {
+		c.JSON(http.StatusCreated, map[string]string{"name": "alice"})
+	})
+
+	recorder := server.TestRequest("GET", "/users/1", nil, map[string]string{"X-Request-ID": "req-42"})
+
+	if recorder.Code != http.StatusCreated {
+		t.Errorf("status = %d, want 201", recorder.Code)
+	}
+
+	var got struct {
+		Data      map[string]string `json:"data"`
+		RequestID string            `json:"requestId"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid envelope %q: %v", recorder.Body.String(), err)
+	}
+	if got.Data["name"] != "alice" || got.RequestID != "req-42" {
+		t.Errorf("envelope = %+v", got)
+	}
+	if length := recorder.Header().Get("Content-Length"); length != strconv.Itoa(recorder.Body.Len()) {
+		t.Errorf("Content-Length = %q, want %d", length, recorder.Body.Len())
+	}
+}
+
+func TestTransformResponseChangesStatus(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+		return http.StatusBadGateway, []byte("upstream failed")
+	}))
+	server.GET("/", func(c *feather.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, nil)
+
+	if recorder.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", recorder.Code)
+	}
+	if body := recorder.Body.String(); body != "upstream failed" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestTransformResponseRecomputesContentLength(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+		return status, []byte(strings.Replace(string(body), "</body>", "<script src=\"/live.js\"></script></body>", 1))
+	}))
+	server.GET("/", func(c *feather.Context) {
+		page := "<html><body>hello</body></html>"
+		c.Writer.Header().Set("Content-Type", "text/html")
+		c.Writer.Header().Set("Content-Length", strconv.Itoa(len(page)))
+		c.Writer.WriteHeader(http.StatusOK)
+		c.Writer.Write([]byte(page))
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, nil)
+
+	want := "<html><body>hello<script src=\"/live.js\"></script></body></html>"
+	if body := recorder.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if length := recorder.Header().Get("Content-Length"); length != strconv.Itoa(len(want)) {
+		t.Errorf("Content-Length = %q, want %d", length, len(want))
+	}
+}
+
+func TestTransformResponseSkips(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TransformOptions
+		handler feather.HandlerFunc
+		want    string
+	}{
+		{
+			name:    "non-matching content type",
+			options: TransformOptions{ContentTypes: []string{"application/json"}},
+			handler: func(c *feather.Context) {
+				c.String(http.StatusOK, "plain text")
+			},
+			want: "plain text",
+		},
+		{
+			name: "flushed response",
+			handler: func(c *feather.Context) {
+				c.Writer.Header().Set("Content-Type", "text/plain")
+				c.Writer.Write([]byte("first "))
+				http.NewResponseController(c.Writer).Flush()
+				c.Writer.Write([]byte("second"))
+			},
+			want: "first second",
+		},
+		{
+			name:    "body above the size cap",
+			options: TransformOptions{MaxBodySize: 8},
+			handler: func(c *feather.Context) {
+				c.Writer.Header().Set("Content-Type", "text/plain")
+				c.Writer.Write([]byte("0123456"))
+				c.Writer.Write([]byte("789abcdef"))
+			},
+			want: "0123456789abcdef",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			called := false
+
+			server := feather.NewServer()
+			server.AddMiddleware(TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+				called = true
+				return status, []byte("transformed")
+			}, test.options))
+			server.GET("/", test.handler)
+
+			recorder := server.TestRequest("GET", "/", nil, nil)
+
+			if called {
+				t.Error("fn called, want the response sent untransformed")
+			}
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", recorder.Code)
+			}
+			if body := recorder.Body.String(); body != test.want {
+				t.Errorf("body = %q, want %q", body, test.want)
+			}
+		})
+	}
+}
+
+func TestTransformResponseEmptyResponse(t *testing.T) {
+	called := false
+
+	server := feather.NewServer()
+	server.AddMiddleware(TransformResponse(func(c *feather.Context, status int, body []byte) (int, []byte) {
+		called = true
+		return status, body
+	}))
+	server.GET("/", func(c *feather.Context) {})
+
+	server.TestRequest("GET", "/", nil, nil)
+
+	if called {
+		t.Error("fn called for a handler that wrote nothing")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	server := feather.NewServer()
+	server.AddMiddleware(Transform(func(body []byte, contentType string) ([]byte, string) {
+		return []byte(strings.ToUpper(string(body))), contentType + "; charset=utf-8"
+	}))
+	server.GET("/", func(c *feather.Context) {
+		c.String(http.StatusAccepted, "hello")
+	})
+
+	recorder := server.TestRequest("GET", "/", nil, nil)
+
+	if recorder.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", recorder.Code)
+	}
+	if body := recorder.Body.String(); body != "HELLO" {
+		t.Errorf("body = %q, want %q", body, "HELLO")
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+}
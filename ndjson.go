@@ -0,0 +1,150 @@
+package feather
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidNDJSON is the error of the lines of an NDJSON body which are not valid JSON values.
+var ErrInvalidNDJSON = errors.New("invalid JSON value")
+
+// DefaultNDJSONMaxLineSize is the maximum size of a line of an NDJSON body when NDJSONOptions.MaxLineSize is not set.
+const DefaultNDJSONMaxLineSize = 1 << 20
+
+// NDJSONOptions configures Context.NDJSONBody.
+type NDJSONOptions struct {
+	// MaxLineSize is the maximum size of a line, in bytes. DefaultNDJSONMaxLineSize (1MB) is used when it is zero.
+	// A longer line stops the reading, even in lenient mode.
+	MaxLineSize int
+
+	// Lenient skips the lines which are not valid JSON values instead of stopping at the first one.
+	// Their errors are collected and returned once the body has been read.
+	Lenient bool
+}
+
+// NDJSONError is the error of a line of an NDJSON body, see Context.NDJSONBody.
+type NDJSONError struct {
+	Line int   // Line is the number of the line, starting at 1.
+	Err  error // Err is the error of the line.
+}
+
+// Error returns the message of the error, prefixed with the line number.
+func (e *NDJSONError) Error() string {
+	return fmt.Sprintf("feather: line %d of the NDJSON body: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the error of the line.
+func (e *NDJSONError) Unwrap() error {
+	return e.Err
+}
+
+// NDJSONBody reads the body of the request as newline-delimited JSON (application/x-ndjson),
+// calling fn with each record without buffering the whole body.
+//
+// Parameters:
+//   - fn: The function called with the raw JSON value of each line, in order. It decodes the record
+//     itself (e.g. with json.Unmarshal), and returns an error to stop the reading.
+//   - options: Optional settings, to limit the size of the lines or to skip the malformed lines.
+//
+// The empty lines are skipped, and the lines ending with "\r\n" are accepted. The raw value given
+// to fn is only valid until fn returns.
+//
+// Returns:
+//   - nil once every line has been read.
+//   - An *NDJSONError holding the line number for a malformed line (wrapping ErrInvalidNDJSON),
+//     a line longer than MaxLineSize (wrapping bufio.ErrTooLong) or an error returned by fn.
+//   - In lenient mode, the errors of the malformed lines joined with errors.Join, once the body has been read.
+func (c *Context) NDJSONBody(fn func(raw json.RawMessage) error, options ...NDJSONOptions) error {
+	var opts NDJSONOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.MaxLineSize <= 0 {
+		opts.MaxLineSize = DefaultNDJSONMaxLineSize
+	}
+
+	// The buffer leaves room for the "\r\n" ending a line of MaxLineSize bytes, the size is checked on each line
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, min(opts.MaxLineSize+2, 64*1024)), opts.MaxLineSize+2)
+
+	skipped := make([]error, 0)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if len(bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))) > opts.MaxLineSize {
+			return &NDJSONError{Line: line, Err: bufio.ErrTooLong}
+		}
+
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		if !json.Valid(raw) {
+			lineErr := &NDJSONError{Line: line, Err: ErrInvalidNDJSON}
+			if !opts.Lenient {
+				return lineErr
+			}
+
+			skipped = append(skipped, lineErr)
+			continue
+		}
+
+		if err := fn(raw); err != nil {
+			return &NDJSONError{Line: line, Err: err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &NDJSONError{Line: line + 1, Err: c.bodyError(err)}
+	}
+
+	return errors.Join(skipped...)
+}
+
+// NDJSONWriter streams newline-delimited JSON values to the client, see Context.NDJSONWriter.
+type NDJSONWriter struct {
+	c       *Context      // c is the Context of the request.
+	encoder *json.Encoder // encoder writes each value followed by a newline.
+}
+
+// NDJSONWriter starts a newline-delimited JSON response, whose values are written with Write.
+//
+// The "Content-Type" header defaults to "application/x-ndjson" and "Cache-Control" to "no-cache"
+// when the handler has not set them, and the 200 status is sent immediately.
+//
+// Returns:
+//   - The *NDJSONWriter of the response.
+func (c *Context) NDJSONWriter() *NDJSONWriter {
+	c.prepareStream("application/x-ndjson")
+
+	return &NDJSONWriter{
+		c:       c,
+		encoder: json.NewEncoder(c.Writer),
+	}
+}
+
+// Write encodes value on its own line and flushes it to the client.
+//
+// Parameters:
+//   - value: The value to encode.
+//
+// Returns:
+//   - An error if the client has disconnected, or if the value cannot be encoded or written.
+func (writer *NDJSONWriter) Write(value any) error {
+	if err := writer.c.Err(); err != nil {
+		return err
+	}
+
+	if err := writer.encoder.Encode(value); err != nil {
+		return err
+	}
+	writer.c.flush()
+
+	return nil
+}
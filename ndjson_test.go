@@ -0,0 +1,204 @@
+package feather
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// ndjsonContext returns a Context whose request body is body.
+func ndjsonContext(body io.Reader) *Context {
+	c, _ := NewTestContext("POST", "/ingest", nil)
+	c.Request.Body = io.NopCloser(body)
+	return c
+}
+
+// syntheticStream returns an NDJSON stream of n records, generated while it is read.
+func syntheticStream(n int) io.Reader {
+	reader, writer := io.Pipe()
+	go func() {
+		buffered := bufio.NewWriter(writer)
+		for i := range n {
+			fmt.Fprintf(buffered, "{\"id\":%d,\"name\":\"record %d\"}\n", i, i)
+		}
+		buffered.Flush()
+		writer.Close()
+	}()
+
+	return reader
+}
+
+func TestNDJSONBodyLargeStream(t *testing.T) {
+	const records = 200_000
+
+	sum := 0
+	count := 0
+	err := ndjsonContext(syntheticStream(records)).NDJSONBody(func(raw json.RawMessage) error {
+		var record struct{ ID int }
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		sum += record.ID
+		count++
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("NDJSONBody = %v", err)
+	}
+	if count != records || sum != records*(records-1)/2 {
+		t.Errorf("read %d records summing to %d", count, sum)
+	}
+}
+
+func TestNDJSONBodyBadLine(t *testing.T) {
+	body := "{\"id\":1}\n{\"id\":2}\n{\"id\":\n{\"id\":4}\n"
+
+	var ids []string
+	err := ndjsonContext(strings.NewReader(body)).NDJSONBody(func(raw json.RawMessage) error {
+		ids = append(ids, string(raw))
+		return nil
+	})
+
+	var lineErr *NDJSONError
+	if !errors.As(err, &lineErr) || lineErr.Line != 3 || !errors.Is(err, ErrInvalidNDJSON) {
+		t.Fatalf("NDJSONBody = %v, want an error on line 3", err)
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("the message %q does not name the line", err.Error())
+	}
+	if len(ids) != 2 {
+		t.Errorf("read %d records before the bad line, want 2", len(ids))
+	}
+}
+
+func TestNDJSONBodyLenient(t *testing.T) {
+	body := "{\"id\":1}\nnot json\n{\"id\":3}\n[1,\n{\"id\":5}\n"
+
+	count := 0
+	err := ndjsonContext(strings.NewReader(body)).NDJSONBody(func(raw json.RawMessage) error {
+		count++
+		return nil
+	}, NDJSONOptions{Lenient: true})
+
+	if count != 3 {
+		t.Errorf("read %d records, want 3", count)
+	}
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("NDJSONBody = %v, want the errors of the lines 2 and 4", err)
+	}
+}
+
+func TestNDJSONBodyCRLF(t *testing.T) {
+	body := "{\"id\":1}\r\n\r\n{\"id\":2}\r\n  \r\n{\"id\":3}"
+
+	var records []string
+	err := ndjsonContext(strings.NewReader(body)).NDJSONBody(func(raw json.RawMessage) error {
+		records = append(records, string(raw))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("NDJSONBody = %v", err)
+	}
+	if got := strings.Join(records, "|"); got != `{"id":1}|{"id":2}|{"id":3}` {
+		t.Errorf("records = %s", got)
+	}
+}
+
+func TestNDJSONBodyMaxLineSize(t *testing.T) {
+	record := `{"data":"` + strings.Repeat("x", 100) + `"}`
+
+	tests := []struct {
+		name    string
+		body    string
+		tooLong bool
+	}{
+		{"line at the limit", record + "\n", false},
+		{"line at the limit with CRLF", record + "\r\n", false},
+		{"last line at the limit", record, false},
+		{"line over the limit", record + " \n", true},
+		{"line far over the limit", strings.Repeat(record, 10) + "\n", true},
+	}
+
+	for _, test := range tests {
+		err := ndjsonContext(strings.NewReader("{}\n"+test.body)).NDJSONBody(func(raw json.RawMessage) error {
+			return nil
+		}, NDJSONOptions{MaxLineSize: len(record), Lenient: true})
+
+		var lineErr *NDJSONError
+		if tooLong := errors.Is(err, bufio.ErrTooLong); tooLong != test.tooLong {
+			t.Errorf("%s: NDJSONBody = %v", test.name, err)
+		} else if test.tooLong && (!errors.As(err, &lineErr) || lineErr.Line != 2) {
+			t.Errorf("%s: NDJSONBody = %v, want an error on line 2", test.name, err)
+		}
+	}
+}
+
+func TestNDJSONBodyCallbackError(t *testing.T) {
+	stop := errors.New("duplicate record")
+
+	count := 0
+	err := ndjsonContext(strings.NewReader("1\n2\n3\n")).NDJSONBody(func(raw json.RawMessage) error {
+		count++
+		if string(raw) == "2" {
+			return stop
+		}
+		return nil
+	})
+
+	var lineErr *NDJSONError
+	if !errors.Is(err, stop) || !errors.As(err, &lineErr) || lineErr.Line != 2 || count != 2 {
+		t.Errorf("NDJSONBody = %v after %d records", err, count)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	next := make(chan any)
+
+	recorder, reader, done := serveStream(t.Context(), func(c *Context) {
+		writer := c.NDJSONWriter()
+		for value := range next {
+			if err := writer.Write(value); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	// Each value reaches the client on its own line as soon as it is written
+	for i, value := range []any{map[string]int{"id": 1}, "two", []int{3}} {
+		next <- value
+		line, err := reader.ReadString('\n')
+		want := []string{"{\"id\":1}\n", "\"two\"\n", "[3]\n"}[i]
+		if err != nil || line != want {
+			t.Fatalf("line %d = %q (%v), want %q", i, line, err, want)
+		}
+	}
+	close(next)
+	<-done
+
+	if got := recorder.header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if recorder.status != http.StatusOK {
+		t.Errorf("status = %d", recorder.status)
+	}
+}
+
+func TestNDJSONWriterClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c, _ := NewTestContext("GET", "/", nil)
+	c.Request = c.Request.WithContext(ctx)
+
+	if err := c.NDJSONWriter().Write(1); err == nil {
+		t.Error("Write succeeded after the client disconnected")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package feather
+
+// raceEnabled reports whether the tests run with the race detector, which adds allocations.
+const raceEnabled = false
@@ -0,0 +1,89 @@
+package feather
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Pagination describes the page of a paginated collection, read from the query string
+// with Context.PaginateQuery and sent back to the client with Context.JSONPage.
+type Pagination struct {
+	Page       int `json:"page"`        // Page is the 1-based index of the requested page.
+	PerPage    int `json:"per_page"`    // PerPage is the number of items per page.
+	Total      int `json:"total"`       // Total is the total number of items of the collection, see SetTotal.
+	TotalPages int `json:"total_pages"` // TotalPages is the number of pages of the collection, see SetTotal.
+}
+
+// Offset returns the number of items to skip to reach the requested page, e.g. for an SQL OFFSET clause.
+//
+// Returns:
+//   - The index of the first item of the page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// SetTotal sets the total number of items of the collection and computes the number of pages.
+//
+// Parameters:
+//   - total: The total number of items of the collection.
+//
+// This function does not return any value.
+func (p *Pagination) SetTotal(total int) {
+	p.Total = total
+	p.TotalPages = 0
+
+	if p.PerPage > 0 {
+		p.TotalPages = (total + p.PerPage - 1) / p.PerPage
+	}
+}
+
+// PaginateQuery reads and validates the "page" and "per_page" query parameters.
+//
+// Parameters:
+//   - defaultPerPage: The number of items per page when "per_page" is absent.
+//   - maxPerPage: The maximum accepted value of "per_page".
+//
+// Returns:
+//   - The Pagination of the request. "page" defaults to 1. Total and TotalPages are left
+//     to zero until SetTotal is called.
+//   - An error if a parameter is not an integer, is lower than 1, or if "per_page" exceeds maxPerPage.
+func (c *Context) PaginateQuery(defaultPerPage, maxPerPage int) (Pagination, error) {
+	pagination := Pagination{
+		Page:    1,
+		PerPage: defaultPerPage,
+	}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return Pagination{}, fmt.Errorf("invalid page %q: must be an integer greater than 0", value)
+		}
+		pagination.Page = page
+	}
+
+	if value := c.Query("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return Pagination{}, fmt.Errorf("invalid per_page %q: must be an integer between 1 and %d", value, maxPerPage)
+		}
+		pagination.PerPage = perPage
+	}
+
+	return pagination, nil
+}
+
+// JSONPage sends a page of a paginated collection as a JSON response, wrapped as
+// {"data": items, "pagination": pag}.
+//
+// Parameters:
+//   - status: The HTTP status code to set for the response.
+//   - items: The items of the page.
+//   - pag: The Pagination of the page, usually completed with SetTotal.
+//
+// This function does not return any value.
+func (c *Context) JSONPage(status int, items any, pag Pagination) {
+	c.JSON(status, map[string]any{
+		"data":       items,
+		"pagination": pag,
+	})
+}
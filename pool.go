@@ -0,0 +1,60 @@
+package feather
+
+import (
+	"net/http"
+	"sync"
+)
+
+// acquireContext returns a Context for a request matching route, reused from the pool of the server when
+// pooling is enabled (see SetContextPooling) and possible. Its Params and Data maps are empty.
+func (server *Server) acquireContext(writer http.ResponseWriter, reader *http.Request, route *Route) *Context {
+	var context *Context
+	ok := false
+	if server.contextPooling {
+		context, ok = server.contextPool.Get().(*Context)
+	}
+	if !ok {
+		context = &Context{
+			Params:    make(map[string]string),
+			Data:      make(map[string]any),
+			server:    server,
+			dataMutex: new(sync.RWMutex),
+		}
+	}
+
+	context.Writer = writer
+	context.Request = reader
+	context.Route = route
+	context.countBody()
+
+	return context
+}
+
+// releaseContext resets the Context of a handled request and puts it back in the pool of the server.
+// The Contexts on which Retain has been called, and all of them when pooling is disabled, are left to the
+// garbage collector.
+func (server *Server) releaseContext(context *Context) {
+	if !server.contextPooling || context.retained {
+		return
+	}
+
+	clear(context.Params)
+	clear(context.Data)
+	clear(context.postFuncs)
+	clear(context.background)
+	if context.body != nil {
+		*context.body = countingBody{}
+	}
+
+	*context = Context{
+		Params:     context.Params,
+		Data:       context.Data,
+		server:     server,
+		postFuncs:  context.postFuncs[:0],
+		background: context.background[:0],
+		dataMutex:  context.dataMutex,
+		body:       context.body,
+	}
+
+	server.contextPool.Put(context)
+}
@@ -0,0 +1,95 @@
+package feather
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// benchmarkContextPool serves a request matching a route with a parameter, with or without pooling.
+//
+// Reference numbers (go test -bench=ContextPool -benchmem):
+//
+//	BenchmarkContextPool/pooled     498 ns/op     48 B/op   2 allocs/op
+//	BenchmarkContextPool/unpooled   777 ns/op    968 B/op   8 allocs/op
+func benchmarkContextPool(b *testing.B, pooling bool) {
+	server := NewServer()
+	server.SetContextPooling(pooling)
+	server.GET("/users/:id", func(c *Context) {
+		c.Set("user", c.Params["id"])
+	})
+
+	request := httptest.NewRequest("GET", "/users/42", nil)
+	recorder := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		server.ServeHTTP(recorder, request)
+	}
+}
+
+func BenchmarkContextPool(b *testing.B) {
+	b.Run("pooled", func(b *testing.B) { benchmarkContextPool(b, true) })
+	b.Run("unpooled", func(b *testing.B) { benchmarkContextPool(b, false) })
+}
+
+func TestContextPoolingReducesAllocations(t *testing.T) {
+	allocs := func(pooling bool) float64 {
+		server := NewServer()
+		server.SetContextPooling(pooling)
+		server.GET("/users/:id", func(c *Context) {
+			c.Set("user", c.Params["id"])
+		})
+
+		request := httptest.NewRequest("GET", "/users/42", nil)
+		recorder := httptest.NewRecorder()
+
+		return testing.AllocsPerRun(100, func() {
+			server.ServeHTTP(recorder, request)
+		})
+	}
+
+	pooled, unpooled := allocs(true), allocs(false)
+	if pooled >= unpooled {
+		t.Fatalf("pooled requests allocate %v times, unpooled ones %v times", pooled, unpooled)
+	}
+}
+
+func TestRetainedContextIsNotReused(t *testing.T) {
+	server := NewServer()
+	server.SetContextPooling(true)
+
+	var kept *Context
+	server.GET("/keep", func(c *Context) {
+		c.Set("owner", "first")
+		kept = c.WithValue("key", "value")
+	})
+	server.GET("/other", func(c *Context) {
+		c.Set("owner", "second")
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/keep", nil))
+	for range 10 {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/other", nil))
+	}
+
+	if owner := kept.Get("owner"); owner != "first" {
+		t.Fatalf("the retained Context has been reused: owner = %v", owner)
+	}
+}
+
+func TestPoolingDisabledByDefault(t *testing.T) {
+	server := NewServer()
+
+	contexts := make([]*Context, 0)
+	server.GET("/", func(c *Context) {
+		contexts = append(contexts, c)
+	})
+
+	for range 2 {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	if contexts[0] == contexts[1] {
+		t.Fatal("the Context has been reused although pooling is disabled")
+	}
+}
@@ -0,0 +1,70 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreRoutingRewriteChangesMatchedRoute(t *testing.T) {
+	server := NewServer()
+	server.PreRouting(func(writer http.ResponseWriter, reader *http.Request) bool {
+		// An ingress forwards the requests under a prefix
+		reader.URL.Path = strings.TrimPrefix(reader.URL.Path, "/ingress")
+		return false
+	})
+	server.GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/ingress/users", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "users" {
+		t.Errorf("got %d %q, want 200 %q", recorder.Code, recorder.Body.String(), "users")
+	}
+}
+
+func TestPreRoutingStopSkipsRouting(t *testing.T) {
+	server := NewServer()
+
+	middlewareRan := false
+	server.AddMiddleware(func(c *Context) { middlewareRan = true })
+	server.PreRouting(func(writer http.ResponseWriter, reader *http.Request) bool {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	server.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "home")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable || middlewareRan {
+		t.Errorf("got %d with middlewareRan = %v, want 503 without middleware", recorder.Code, middlewareRan)
+	}
+}
+
+func TestPreRoutingRunsBeforeCleanPath(t *testing.T) {
+	server := NewServer()
+	server.SetCleanPath(true)
+
+	var seen string
+	server.PreRouting(func(writer http.ResponseWriter, reader *http.Request) bool {
+		seen = reader.URL.Path
+		return false
+	})
+	server.GET("/users", func(c *Context) {})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/../users", nil))
+
+	if seen != "/api/../users" {
+		t.Errorf("the hook saw %q, want the path sent by the client", seen)
+	}
+	if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != "/users" {
+		t.Errorf("got %d to %q, want a redirect to /users", recorder.Code, recorder.Header().Get("Location"))
+	}
+}
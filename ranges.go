@@ -0,0 +1,270 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRange is returned by Context.ParseRange when the Range header is malformed.
+// RFC 9110 requires such a header to be ignored, sending the full content.
+var ErrInvalidRange = errors.New("feather: invalid range")
+
+// ErrRangeNotSatisfiable is returned by Context.ParseRange when none of the requested ranges overlaps the content.
+// The request should then be answered with Context.RangeNotSatisfiable.
+var ErrRangeNotSatisfiable = errors.New("feather: range not satisfiable")
+
+// Range is a byte range of a content, see Context.ParseRange.
+type Range struct {
+	Start  int64 // Start is the offset of the first byte of the range.
+	Length int64 // Length is the number of bytes of the range.
+}
+
+// ContentRange returns the value of the Content-Range header describing the range.
+//
+// Parameters:
+//   - size: The total size of the content.
+//
+// Returns:
+//   - The value of the header, e.g. "bytes 0-99/1000".
+func (r Range) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// ParseRange parses the Range header of the request, for handlers serving content from sources
+// http.ServeContent does not support (object storages, databases, ...).
+//
+// Parameters:
+//   - size: The total size of the content.
+//
+// The suffix ranges ("bytes=-500") are resolved against size and the ranges extending beyond the end
+// of the content are truncated. The ranges starting beyond the end are dropped.
+//
+// Returns:
+//   - The requested ranges, in the order of the header, or nil if the request has no Range header.
+//   - ErrInvalidRange if the header is malformed, ErrRangeNotSatisfiable if no range overlaps the content.
+func (c *Context) ParseRange(size int64) ([]Range, error) {
+	header := c.Request.Header.Get("Range")
+	if header == "" {
+		return nil, nil
+	}
+
+	specs, found := strings.CutPrefix(header, "bytes=")
+	if !found {
+		return nil, ErrInvalidRange
+	}
+
+	ranges := make([]Range, 0, 1)
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		first, last, found := strings.Cut(spec, "-")
+		if !found {
+			return nil, ErrInvalidRange
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		if first == "" {
+			// Suffix range: the last bytes of the content
+			length, err := parseRangeInt(last)
+			if err != nil {
+				return nil, ErrInvalidRange
+			}
+			if length == 0 || size == 0 {
+				continue
+			}
+			if length > size {
+				length = size
+			}
+
+			ranges = append(ranges, Range{Start: size - length, Length: length})
+			continue
+		}
+
+		start, err := parseRangeInt(first)
+		if err != nil {
+			return nil, ErrInvalidRange
+		}
+
+		end := size - 1
+		if last != "" {
+			end, err = parseRangeInt(last)
+			if err != nil || end < start {
+				return nil, ErrInvalidRange
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+
+		if start >= size {
+			continue
+		}
+
+		ranges = append(ranges, Range{Start: start, Length: end - start + 1})
+	}
+
+	if len(ranges) == 0 {
+		return nil, ErrRangeNotSatisfiable
+	}
+
+	return ranges, nil
+}
+
+// parseRangeInt parses a position of a byte range, which RFC 9110 restricts to digits: the signs
+// accepted by strconv.ParseInt make the range invalid.
+func parseRangeInt(value string) (int64, error) {
+	if value == "" || strings.TrimLeft(value, "0123456789") != "" {
+		return 0, ErrInvalidRange
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// RangeNotSatisfiable answers the request with a 416 Range Not Satisfiable, along with the
+// Content-Range header advertising the size of the content.
+//
+// Parameters:
+//   - size: The total size of the content.
+//
+// It does not return any value.
+func (c *Context) RangeNotSatisfiable(size int64) {
+	c.Writer.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	c.Error(http.StatusRequestedRangeNotSatisfiable, http.StatusText(http.StatusRequestedRangeNotSatisfiable))
+}
+
+// ServeRange serves a content read through readAt, honouring the Range header of the request.
+//
+// Parameters:
+//   - status: The status of the full response, usually 200.
+//   - contentType: The media type of the content.
+//   - size: The total size of the content.
+//   - readAt: The function returning a reader of length bytes of the content, starting at offset.
+//     A reader implementing io.Closer is closed once read.
+//
+// A single range is answered with a 206 Partial Content, several ranges with a 206 multipart/byteranges
+// response, and unsatisfiable ranges with a 416. The full content is sent when the request has no
+// Range header, when the header is malformed, when the If-Range precondition does not match the
+// ETag or Last-Modified response headers, or when the ranges overlap so much that they exceed the
+// content. Only the headers are sent for HEAD requests.
+// It does not return any value; when readAt fails before the response is started, a 500 is sent.
+func (c *Context) ServeRange(status int, contentType string, size int64, readAt func(offset, length int64) (io.Reader, error)) {
+	header := c.Writer.Header()
+	header.Set("Accept-Ranges", "bytes")
+
+	ranges, err := c.ParseRange(size)
+	if errors.Is(err, ErrRangeNotSatisfiable) && c.rangeCurrent() {
+		c.RangeNotSatisfiable(size)
+		return
+	}
+
+	var total int64
+	for _, r := range ranges {
+		total += r.Length
+	}
+	if err != nil || total > size || !c.rangeCurrent() {
+		ranges = nil
+	}
+
+	head := c.Request.Method == http.MethodHead
+
+	switch len(ranges) {
+	case 0:
+		reader, err := readAt(0, size)
+		if err != nil {
+			c.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer closeReader(reader)
+
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+		c.Writer.WriteHeader(status)
+		if !head {
+			io.CopyN(c.Writer, reader, size)
+		}
+	case 1:
+		r := ranges[0]
+		reader, err := readAt(r.Start, r.Length)
+		if err != nil {
+			c.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer closeReader(reader)
+
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Range", r.ContentRange(size))
+		header.Set("Content-Length", strconv.FormatInt(r.Length, 10))
+		c.Writer.WriteHeader(http.StatusPartialContent)
+		if !head {
+			io.CopyN(c.Writer, reader, r.Length)
+		}
+	default:
+		parts := multipart.NewWriter(c.Writer)
+		header.Set("Content-Type", "multipart/byteranges; boundary="+parts.Boundary())
+		header.Del("Content-Length")
+		c.Writer.WriteHeader(http.StatusPartialContent)
+		if head {
+			return
+		}
+
+		for _, r := range ranges {
+			reader, err := readAt(r.Start, r.Length)
+			if err != nil {
+				// The status is already sent: leave the multipart body unterminated
+				return
+			}
+
+			part, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":  {contentType},
+				"Content-Range": {r.ContentRange(size)},
+			})
+			if err == nil {
+				_, err = io.CopyN(part, reader, r.Length)
+			}
+			closeReader(reader)
+			if err != nil {
+				return
+			}
+		}
+
+		parts.Close()
+	}
+}
+
+// rangeCurrent evaluates the If-Range precondition of the request against the ETag and Last-Modified
+// response headers, reporting whether the Range header applies.
+func (c *Context) rangeCurrent() bool {
+	ifRange := c.Request.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		etag := c.Writer.Header().Get("ETag")
+		// If-Range requires the strong comparison
+		return etag != "" && !strings.HasPrefix(ifRange, "W/") && ifRange == etag
+	}
+
+	lastModified, err := http.ParseTime(c.Writer.Header().Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	date, ok := parseHTTPDate(ifRange)
+	return ok && lastModified.Equal(date)
+}
+
+// closeReader closes reader if it implements io.Closer.
+func closeReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,205 @@
+package feather
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header string
+		size   int64
+		want   []Range
+		err    error
+	}{
+		{"", 100, nil, nil},
+		{"bytes=0-9", 100, []Range{{0, 10}}, nil},
+		{"bytes=90-", 100, []Range{{90, 10}}, nil},
+		{"bytes=-20", 100, []Range{{80, 20}}, nil},
+		{"bytes=-200", 100, []Range{{0, 100}}, nil},
+		{"bytes=50-500", 100, []Range{{50, 50}}, nil},
+		{"bytes=0-9, 20-29,-5", 100, []Range{{0, 10}, {20, 10}, {95, 5}}, nil},
+		{"bytes=0-49,25-74", 100, []Range{{0, 50}, {25, 50}}, nil},
+		{"bytes=0-9,100-199", 100, []Range{{0, 10}}, nil},
+		{"bytes=100-199", 100, nil, ErrRangeNotSatisfiable},
+		{"bytes=-0", 100, nil, ErrRangeNotSatisfiable},
+		{"bytes=0-9", 0, nil, ErrRangeNotSatisfiable},
+		{"bytes=-5", 0, nil, ErrRangeNotSatisfiable},
+		{"items=0-9", 100, nil, ErrInvalidRange},
+		{"bytes=9-0", 100, nil, ErrInvalidRange},
+		{"bytes=abc", 100, nil, ErrInvalidRange},
+		{"bytes=a-9", 100, nil, ErrInvalidRange},
+		{"bytes=--5", 100, nil, ErrInvalidRange},
+		{"bytes=+1-9", 100, nil, ErrInvalidRange},
+		{"bytes=1-+9", 100, nil, ErrInvalidRange},
+	}
+
+	for _, test := range tests {
+		c, _ := NewTestContext("GET", "/", nil)
+		if test.header != "" {
+			c.Request.Header.Set("Range", test.header)
+		}
+
+		ranges, err := c.ParseRange(test.size)
+		if err != test.err {
+			t.Errorf("%q: err = %v, want %v", test.header, err, test.err)
+		}
+		if len(ranges) != len(test.want) {
+			t.Errorf("%q: ranges = %v, want %v", test.header, ranges, test.want)
+			continue
+		}
+		for i := range ranges {
+			if ranges[i] != test.want[i] {
+				t.Errorf("%q: ranges = %v, want %v", test.header, ranges, test.want)
+			}
+		}
+	}
+}
+
+func TestRangeContentRange(t *testing.T) {
+	if got := (Range{Start: 0, Length: 100}).ContentRange(1000); got != "bytes 0-99/1000" {
+		t.Errorf("ContentRange = %q", got)
+	}
+}
+
+// rangeContent is the content served by the ServeRange tests.
+var rangeContent = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+// serveRange serves rangeContent with ServeRange for a request with the given headers.
+func serveRange(method string, headers map[string]string) *httptest.ResponseRecorder {
+	c, recorder := NewTestContext(method, "/media", nil)
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	c.Writer.Header().Set("ETag", `"v1"`)
+	c.Writer.Header().Set("Last-Modified", "Fri, 01 Mar 2024 12:00:00 GMT")
+
+	c.ServeRange(http.StatusOK, "text/plain", int64(len(rangeContent)), func(offset, length int64) (io.Reader, error) {
+		return bytes.NewReader(rangeContent[offset : offset+length]), nil
+	})
+
+	return recorder
+}
+
+func TestServeRangeSingle(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"full", nil, http.StatusOK, string(rangeContent), ""},
+		{"first bytes", map[string]string{"Range": "bytes=0-3"}, http.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"suffix", map[string]string{"Range": "bytes=-4"}, http.StatusPartialContent, "wxyz", "bytes 32-35/36"},
+		{"open ended", map[string]string{"Range": "bytes=30-"}, http.StatusPartialContent, "uvwxyz", "bytes 30-35/36"},
+		{"truncated", map[string]string{"Range": "bytes=34-99"}, http.StatusPartialContent, "yz", "bytes 34-35/36"},
+		{"malformed", map[string]string{"Range": "bytes=z-"}, http.StatusOK, string(rangeContent), ""},
+		{"overlapping beyond the size", map[string]string{"Range": "bytes=0-29,5-35"}, http.StatusOK, string(rangeContent), ""},
+		{"if-range etag", map[string]string{"Range": "bytes=0-3", "If-Range": `"v1"`}, http.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"if-range stale etag", map[string]string{"Range": "bytes=0-3", "If-Range": `"v0"`}, http.StatusOK, string(rangeContent), ""},
+		{"if-range weak etag", map[string]string{"Range": "bytes=0-3", "If-Range": `W/"v1"`}, http.StatusOK, string(rangeContent), ""},
+		{"if-range date", map[string]string{"Range": "bytes=0-3", "If-Range": "Fri, 01 Mar 2024 12:00:00 GMT"}, http.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"if-range stale date", map[string]string{"Range": "bytes=0-3", "If-Range": "Thu, 29 Feb 2024 12:00:00 GMT"}, http.StatusOK, string(rangeContent), ""},
+	}
+
+	for _, test := range tests {
+		recorder := serveRange("GET", test.headers)
+
+		if recorder.Code != test.status || recorder.Body.String() != test.body {
+			t.Errorf("%s: got %d with %q, want %d with %q", test.name, recorder.Code, recorder.Body.String(), test.status, test.body)
+		}
+		if got := recorder.Header().Get("Content-Range"); got != test.contentRange {
+			t.Errorf("%s: Content-Range = %q, want %q", test.name, got, test.contentRange)
+		}
+		if got := recorder.Header().Get("Accept-Ranges"); got != "bytes" {
+			t.Errorf("%s: Accept-Ranges = %q", test.name, got)
+		}
+	}
+}
+
+func TestServeRangeNotSatisfiable(t *testing.T) {
+	recorder := serveRange("GET", map[string]string{"Range": "bytes=36-40"})
+
+	if recorder.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("status = %d, want 416", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Range"); got != "bytes */36" {
+		t.Errorf("Content-Range = %q", got)
+	}
+
+	// A stale If-Range sends the full content instead
+	recorder = serveRange("GET", map[string]string{"Range": "bytes=36-40", "If-Range": `"v0"`})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status = %d with a stale If-Range, want 200", recorder.Code)
+	}
+}
+
+func TestServeRangeMultipart(t *testing.T) {
+	recorder := serveRange("GET", map[string]string{"Range": "bytes=0-3,10-12,-2"})
+
+	if recorder.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", recorder.Code)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q", recorder.Header().Get("Content-Type"))
+	}
+
+	want := []struct{ contentRange, body string }{
+		{"bytes 0-3/36", "0123"},
+		{"bytes 10-12/36", "abc"},
+		{"bytes 34-35/36", "yz"},
+	}
+
+	reader := multipart.NewReader(recorder.Body, params["boundary"])
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d parts, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if i >= len(want) {
+			t.Fatalf("unexpected part %d", i)
+		}
+
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Range") != want[i].contentRange || string(body) != want[i].body || part.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("part %d: %v with %q, want %q with %q", i, part.Header, body, want[i].contentRange, want[i].body)
+		}
+	}
+}
+
+func TestServeRangeHead(t *testing.T) {
+	recorder := serveRange("HEAD", map[string]string{"Range": "bytes=0-3"})
+
+	if recorder.Code != http.StatusPartialContent || recorder.Body.Len() != 0 {
+		t.Errorf("got %d with %q, want an empty 206", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Length"); got != "4" {
+		t.Errorf("Content-Length = %q", got)
+	}
+}
+
+func TestServeRangeReadError(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/media", nil)
+	c.ServeRange(http.StatusOK, "text/plain", 10, func(offset, length int64) (io.Reader, error) {
+		return nil, io.ErrUnexpectedEOF
+	})
+
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), "unexpected EOF") {
+		t.Errorf("got %d with %q", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,77 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPanicRunsPostFuncs(t *testing.T) {
+	server := NewServer()
+
+	runs := 0
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) { runs++ })
+	})
+	server.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/panic", nil))
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, want 500", recorder.Code)
+	}
+	if runs != 1 {
+		t.Errorf("the post function ran %d times, want 1", runs)
+	}
+}
+
+func TestPanickingPostFuncDoesNotSkipTheOthers(t *testing.T) {
+	server := NewServer()
+
+	var order []string
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) { order = append(order, "first") })
+		c.Post(func(c *Context) {
+			order = append(order, "panicking")
+			panic("boom")
+		})
+		c.Post(func(c *Context) { order = append(order, "last") })
+	})
+	server.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if len(order) != 3 || order[0] != "first" || order[1] != "panicking" || order[2] != "last" {
+		t.Errorf("post functions ran as %v, want [first panicking last]", order)
+	}
+}
+
+func TestAbortHandlerPanicRunsPostFuncs(t *testing.T) {
+	server := NewServer()
+
+	runs := 0
+	server.AddMiddleware(func(c *Context) {
+		c.Post(func(c *Context) { runs++ })
+	})
+	server.GET("/abort", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", recovered)
+			}
+		}()
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	}()
+
+	if runs != 1 {
+		t.Errorf("the post function ran %d times, want 1", runs)
+	}
+}
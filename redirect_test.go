@@ -0,0 +1,81 @@
+package feather
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	server := NewServer()
+	server.Redirect("/old/:id", "/new/:id", http.StatusMovedPermanently)
+	server.Redirect("/docs/*page", "/manual/*page?v=2", http.StatusFound)
+
+	tests := []struct {
+		url  string
+		code int
+		want string
+	}{
+		{"/old/42", http.StatusMovedPermanently, "/new/42"},
+		{"/old/42?tab=posts&page=2", http.StatusMovedPermanently, "/new/42?tab=posts&page=2"},
+		{"/docs/guide/install", http.StatusFound, "/manual/guide/install?v=2"},
+		{"/docs/guide?lang=fr", http.StatusFound, "/manual/guide?v=2&lang=fr"},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", test.url, nil))
+
+		if recorder.Code != test.code || recorder.Header().Get("Location") != test.want {
+			t.Errorf("%s: got %d to %q, want %d to %q", test.url, recorder.Code, recorder.Header().Get("Location"), test.code, test.want)
+		}
+	}
+}
+
+func TestRedirectHead(t *testing.T) {
+	server := NewServer()
+	server.Redirect("/old", "/new", http.StatusPermanentRedirect)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("HEAD", "/old", nil))
+	if recorder.Code != http.StatusPermanentRedirect || recorder.Header().Get("Location") != "/new" {
+		t.Errorf("got %d to %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+}
+
+func TestRedirectRejectsUnknownParameter(t *testing.T) {
+	server := NewServer()
+	builder := server.Redirect("/old/:id", "/new/:slug", http.StatusMovedPermanently)
+
+	if !errors.Is(builder.Err(), ErrUnknownParameter) {
+		t.Errorf("Err() = %v, want ErrUnknownParameter", builder.Err())
+	}
+	if len(server.Routes) != 0 {
+		t.Error("the route has been registered")
+	}
+}
+
+func TestRedirectEscapesParameters(t *testing.T) {
+	server := NewServer()
+	server.Redirect("/old/:id", "/new/:id", http.StatusMovedPermanently)
+	server.Redirect("/files/*path", "/archive/*path", http.StatusMovedPermanently)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/old/a%3Fb", "/new/a%3Fb"},
+		{"/old/a%20b", "/new/a%20b"},
+		{"/files/2024/a%3Fb.txt", "/archive/2024/a%3Fb.txt"},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", test.url, nil))
+
+		if location := recorder.Header().Get("Location"); location != test.want {
+			t.Errorf("%s: Location = %q, want %q", test.url, location, test.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrNonStandardMethod is the cause of the RouteError of a route registered for a non-standard HTTP method
+// while custom methods are not allowed, see Server.SetAllowCustomMethods.
+var ErrNonStandardMethod = errors.New("non-standard HTTP method")
+
+// ErrInvalidPattern is the cause of the RouteError of a route whose pattern does not compile to a valid regular expression.
+var ErrInvalidPattern = errors.New("invalid route pattern")
+
+// ErrUnknownParameter is the cause of the RouteError of a route referencing a parameter its pattern does not define.
+var ErrUnknownParameter = errors.New("unknown route parameter")
+
+// ErrUnknownHandler is the cause of the RouteError of a route definition naming a handler or a middleware
+// missing from the handlers given to RegisterRoutes.
+var ErrUnknownHandler = errors.New("unknown handler")
+
+// RouteError is the error returned when a route cannot be registered.
+type RouteError struct {
+	Pattern string // Pattern is the pattern of the route.
+	Err     error  // Err is the cause of the error, wrapping ErrNonStandardMethod, ErrInvalidPattern, ErrUnknownParameter or ErrUnknownHandler.
+}
+
+// Error returns a message naming the route and the cause of the error.
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("feather: cannot register the route %q: %v", e.Pattern, e.Err)
+}
+
+// Unwrap returns the cause of the error, so that errors.Is(err, ErrInvalidPattern) works.
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+/*
+	RouteBuilder is returned by the route registration methods (Handle, GET, POST, ...) to configure the
+	registered route further, by chaining its methods:
+
+		server.GET("/admin", handler).Name("admin").Use(auth).Meta("role", "admin").Header("Cache-Control", "no-store")
+
+	The builder references the stored route, so its changes are visible to every method the route was
+	registered for. The stored route is replaced by an updated copy rather than modified, so the builder
+	can be used while the server handles requests. Ignoring the returned builder is fine.
+
+	When the route could not be registered, the builder methods do nothing and Err returns the error.
+*/
+type RouteBuilder struct {
+	route  *Route  // route is the stored route configured by the builder, nil if the registration failed.
+	server *Server // server is the Server the route is registered on.
+	err    error   // err is the error of the registration, nil if the route has been registered.
+}
+
+// update applies change to a copy of the route, then replaces the route by the copy in the method tables.
+// The stored routes are never modified, since the requests read them without locking routesMutex.
+func (builder *RouteBuilder) update(change func(route *Route)) *RouteBuilder {
+	if builder.err != nil {
+		return builder
+	}
+
+	builder.server.routesMutex.Lock()
+	defer builder.server.routesMutex.Unlock()
+
+	updated := *builder.route
+	updated.Meta = maps.Clone(builder.route.Meta)
+	updated.Headers = builder.route.Headers.Clone()
+	updated.Middlewares = slices.Clip(builder.route.Middlewares)
+	change(&updated)
+
+	// The slices are copied on write as well, like in Server.HandleWithOptions
+	for method, routes := range builder.server.Routes {
+		if index := slices.Index(routes, builder.route); index != -1 {
+			routes = slices.Clone(routes)
+			routes[index] = &updated
+			builder.server.Routes[method] = routes
+		}
+	}
+
+	builder.route = &updated
+	return builder
+}
+
+/*
+	Err returns the error of the registration of the route.
+
+	Returns:
+		- error: A *RouteError if the route could not be registered, nil otherwise.
+*/
+func (builder *RouteBuilder) Err() error {
+	return builder.err
+}
+
+/*
+	Meta attaches a metadata value to the route, readable from middlewares and handlers with Context.RouteMeta.
+
+	Parameters:
+		- key (string): The key of the metadata.
+		- value (any): The value of the metadata. A value set again for the same key replaces the previous one.
+
+	Returns:
+		- *RouteBuilder: The builder itself, to chain further calls.
+*/
+func (builder *RouteBuilder) Meta(key string, value any) *RouteBuilder {
+	return builder.update(func(route *Route) {
+		route.Meta[key] = value
+	})
+}
+
+/*
+	Header sets a default response header of the route, e.g. long cache headers for static routes.
+
+	The header is set before the middlewares and the handler run, so the handler can override it.
+	It overrides the default headers of the group the route was registered with, see Group.DefaultHeaders.
+
+	Parameters:
+		- key (string): The name of the header.
+		- value (string): The value of the header. A value set again for the same key replaces the previous one.
+
+	Returns:
+		- *RouteBuilder: The builder itself, to chain further calls.
+*/
+func (builder *RouteBuilder) Header(key string, value string) *RouteBuilder {
+	return builder.update(func(route *Route) {
+		route.Headers.Set(key, value)
+	})
+}
+
+/*
+	Name sets the name of the route, used to identify it in the route table returned by Server.RouteMap.
+
+	Parameters:
+		- name (string): The name of the route (e.g. "user.show").
+
+	Returns:
+		- *RouteBuilder: The builder itself, to chain further calls.
+*/
+func (builder *RouteBuilder) Name(name string) *RouteBuilder {
+	return builder.update(func(route *Route) {
+		route.Name = name
+	})
+}
+
+/*
+	Use adds middlewares to the route only, run after the middlewares of the server and before the handler.
+
+	Parameters:
+		- middlewares (...HandlerFunc): The middlewares to add, run in order.
+
+	Returns:
+		- *RouteBuilder: The builder itself, to chain further calls.
+*/
+func (builder *RouteBuilder) Use(middlewares ...HandlerFunc) *RouteBuilder {
+	return builder.update(func(route *Route) {
+		route.Middlewares = append(route.Middlewares, middlewares...)
+	})
+}
+
+// RouteDoc documents a route, for the documentation generators (e.g. OpenAPI) reading Server.RouteMap.
+type RouteDoc struct {
+	Summary     string   `json:"summary,omitempty"`     // Summary is a short summary of what the route does.
+	Description string   `json:"description,omitempty"` // Description is a detailed description of the route.
+	Tags        []string `json:"tags,omitempty"`        // Tags groups the routes in the generated documentation.
+}
+
+/*
+	Doc attaches documentation to the route, exposed by Server.RouteMap.
+
+	Parameters:
+		- doc (RouteDoc): The documentation of the route, replacing any previous one.
+
+	Returns:
+		- *RouteBuilder: The builder itself, to chain further calls.
+*/
+func (builder *RouteBuilder) Doc(doc RouteDoc) *RouteBuilder {
+	return builder.update(func(route *Route) {
+		route.Doc = doc
+	})
+}
+
+// RouteInfo describes a registered route, see Server.RouteMap.
+type RouteInfo struct {
+	Pattern     string   // Pattern is the URL pattern the route was registered with (e.g. "/user/:id").
+	Params      []string // Params is the list of the names of the parameters of the route, in order.
+	Name        string   // Name is the name of the route, empty if it has not been named.
+	RegexString string   // RegexString is the source of the regular expression matching the route.
+	Doc         RouteDoc // Doc is the documentation of the route.
+	Host        string   // Host is the host pattern of the route (see Server.HostPattern), empty if it matches every host.
+	Priority    int      // Priority is the priority of the route, see RouteOptions.
+}
+
+/*
+	RouteMap returns the route table of the server, for programmatic inspection (documentation generators,
+	test coverage tools, ...).
+
+	Returns:
+		- map[string][]RouteInfo: The routes indexed by HTTP method, in the order they are matched. The returned
+				values are copies: modifying them does not affect the server.
+*/
+func (server *Server) RouteMap() map[string][]RouteInfo {
+	server.routesMutex.RLock()
+	defer server.routesMutex.RUnlock()
+
+	table := make(map[string][]RouteInfo, len(server.Routes))
+
+	for method, routes := range server.Routes {
+		infos := make([]RouteInfo, len(routes))
+		for i, route := range routes {
+			infos[i] = RouteInfo{
+				Pattern:     route.Pattern,
+				Params:      append([]string(nil), route.Params...),
+				Name:        route.Name,
+				RegexString: route.Regex.String(),
+				Doc:         route.Doc,
+				Priority:    route.Priority,
+			}
+			if route.group != nil && route.group.host != nil {
+				infos[i].Host = route.group.host.pattern
+			}
+		}
+
+		table[method] = infos
+	}
+
+	return table
+}
@@ -0,0 +1,125 @@
+package feather
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRouteBuilderUpdatesEveryMethod(t *testing.T) {
+	server := NewServer()
+	builder, err := server.Handle("/items", func(c *Context) {
+		c.String(200, c.RouteMeta("kind").(string))
+	}, "GET", "POST")
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	builder.Name("items").Meta("kind", "list").Header("X-Items", "1")
+
+	for _, method := range []string{"GET", "POST"} {
+		route := server.Routes[method][0]
+		if route != builder.route {
+			t.Errorf("%s: the method table does not hold the updated route", method)
+		}
+		if route.Name != "items" {
+			t.Errorf("%s: Name = %q, want %q", method, route.Name, "items")
+		}
+
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(method, "/items", nil))
+		if recorder.Body.String() != "list" || recorder.Header().Get("X-Items") != "1" {
+			t.Errorf("%s: got body %q and X-Items %q", method, recorder.Body.String(), recorder.Header().Get("X-Items"))
+		}
+	}
+}
+
+func TestRouteBuilderDoesNotModifyPublishedRoute(t *testing.T) {
+	server := NewServer()
+	builder := server.GET("/items", func(c *Context) {})
+	published := server.Routes["GET"][0]
+
+	builder.Meta("kind", "list").Use(func(c *Context) {})
+
+	if len(published.Meta) != 0 || len(published.Middlewares) != 0 {
+		t.Error("the builder modified the route already read by the requests")
+	}
+}
+
+func TestRouteBuilderConcurrentWithRequests(t *testing.T) {
+	server := NewServer()
+	builder := server.GET("/items/:id", func(c *Context) {
+		_ = c.RouteMeta("kind")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/1", nil))
+		}()
+		go func() {
+			defer wg.Done()
+			builder.Meta("kind", "item").Header("Cache-Control", "no-store")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRouteMetaAuthorization(t *testing.T) {
+	var audited []string
+
+	server := NewServer()
+	// A single middleware enforces the permissions declared on the routes
+	server.AddMiddleware(func(c *Context) {
+		if role, ok := c.RouteMeta("role").(string); ok && c.Request.Header.Get("X-Role") != role {
+			c.String(http.StatusForbidden, "forbidden")
+			c.Abort()
+			return
+		}
+		if c.RouteMeta("audit") == true {
+			audited = append(audited, c.Request.URL.Path)
+		}
+	})
+
+	ok := func(c *Context) { c.String(http.StatusOK, "ok") }
+	server.GET("/admin", ok).Meta("role", "admin").Meta("audit", true)
+	server.GET("/public", ok)
+	server.Group("/reports").GET("/:id", ok).Meta("role", "analyst")
+
+	tests := []struct {
+		path   string
+		role   string
+		status int
+	}{
+		{"/admin", "", http.StatusForbidden},
+		{"/admin", "analyst", http.StatusForbidden},
+		{"/admin", "admin", http.StatusOK},
+		{"/public", "", http.StatusOK},
+		{"/reports/7", "admin", http.StatusForbidden},
+		{"/reports/7", "analyst", http.StatusOK},
+		{"/missing", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		recorder := server.TestRequest("GET", test.path, nil, map[string]string{"X-Role": test.role})
+		if recorder.Code != test.status {
+			t.Errorf("GET %s as %q = %d, want %d", test.path, test.role, recorder.Code, test.status)
+		}
+	}
+
+	// Only the authorized requests reach the audit
+	if len(audited) != 1 || audited[0] != "/admin" {
+		t.Errorf("audited = %v, want [/admin]", audited)
+	}
+}
+
+func TestRouteMetaWithoutRoute(t *testing.T) {
+	c, _ := NewTestContext("GET", "/", nil)
+
+	if value := c.RouteMeta("role"); value != nil {
+		t.Errorf("RouteMeta = %v, want nil without a matched route", value)
+	}
+}
@@ -0,0 +1,175 @@
+package feather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+)
+
+// RouteDef is the declarative description of a route for one HTTP method, emitted by Server.ExportRoutes
+// and registered by RegisterRoutes.
+type RouteDef struct {
+	Method      string         `json:"method"`                // Method is the HTTP method of the route.
+	Pattern     string         `json:"pattern"`               // Pattern is the URL pattern of the route, group prefix included.
+	Host        string         `json:"host,omitempty"`        // Host is the host pattern of the route, see Server.HostPattern.
+	Name        string         `json:"name,omitempty"`        // Name is the name of the route, see RouteBuilder.Name.
+	Priority    int            `json:"priority,omitempty"`    // Priority is the priority of the route, see RouteOptions.
+	Handler     string         `json:"handler"`               // Handler is the name of the handler of the route.
+	Middlewares []string       `json:"middlewares,omitempty"` // Middlewares are the names of the middlewares of the route, see RouteBuilder.Use.
+	Meta        map[string]any `json:"meta,omitempty"`        // Meta is the metadata of the route, see RouteBuilder.Meta.
+	Headers     http.Header    `json:"headers,omitempty"`     // Headers are the default response headers of the route, see RouteBuilder.Header.
+	Doc         RouteDoc       `json:"doc,omitzero"`          // Doc is the documentation of the route, see RouteBuilder.Doc.
+}
+
+/*
+	ExportRoutes writes the route table of the server as a JSON array of RouteDef, for audits and for comparing
+	the route tables of two releases (e.g. in CI).
+
+	The output is stable: the routes are sorted by HTTP method, then listed in the order they are matched, and the
+	keys of their metadata and headers are sorted. A route registered for several methods is listed once per method.
+	The handlers and the middlewares are named after the names given to RegisterRoutes, or after their Go function
+	names otherwise (e.g. "main.listUsers", or "main.main.func1" for a function literal). The middlewares of the
+	server are not listed.
+
+	Parameters:
+		- w (io.Writer): The writer receiving the JSON document.
+
+	Returns:
+		- error: An error if a metadata value cannot be encoded in JSON or the document cannot be written.
+*/
+func (server *Server) ExportRoutes(w io.Writer) error {
+	server.routesMutex.RLock()
+	defs := make([]RouteDef, 0)
+	for _, method := range slices.Sorted(maps.Keys(server.Routes)) {
+		for _, route := range server.Routes[method] {
+			defs = append(defs, route.def(method))
+		}
+	}
+	server.routesMutex.RUnlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(defs)
+}
+
+// def returns the definition of the route for method, see ExportRoutes.
+func (route *Route) def(method string) RouteDef {
+	def := RouteDef{
+		Method:   method,
+		Pattern:  route.Pattern,
+		Name:     route.Name,
+		Priority: route.Priority,
+		Handler:  route.handlerName,
+		Meta:     maps.Clone(route.Meta),
+		Headers:  route.Headers.Clone(),
+		Doc:      route.Doc,
+	}
+	if route.group != nil && route.group.host != nil {
+		def.Host = route.group.host.pattern
+	}
+	if def.Handler == "" {
+		def.Handler = funcName(route.Handler)
+	}
+
+	for i, middleware := range route.Middlewares {
+		if i < len(route.middlewareNames) {
+			def.Middlewares = append(def.Middlewares, route.middlewareNames[i])
+		} else {
+			def.Middlewares = append(def.Middlewares, funcName(middleware))
+		}
+	}
+
+	return def
+}
+
+// funcName returns the Go name of a function, e.g. "main.listUsers", or an empty string for a nil function.
+func funcName(fn HandlerFunc) string {
+	if fn == nil {
+		return ""
+	}
+
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+/*
+	RegisterRoutes registers the routes described by a declarative slice of RouteDef, e.g. generated from an API
+	specification or decoded from the output of Server.ExportRoutes, which the routes registered this way
+	reproduce identically.
+
+	The handler and the middlewares of each route are looked up by name in handlers. A route whose handler or
+	middlewares are missing is not registered, and its *RouteError wraps ErrUnknownHandler. The other routes are
+	still registered, and every error is also reported by Server.Err.
+
+	Parameters:
+		- s (*Server): The server to register the routes on.
+		- defs ([]RouteDef): The definitions of the routes, registered in order.
+		- handlers (map[string]HandlerFunc): The handlers and middlewares, indexed by the names used in defs.
+
+	Returns:
+		- error: The errors of the routes which could not be registered, joined with errors.Join, nil otherwise.
+*/
+func RegisterRoutes(s *Server, defs []RouteDef, handlers map[string]HandlerFunc) error {
+	errs := make([]error, 0)
+
+	for _, def := range defs {
+		if err := s.registerRoute(def, handlers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// registerRoute registers the route of a definition, see RegisterRoutes.
+func (server *Server) registerRoute(def RouteDef, handlers map[string]HandlerFunc) error {
+	handler, ok := handlers[def.Handler]
+	if !ok {
+		_, err := server.routeError(def.Pattern, fmt.Errorf("%w: %q", ErrUnknownHandler, def.Handler))
+		return err
+	}
+
+	middlewares := make([]HandlerFunc, len(def.Middlewares))
+	for i, name := range def.Middlewares {
+		if middlewares[i], ok = handlers[name]; !ok {
+			_, err := server.routeError(def.Pattern, fmt.Errorf("%w: middleware %q", ErrUnknownHandler, name))
+			return err
+		}
+	}
+
+	opts := RouteOptions{Methods: []string{def.Method}, Priority: def.Priority}
+
+	var builder *RouteBuilder
+	var err error
+	if def.Host != "" {
+		builder, err = server.HostPattern(def.Host).handleWithOptions(def.Pattern, handler, opts)
+	} else {
+		builder, err = server.HandleWithOptions(def.Pattern, handler, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	builder.update(func(route *Route) {
+		route.Name = def.Name
+		route.Middlewares = append(route.Middlewares, middlewares...)
+		route.Doc = def.Doc
+		for key, value := range def.Meta {
+			route.Meta[key] = value
+		}
+		for name, values := range def.Headers {
+			route.Headers[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+
+		route.handlerName = def.Handler
+		route.middlewareNames = slices.Clone(def.Middlewares)
+	})
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package feather
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func listUsers(c *Context)  { c.String(http.StatusOK, "users") }
+func showUser(c *Context)   { c.String(http.StatusOK, "user "+c.Params["id"]) }
+func findUser(c *Context)   { c.String(http.StatusOK, "user "+c.Params["name"]) }
+func createUser(c *Context) { c.String(http.StatusCreated, "created") }
+func showTenant(c *Context) { c.String(http.StatusOK, "tenant "+c.Params["tenant"]) }
+func requireAuth(c *Context) {
+	if c.Request.Header.Get("Authorization") == "" {
+		c.String(http.StatusUnauthorized, "unauthorized")
+		c.Abort()
+	}
+}
+
+// routeTableServer returns a server whose route table uses every field of RouteDef.
+func routeTableServer() *Server {
+	server := NewServer()
+
+	api := server.Group("/api")
+	api.GET("/users", listUsers).Name("users.list").Meta("public", true).Header("Cache-Control", "max-age=60")
+	api.GET("/users/:id|[0-9]+", showUser).Name("users.show").Use(requireAuth).Doc(RouteDoc{
+		Summary: "Show a user",
+		Tags:    []string{"users"},
+	})
+	api.POST("/users", createUser).Use(requireAuth).Meta("scope", "users:write")
+	server.HandleWithOptions("/api/users/:name", findUser, RouteOptions{Priority: -1})
+	server.HostPattern(":tenant.example.com").GET("/", showTenant).Name("tenant.home")
+
+	return server
+}
+
+// exportRoutes returns the output of ExportRoutes.
+func exportRoutes(t *testing.T, server *Server) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := server.ExportRoutes(&buffer); err != nil {
+		t.Fatalf("ExportRoutes: %v", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestExportRoutes(t *testing.T) {
+	var defs []RouteDef
+	if err := json.Unmarshal(exportRoutes(t, routeTableServer()), &defs); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if len(defs) != 5 {
+		t.Fatalf("exported %d routes, want 5: %+v", len(defs), defs)
+	}
+
+	// The methods are sorted, then the routes are listed in the order they are matched: static routes first
+	wantOrder := []string{"GET /api/users", "GET /", "GET /api/users/:id|[0-9]+", "GET /api/users/:name", "POST /api/users"}
+	for i, def := range defs {
+		if got := def.Method + " " + def.Pattern; got != wantOrder[i] {
+			t.Errorf("route %d = %q, want %q", i, got, wantOrder[i])
+		}
+	}
+
+	show := defs[2]
+	if show.Name != "users.show" || show.Handler != funcName(showUser) || len(show.Middlewares) != 1 || show.Middlewares[0] != funcName(requireAuth) {
+		t.Errorf("users.show = %+v", show)
+	}
+	if show.Doc.Summary != "Show a user" {
+		t.Errorf("doc = %+v", show.Doc)
+	}
+	if defs[1].Host != ":tenant.example.com" {
+		t.Errorf("host = %q", defs[1].Host)
+	}
+	if defs[0].Meta["public"] != true || defs[0].Headers.Get("Cache-Control") != "max-age=60" {
+		t.Errorf("users.list = %+v", defs[0])
+	}
+	if defs[3].Priority != -1 {
+		t.Errorf("priority = %d, want -1", defs[3].Priority)
+	}
+
+	// The output is stable
+	if first, second := exportRoutes(t, routeTableServer()), exportRoutes(t, routeTableServer()); !bytes.Equal(first, second) {
+		t.Errorf("two exports differ:\n%s\n%s", first, second)
+	}
+}
+
+func TestRegisterRoutesRoundTrip(t *testing.T) {
+	exported := exportRoutes(t, routeTableServer())
+
+	var defs []RouteDef
+	if err := json.Unmarshal(exported, &defs); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	handlers := map[string]HandlerFunc{
+		funcName(listUsers):   listUsers,
+		funcName(showUser):    showUser,
+		funcName(findUser):    findUser,
+		funcName(createUser):  createUser,
+		funcName(showTenant):  showTenant,
+		funcName(requireAuth): requireAuth,
+	}
+
+	server := NewServer()
+	if err := RegisterRoutes(server, defs, handlers); err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+
+	if reexported := exportRoutes(t, server); !bytes.Equal(exported, reexported) {
+		t.Errorf("export -> register -> export differs:\n%s\n%s", exported, reexported)
+	}
+
+	// The registered routes behave like the original ones
+	tests := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"GET", "/api/users", nil, http.StatusOK, "users"},
+		{"GET", "/api/users/7", nil, http.StatusUnauthorized, "unauthorized"},
+		{"GET", "/api/users/7", map[string]string{"Authorization": "token"}, http.StatusOK, "user 7"},
+		{"GET", "/api/users/alice", nil, http.StatusOK, "user alice"},
+		{"POST", "/api/users", map[string]string{"Authorization": "token"}, http.StatusCreated, "created"},
+		{"GET", "http://acme.example.com/", nil, http.StatusOK, "tenant acme"},
+	}
+	for _, test := range tests {
+		recorder := server.TestRequest(test.method, test.path, nil, test.headers)
+		if recorder.Code != test.status || recorder.Body.String() != test.body {
+			t.Errorf("%s %s = %d %q, want %d %q", test.method, test.path, recorder.Code, recorder.Body.String(), test.status, test.body)
+		}
+	}
+
+	if recorder := server.TestRequest("GET", "/api/users", nil, nil); recorder.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("Cache-Control = %q, want the header of the definition", recorder.Header().Get("Cache-Control"))
+	}
+}
+
+func TestRegisterRoutesUnknownHandler(t *testing.T) {
+	defs := []RouteDef{
+		{Method: "GET", Pattern: "/users", Handler: "listUsers"},
+		{Method: "GET", Pattern: "/missing", Handler: "missing"},
+		{Method: "GET", Pattern: "/guarded", Handler: "listUsers", Middlewares: []string{"unknownMiddleware"}},
+	}
+
+	server := NewServer()
+	err := RegisterRoutes(server, defs, map[string]HandlerFunc{"listUsers": listUsers})
+
+	if !errors.Is(err, ErrUnknownHandler) {
+		t.Fatalf("err = %v, want ErrUnknownHandler", err)
+	}
+	var routeErr *RouteError
+	if !errors.As(err, &routeErr) || routeErr.Pattern != "/missing" {
+		t.Errorf("err = %v, want a *RouteError for /missing", err)
+	}
+
+	// The valid definitions are still registered
+	if recorder := server.TestRequest("GET", "/users", nil, nil); recorder.Code != http.StatusOK {
+		t.Errorf("GET /users = %d, want 200", recorder.Code)
+	}
+	for _, path := range []string{"/missing", "/guarded"} {
+		if recorder := server.TestRequest("GET", path, nil, nil); recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package feather
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+/*
+	RunOptions configures Run.
+*/
+type RunOptions struct {
+	// Signals are the signals triggering the shutdown. SIGINT and SIGTERM are used when it is empty.
+	Signals []os.Signal
+
+	// TLSAddr is the address of an additional HTTPS listener, in the format "host:port" (e.g. ":8443").
+	// No HTTPS listener is started when it is empty.
+	TLSAddr string
+
+	// CertFile and KeyFile are the paths of the certificate and of the private key of the HTTPS listener.
+	CertFile string
+	KeyFile  string
+}
+
+/*
+	Run starts the HTTP server on the given address and blocks until a shutdown signal (SIGINT or SIGTERM by default)
+	is received, replacing the signal handling boilerplate of the main functions.
+
+	On signal, a shutdown banner is printed, the server stops accepting connections and waits for the in-flight
+	requests and the tasks registered with Context.Background, for at most ShutdownTimeout. The functions registered
+	with OnShutdown are then run.
+
+	Parameters:
+		- addr (string): The address to listen on, in the format "host:port" (e.g. ":8080").
+		- options (...RunOptions): Optional settings, to change the signals or to also serve HTTPS.
+
+	Returns:
+		- error: The error of a listener if it fails to start or stops unexpectedly, or the error of the shutdown
+				(a *ShutdownError when the requests did not complete in time). It returns nil after a clean exit.
+*/
+func (server *Server) Run(addr string, options ...RunOptions) error {
+	var opts RunOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	signals := opts.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+
+	httpServer := server.newHTTPServer(addr)
+	httpServers := []*http.Server{httpServer}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	if opts.TLSAddr != "" {
+		tlsServer := server.newHTTPServer(opts.TLSAddr)
+		httpServers = append(httpServers, tlsServer)
+
+		go func() {
+			serveErr <- tlsServer.ListenAndServeTLS(opts.CertFile, opts.KeyFile)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		for _, httpServer := range httpServers {
+			httpServer.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	printShutdown(fmt.Sprintf("Shutting down, waiting for %d requests", server.InFlight()))
+	return server.drain(serveErr, httpServers...)
+}
+
+/*
+	OnShutdown registers a function run at the end of a graceful shutdown (ListenWithShutdown, ListenGraceful, Run),
+	once the in-flight requests and the background tasks are done, e.g. to close a database pool.
+
+	Parameters:
+		- hook (func()): The function to run. The hooks are run in the order they are registered.
+
+	Returns:
+		- This function does not return any value.
+*/
+func (server *Server) OnShutdown(hook func()) {
+	server.shutdownHooks = append(server.shutdownHooks, hook)
+}
+
+// runShutdownHooks runs the functions registered with OnShutdown.
+func (server *Server) runShutdownHooks() {
+	for _, hook := range server.shutdownHooks {
+		hook()
+	}
+}
+
+// printShutdown prints message in the same format as the message lines of the Logging middleware.
+func printShutdown(message string) {
+	fmt.Printf("\033[1m%s\033[0m │%s %s \033[0m│ %-20s │ %s\n",
+		time.Now().Format("2006/01/02 15:04:05.000"),
+		"\033[45m",
+		" INFO",
+		"Run",
+		message,
+	)
+}
@@ -0,0 +1,127 @@
+//go:build unix
+
+package feather
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runUntilSignal starts Run on a free address, waits for it to listen, then sends sig to the process.
+// It returns the time Run took to return after the signal, and its error.
+func runUntilSignal(t *testing.T, server *Server, sig syscall.Signal, options ...RunOptions) (time.Duration, error) {
+	t.Helper()
+
+	addr := freeAddr(t)
+	result := make(chan error, 1)
+	go func() { result <- server.Run(addr, options...) }()
+
+	// The signal handler is installed before the listener, so the signal cannot kill the test once it listens
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	start := time.Now()
+	if err := syscall.Kill(os.Getpid(), sig); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-result:
+		return time.Since(start), err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the signal")
+		return 0, nil
+	}
+}
+
+func TestRunStopsOnSIGTERM(t *testing.T) {
+	server := NewServer()
+	server.ShutdownTimeout = time.Second
+
+	hooked := false
+	server.OnShutdown(func() { hooked = true })
+
+	elapsed, err := runUntilSignal(t, server, syscall.SIGTERM)
+	if err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if elapsed > server.ShutdownTimeout {
+		t.Errorf("Run returned after %v, beyond the grace period", elapsed)
+	}
+	if !hooked {
+		t.Error("the OnShutdown hooks have not run")
+	}
+}
+
+func TestRunWaitsForInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+
+	server := NewServer()
+	server.ShutdownTimeout = 2 * time.Second
+	server.GET("/slow", func(c *Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	result := make(chan error, 1)
+	go func() { result <- server.Run(addr) }()
+
+	status := make(chan int, 1)
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	waitFor(t, "the request to be in flight", func() bool { return server.InFlight() == 1 })
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+
+	if err := <-result; err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("the in-flight request got %d, want 200", code)
+	}
+}
+
+func TestRunCustomSignals(t *testing.T) {
+	server := NewServer()
+
+	_, err := runUntilSignal(t, server, syscall.SIGUSR1, RunOptions{Signals: []os.Signal{syscall.SIGUSR1}})
+	if err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+}
+
+func TestRunListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// The address is already in use: Run fails instead of waiting for a signal
+	if err := NewServer().Run(listener.Addr().String()); err == nil {
+		t.Error("Run = nil for an address in use")
+	}
+}
@@ -0,0 +1,225 @@
+package feather
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address with a port free to listen on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+// waitFor polls condition until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	release := make(chan struct{})
+
+	server := NewServer()
+	server.GET("/slow", func(c *Context) {
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	server.GET("/panic", func(c *Context) {
+		<-release
+		panic("boom")
+	})
+
+	var wg sync.WaitGroup
+	for _, url := range []string{"/slow", "/slow", "/slow", "/panic"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+		}()
+	}
+
+	waitFor(t, "4 requests in flight", func() bool { return server.InFlight() == 4 })
+
+	close(release)
+	wg.Wait()
+
+	// The requests are no longer counted once handled, including the one which panicked
+	if n := server.InFlight(); n != 0 {
+		t.Errorf("InFlight = %d after the requests, want 0", n)
+	}
+}
+
+func TestShutdownDrainsSlowRequests(t *testing.T) {
+	addr := freeAddr(t)
+
+	server := NewServer()
+	server.ShutdownTimeout = 2 * time.Second
+	server.GET("/slow", func(c *Context) {
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- server.ListenWithShutdown(addr, ctx) }()
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	response := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			t.Error(err)
+		}
+		response <- resp
+	}()
+
+	waitFor(t, "the request to be in flight", func() bool { return server.InFlight() == 1 })
+	cancel()
+
+	if err := <-result; err != nil {
+		t.Errorf("ListenWithShutdown = %v, want a clean shutdown", err)
+	}
+	if resp := <-response; resp == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("the in-flight request has not completed: %v", resp)
+	} else {
+		resp.Body.Close()
+	}
+}
+
+func TestShutdownTimeoutForcesClose(t *testing.T) {
+	addr := freeAddr(t)
+	release := make(chan struct{})
+	defer close(release)
+
+	server := NewServer()
+	server.ShutdownTimeout = 100 * time.Millisecond
+	server.GET("/stuck", func(c *Context) {
+		<-release
+	})
+
+	hooked := false
+	server.OnShutdown(func() { hooked = true })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- server.ListenWithShutdown(addr, ctx) }()
+	waitFor(t, "the server to listen", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	})
+
+	clientErr := make(chan error, 2)
+	for range 2 {
+		go func() {
+			resp, err := http.Get("http://" + addr + "/stuck")
+			if err == nil {
+				resp.Body.Close()
+			}
+			clientErr <- err
+		}()
+	}
+
+	waitFor(t, "the requests to be in flight", func() bool { return server.InFlight() == 2 })
+	start := time.Now()
+	cancel()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the shutdown did not stop at the timeout")
+	}
+
+	var shutdownErr *ShutdownError
+	if !errors.As(err, &shutdownErr) || shutdownErr.CutOff != 2 {
+		t.Fatalf("ListenWithShutdown = %v, want a *ShutdownError cutting off 2 requests", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("the error does not wrap context.DeadlineExceeded")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("the shutdown returned after %v, before the timeout", elapsed)
+	}
+	if !hooked {
+		t.Error("the OnShutdown hooks have not run")
+	}
+
+	// The connections of the requests cut off are closed
+	for range 2 {
+		if err := <-clientErr; err == nil {
+			t.Error("a request cut off got a response")
+		}
+	}
+}
+
+func TestOnStateChange(t *testing.T) {
+	addr := freeAddr(t)
+
+	var mutex sync.Mutex
+	states := make(map[http.ConnState]int)
+
+	server := NewServer()
+	server.OnStateChange(func(conn net.Conn, state http.ConnState) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		states[state]++
+	})
+	server.GET("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- server.ListenWithShutdown(addr, ctx) }()
+
+	var resp *http.Response
+	waitFor(t, "a response", func() bool {
+		var err error
+		resp, err = http.Get("http://" + addr + "/")
+		return err == nil
+	})
+	resp.Body.Close()
+	http.DefaultClient.CloseIdleConnections()
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Fatalf("ListenWithShutdown = %v", err)
+	}
+
+	// The closed state is reported by the goroutine of the connection, possibly after the shutdown returned
+	for _, state := range []http.ConnState{http.StateNew, http.StateActive, http.StateIdle, http.StateClosed} {
+		waitFor(t, "the "+state.String()+" state", func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return states[state] > 0
+		})
+	}
+}
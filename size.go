@@ -0,0 +1,60 @@
+package feather
+
+import (
+	"io"
+	"net/http"
+)
+
+// countingBody is the body of the requests handled by the server, counting the bytes read from the wire
+// without buffering them. The readers installed afterwards by the middlewares and helpers (e.g. the
+// http.MaxBytesReader of EachPart, or a decompressing reader) wrap it, so that it always counts the raw bytes.
+type countingBody struct {
+	io.ReadCloser
+
+	read int64 // read is the number of bytes read so far.
+}
+
+// Read reads from the wrapped body and counts the bytes read.
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.read += int64(n)
+
+	return n, err
+}
+
+// countBody wraps the body of the request with the counting body of the Context, see RequestSize.
+// The counting body is shared with the copies of the Context (e.g. WithValue), and kept by the pooled
+// Contexts, so that no allocation is needed once they are reused.
+func (c *Context) countBody() {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+
+	if c.body == nil {
+		c.body = new(countingBody)
+	}
+	*c.body = countingBody{ReadCloser: c.Request.Body}
+	c.Request.Body = c.body
+}
+
+// RequestSize returns the size of the body of the request as sent on the wire, for the logging, metrics
+// or bandwidth-based rate limiting middlewares.
+//
+// It is the Content-Length of the request when it is known. For the chunked bodies, it is the number of
+// bytes read from the body so far: it is complete once the handler has read the whole body, e.g. in the
+// functions registered with Post. The body is never buffered to measure it. When the body is compressed,
+// the size is the one of the compressed bytes.
+//
+// Returns:
+//   - The size of the body of the request, in bytes. It is 0 for the requests without a body.
+func (c *Context) RequestSize() int64 {
+	if c.Request.ContentLength >= 0 {
+		return c.Request.ContentLength
+	}
+
+	if c.body == nil {
+		return 0
+	}
+
+	return c.body.read
+}
@@ -0,0 +1,149 @@
+package feather
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// chunkedRequest returns a request whose body has no Content-Length, as sent with the chunked transfer encoding.
+func chunkedRequest(method string, path string, body []byte) *http.Request {
+	request := httptest.NewRequest(method, path, io.MultiReader(bytes.NewReader(body)))
+	request.TransferEncoding = []string{"chunked"}
+
+	return request
+}
+
+// gzipped compresses data with gzip.
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestRequestSizeContentLength(t *testing.T) {
+	var size int64
+
+	server := NewServer()
+	server.POST("/upload", func(c *Context) {
+		// The Content-Length is known before the body is read
+		size = c.RequestSize()
+	})
+
+	server.TestRequest("POST", "/upload", strings.NewReader("hello world"), nil)
+
+	if size != 11 {
+		t.Errorf("RequestSize = %d, want 11", size)
+	}
+}
+
+func TestRequestSizeChunked(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+
+	var before, partial, after, post int64
+	server := NewServer()
+	server.POST("/upload", func(c *Context) {
+		before = c.RequestSize()
+
+		io.ReadFull(c.Request.Body, make([]byte, 100))
+		partial = c.RequestSize()
+
+		io.Copy(io.Discard, c.Request.Body)
+		after = c.RequestSize()
+
+		c.Post(func(c *Context) {
+			post = c.RequestSize()
+		})
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), chunkedRequest("POST", "/upload", body))
+
+	if before != 0 || partial != 100 {
+		t.Errorf("RequestSize = %d before reading and %d after 100 bytes, want 0 and 100", before, partial)
+	}
+	if after != int64(len(body)) || post != int64(len(body)) {
+		t.Errorf("RequestSize = %d after reading and %d in Post, want %d", after, post, len(body))
+	}
+}
+
+func TestRequestSizeGzipBody(t *testing.T) {
+	original := bytes.Repeat([]byte(`{"event":"click"}`+"\n"), 500)
+	compressed := gzipped(t, original)
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+	}{
+		{"content length", func() *http.Request {
+			return httptest.NewRequest("POST", "/events", bytes.NewReader(compressed))
+		}},
+		{"chunked", func() *http.Request {
+			return chunkedRequest("POST", "/events", compressed)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var wire, decompressed int64
+
+			server := NewServer()
+			server.POST("/events", func(c *Context) {
+				// The readers installed by the handler wrap the counting body, which still counts the wire bytes
+				limited := http.MaxBytesReader(c.Writer, c.Request.Body, 1<<20)
+				reader, err := gzip.NewReader(limited)
+				if err != nil {
+					c.String(http.StatusBadRequest, err.Error())
+					return
+				}
+
+				decompressed, _ = io.Copy(io.Discard, reader)
+				wire = c.RequestSize()
+			})
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, test.request())
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+			}
+			if wire != int64(len(compressed)) {
+				t.Errorf("RequestSize = %d, want the %d compressed bytes", wire, len(compressed))
+			}
+			if decompressed != int64(len(original)) {
+				t.Errorf("decompressed %d bytes, want %d", decompressed, len(original))
+			}
+		})
+	}
+}
+
+func TestRequestSizePooledContext(t *testing.T) {
+	var sizes []int64
+
+	server := NewServer()
+	server.POST("/upload", func(c *Context) {
+		io.Copy(io.Discard, c.Request.Body)
+		sizes = append(sizes, c.RequestSize())
+	})
+
+	// The counter of a reused Context starts from zero
+	server.ServeHTTP(httptest.NewRecorder(), chunkedRequest("POST", "/upload", make([]byte, 300)))
+	server.ServeHTTP(httptest.NewRecorder(), chunkedRequest("POST", "/upload", make([]byte, 20)))
+	server.TestRequest("POST", "/upload", nil, nil)
+
+	if len(sizes) != 3 || sizes[0] != 300 || sizes[1] != 20 || sizes[2] != 0 {
+		t.Errorf("sizes = %v, want [300 20 0]", sizes)
+	}
+}
@@ -0,0 +1,41 @@
+package feather
+
+// Skip wraps a middleware so that it is not run for the requests matching skipper, e.g. to exclude
+// the health checks from the logs or the authentication.
+//
+// Parameters:
+//   - mw: The middleware to wrap.
+//   - skipper: The function reporting whether the middleware must be skipped for the request.
+//
+// A skipped middleware does not run at all: it neither writes to the response nor registers post functions.
+//
+// Returns:
+//   - The wrapping middleware.
+func Skip(mw HandlerFunc, skipper func(c *Context) bool) HandlerFunc {
+	return func(c *Context) {
+		if skipper(c) {
+			return
+		}
+
+		mw(c)
+	}
+}
+
+// SkipPaths wraps a middleware so that it is not run for the requests to the given paths, see Skip.
+//
+// Parameters:
+//   - mw: The middleware to wrap.
+//   - paths: The exact request paths to skip (e.g. "/healthz", "/metrics").
+//
+// Returns:
+//   - The wrapping middleware.
+func SkipPaths(mw HandlerFunc, paths ...string) HandlerFunc {
+	skipped := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		skipped[path] = true
+	}
+
+	return Skip(mw, func(c *Context) bool {
+		return skipped[c.Request.URL.Path]
+	})
+}
@@ -0,0 +1,505 @@
+package feather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOption configures the routes registered by Static and StaticFS.
+type StaticOption func(config *staticConfig)
+
+// staticConfig holds the options of a static route.
+type staticConfig struct {
+	precompressed bool              // precompressed enables serving the ".br" and ".gz" variants of the files.
+	listing       *DirectoryListing // listing enables the directory index when not nil.
+	fingerprint   *regexp.Regexp    // fingerprint matches the fingerprinted file names, enabling the cache headers when not nil.
+	shortMaxAge   time.Duration     // shortMaxAge is the max-age of the files whose name is not fingerprinted.
+	etags         *etagCache        // etags memoizes the content ETags of the files when not nil, see WithContentETags.
+}
+
+// contentETagCacheSize is the maximum number of files whose ETag is memoized by a route using WithContentETags.
+const contentETagCacheSize = 4096
+
+// etagCache memoizes the content ETags of the files of a static route, keyed by name and invalidated
+// when the modification time or the size of a file changes.
+type etagCache struct {
+	mutex   sync.Mutex
+	entries map[string]etagEntry
+}
+
+// etagEntry is the memoized ETag of a file along with the modification time and size it was computed for.
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// DefaultFingerprintRegex matches the fingerprinted file names (e.g. "app.3f2a9c1e.js"), see WithFingerprintCaching.
+var DefaultFingerprintRegex = regexp.MustCompile(`\.[0-9a-f]{8,}\.`)
+
+// DirectoryListing configures the directory index rendered by a static route, see WithDirectoryListing.
+type DirectoryListing struct {
+	HideDotfiles bool               // HideDotfiles excludes the files and directories whose name starts with a dot.
+	Template     *template.Template // Template overrides the built-in HTML template. It is executed with a DirectoryIndex.
+}
+
+// DirectoryIndex is the content of a directory rendered by a static route with a directory listing.
+// It is the data given to the template of the listing, and the body of the JSON variant.
+type DirectoryIndex struct {
+	Path    string           `json:"path"`    // Path is the URL path of the directory, ending with a slash.
+	Parent  string           `json:"parent"`  // Parent is the URL path of the parent directory, empty at the root of the route.
+	Entries []DirectoryEntry `json:"entries"` // Entries are the files and directories, directories first, then sorted by name.
+}
+
+// DirectoryEntry is a file or directory of a DirectoryIndex.
+type DirectoryEntry struct {
+	Name    string    `json:"name"`     // Name is the name of the entry.
+	URL     string    `json:"url"`      // URL is the URL path of the entry, ending with a slash for directories.
+	IsDir   bool      `json:"is_dir"`   // IsDir is true for directories.
+	Size    int64     `json:"size"`     // Size is the size of the file in bytes.
+	ModTime time.Time `json:"mod_time"` // ModTime is the modification time of the entry.
+}
+
+// directoryTemplate is the built-in HTML template of the directory listings.
+var directoryTemplate = template.Must(template.New("directory").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{ .Path }}</title></head>
+<body>
+<h1>Index of {{ .Path }}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{ if .Parent }}<tr><td><a href="{{ .Parent }}">../</a></td><td></td><td></td></tr>{{ end }}
+{{ range .Entries }}<tr><td><a href="{{ .URL }}">{{ .Name }}{{ if .IsDir }}/{{ end }}</a></td><td>{{ if not .IsDir }}{{ .Size }}{{ end }}</td><td>{{ .ModTime.Format "2006-01-02 15:04:05" }}</td></tr>
+{{ end }}</table>
+</body>
+</html>
+`))
+
+// precompressedEncodings lists the precompressed variants looked up by WithPrecompressed, by order of preference.
+var precompressedEncodings = []struct {
+	encoding  string // encoding is the content coding, as found in Accept-Encoding.
+	extension string // extension is the suffix of the variant file.
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+/*
+	WithPrecompressed makes a static route serve the precompressed variants of its files.
+
+	When "app.js" is requested, "app.js.br" or "app.js.gz" is served instead if it exists next to it and the
+	Accept-Encoding header of the request allows its encoding (Brotli is preferred). The response then has the
+	matching Content-Encoding header and the Content-Type of the original file. The raw file is served when no
+	variant matches. Responses always carry a "Vary: Accept-Encoding" header.
+
+	Returns:
+		- StaticOption: The option to pass to Static or StaticFS.
+*/
+func WithPrecompressed() StaticOption {
+	return func(config *staticConfig) {
+		config.precompressed = true
+	}
+}
+
+/*
+	WithDirectoryListing makes a static route render an index of the requested directories which have no "index.html".
+
+	The index lists the name, size and modification time of the entries, directories first and then sorted by name,
+	with a link to the parent directory. It is rendered as HTML, with the built-in template or listing.Template, or as
+	JSON (a DirectoryIndex) when the client prefers "application/json" in its Accept header.
+	Directory listings are disabled by default.
+
+	Parameters:
+		- listing (DirectoryListing): The configuration of the listing.
+
+	Returns:
+		- StaticOption: The option to pass to Static or StaticFS.
+*/
+func WithDirectoryListing(listing DirectoryListing) StaticOption {
+	return func(config *staticConfig) {
+		config.listing = &listing
+	}
+}
+
+/*
+	WithFingerprintCaching makes a static route emit cache headers depending on whether the requested file name is
+	fingerprinted: "Cache-Control: public, max-age=31536000, immutable" for the fingerprinted files, which can be cached
+	forever since their name changes with their content, and "Cache-Control: public, max-age=<shortMaxAge>" otherwise.
+
+	Parameters:
+		- pattern (*regexp.Regexp): The regular expression matching the fingerprinted file names.
+				If nil, DefaultFingerprintRegex (`\.[0-9a-f]{8,}\.`) is used.
+		- shortMaxAge (time.Duration): The max-age of the files whose name is not fingerprinted.
+
+	Returns:
+		- StaticOption: The option to pass to Static or StaticFS.
+*/
+func WithFingerprintCaching(pattern *regexp.Regexp, shortMaxAge time.Duration) StaticOption {
+	if pattern == nil {
+		pattern = DefaultFingerprintRegex
+	}
+
+	return func(config *staticConfig) {
+		config.fingerprint = pattern
+		config.shortMaxAge = shortMaxAge
+	}
+}
+
+/*
+	WithContentETags makes a static route send strong ETags computed from the content of the files (SHA-256),
+	instead of relying only on their modification time, so that deployments touching the timestamps of unchanged
+	files do not bust the browser caches. Conditional requests with a matching If-None-Match get a 304 response.
+
+	The ETags are computed on the first request of each file and memoized for at most 4096 files per route.
+	A file is hashed again when its modification time or size changes: a touch keeps its ETag, while a change
+	of content gives a new one.
+
+	Returns:
+		- StaticOption: The option to pass to Static or StaticFS.
+*/
+func WithContentETags() StaticOption {
+	return func(config *staticConfig) {
+		config.etags = &etagCache{entries: make(map[string]etagEntry)}
+	}
+}
+
+/*
+	Static serves files from a specified folder when the requested URL matches a given prefix.
+
+	This function registers a route that maps a URL prefix to a folder on the server's filesystem.
+	When a request is made to a URL that matches the prefix, the server attempts to locate the corresponding
+	file in the specified folder and serves it to the client. The route uses the "GET" HTTP method and supports
+	wildcard paths to serve files dynamically. A request for a directory serves its "index.html" file, if any.
+
+	Parameters:
+		- prefix (string): The URL prefix that maps to the folder. For example, if the prefix is "/static",
+			a request to "/static/file.txt" will attempt to serve "file.txt" from the specified folder.
+		- folderPath (string): The path to the folder on the server's filesystem that contains the files to be served.
+		- options (...StaticOption): The options of the route, such as WithPrecompressed().
+
+	The requested path is always cleaned before being looked up in the folder, so that ".." segments (even percent-encoded)
+	cannot reach files outside of the folder.
+
+	Returns:
+		- *RouteBuilder: A builder to configure the registered file-serving route, e.g. to set its default headers.
+*/
+func (server *Server) Static(prefix string, folderPath string, options ...StaticOption) *RouteBuilder {
+	return server.StaticFS(prefix, os.DirFS(folderPath), options...)
+}
+
+/*
+	StaticFS serves files from a filesystem, such as an embed.FS, when the requested URL matches a given prefix.
+
+	It behaves like Static, looking the files up in fsys instead of a folder of the server's filesystem.
+
+	Parameters:
+		- prefix (string): The URL prefix that maps to the root of the filesystem.
+		- fsys (fs.FS): The filesystem that contains the files to be served.
+		- options (...StaticOption): The options of the route, such as WithPrecompressed().
+
+	Returns:
+		- *RouteBuilder: A builder to configure the registered file-serving route, e.g. to set its default headers.
+*/
+func (server *Server) StaticFS(prefix string, fsys fs.FS, options ...StaticOption) *RouteBuilder {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	config := &staticConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	return server.GET(prefix + "/*filepath", func (c *Context) {
+		// Rooting the path before cleaning it resolves every ".." segment, so it can't escape the filesystem
+		name := strings.Trim(cleanPath("/" + c.Params["filepath"]), "/")
+		if name == "" {
+			name = "."
+		}
+
+		serveStatic(c, fsys, name, config)
+	})
+}
+
+// serveStatic serves the file name of fsys, following the options of config.
+func serveStatic(c *Context, fsys fs.FS, name string, config *staticConfig) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		c.Error(http.StatusNotFound, "File not found")
+		return
+	}
+
+	if info.IsDir() {
+		index := path.Join(name, "index.html")
+
+		info, err = fs.Stat(fsys, index)
+		if err != nil || info.IsDir() {
+			if config.listing != nil {
+				serveDirectory(c, fsys, name, config.listing)
+				return
+			}
+
+			c.Error(http.StatusNotFound, "File not found")
+			return
+		}
+
+		name = index
+	}
+
+	if config.fingerprint != nil {
+		if config.fingerprint.MatchString(path.Base(name)) {
+			c.CacheControl(CacheControl{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true})
+		} else {
+			c.CacheControl(CacheControl{Public: true, MaxAge: config.shortMaxAge})
+		}
+	}
+
+	served, encoding := name, ""
+	if config.precompressed {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		if variant, variantEncoding, ok := findPrecompressed(c, fsys, name); ok {
+			served, encoding = variant, variantEncoding
+		}
+	}
+
+	file, err := fsys.Open(served)
+	if err != nil {
+		c.Error(http.StatusNotFound, "File not found")
+		return
+	}
+	defer file.Close()
+
+	// The encoding is only announced once the variant is opened, so that an error response is never marked as encoded
+	if encoding != "" {
+		c.Writer.Header().Set("Content-Encoding", encoding)
+
+		if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+			c.Writer.Header().Set("Content-Type", ctype)
+		}
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		c.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if config.etags != nil {
+		etag, err := config.etags.get(served, stat, content)
+		if err != nil {
+			c.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Writer.Header().Set("ETag", etag)
+	}
+
+	// The name of the original file is given so that the Content-Type is not guessed from the variant
+	http.ServeContent(c.Writer, c.Request, name, stat.ModTime(), content)
+}
+
+// get returns the ETag of the file name, hashing content when it is not memoized for the modification time
+// and size of info. content is rewound to its start after hashing.
+func (cache *etagCache) get(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	cache.mutex.Lock()
+	entry, ok := cache.entries[name]
+	cache.mutex.Unlock()
+
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.etag, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// Evict an arbitrary file once the cache is full, it is hashed again on its next request
+	if _, ok := cache.entries[name]; !ok && len(cache.entries) >= contentETagCacheSize {
+		for key := range cache.entries {
+			delete(cache.entries, key)
+			break
+		}
+	}
+	cache.entries[name] = etagEntry{modTime: info.ModTime(), size: info.Size(), etag: etag}
+
+	return etag, nil
+}
+
+// serveDirectory renders the index of the directory name of fsys, see WithDirectoryListing.
+func serveDirectory(c *Context, fsys fs.FS, name string, listing *DirectoryListing) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		c.Error(http.StatusNotFound, "File not found")
+		return
+	}
+
+	urlPath := c.Request.URL.Path
+	if !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+
+	// The links are built from the escaped path, the names with a space, a "?" or a "#" would break them otherwise
+	escapedPath := (&url.URL{Path: urlPath}).EscapedPath()
+
+	index := DirectoryIndex{
+		Path:    urlPath,
+		Entries: make([]DirectoryEntry, 0, len(entries)),
+	}
+	if name != "." {
+		index.Parent = path.Dir(strings.TrimSuffix(escapedPath, "/")) + "/"
+		if index.Parent == "//" {
+			index.Parent = "/"
+		}
+	}
+
+	for _, entry := range entries {
+		if listing.HideDotfiles && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		item := DirectoryEntry{
+			Name:    entry.Name(),
+			URL:     escapedPath + url.PathEscape(entry.Name()),
+			IsDir:   entry.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if item.IsDir {
+			item.URL += "/"
+		} else {
+			item.Size = info.Size()
+		}
+
+		index.Entries = append(index.Entries, item)
+	}
+
+	sort.Slice(index.Entries, func(i, j int) bool {
+		if index.Entries[i].IsDir != index.Entries[j].IsDir {
+			return index.Entries[i].IsDir
+		}
+		return index.Entries[i].Name < index.Entries[j].Name
+	})
+
+	if prefersJSON(c.Header("Accept")) {
+		c.JSON(http.StatusOK, index)
+		return
+	}
+
+	tmpl := listing.Template
+	if tmpl == nil {
+		tmpl = directoryTemplate
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, index); err != nil {
+		c.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.HTML(http.StatusOK, buffer.String())
+}
+
+// prefersJSON reports whether an Accept header prefers "application/json" over "text/html".
+func prefersJSON(accept string) bool {
+	jsonQuality, htmlQuality := -1.0, -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		switch strings.ToLower(mediaType) {
+		case "application/json":
+			jsonQuality = max(jsonQuality, quality)
+		case "text/html":
+			htmlQuality = max(htmlQuality, quality)
+		}
+	}
+
+	return jsonQuality > 0 && jsonQuality > htmlQuality
+}
+
+// findPrecompressed returns the name of the precompressed variant of name to serve for the request,
+// along with its content coding, if the request accepts one of the existing variants.
+func findPrecompressed(c *Context, fsys fs.FS, name string) (string, string, bool) {
+	accepted := acceptedEncodings(c.Header("Accept-Encoding"))
+
+	for _, candidate := range precompressedEncodings {
+		if !accepted[candidate.encoding] {
+			continue
+		}
+
+		info, err := fs.Stat(fsys, name + candidate.extension)
+		if err == nil && !info.IsDir() {
+			return name + candidate.extension, candidate.encoding, true
+		}
+	}
+
+	return "", "", false
+}
+
+// acceptedEncodings returns the set of the content codings accepted by an Accept-Encoding header,
+// ignoring the ones with a quality of 0.
+func acceptedEncodings(header string) map[string]bool {
+	accepted := make(map[string]bool)
+
+	for _, part := range strings.Split(header, ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if encoding == "" {
+			continue
+		}
+
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if quality, err := strconv.ParseFloat(value, 64); err != nil || quality == 0 {
+				continue
+			}
+		}
+
+		accepted[strings.ToLower(encoding)] = true
+	}
+
+	return accepted
+}
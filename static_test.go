@@ -0,0 +1,425 @@
+package feather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// listingFixture is a tree served by the directory listing tests.
+func listingFixture() fstest.MapFS {
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	return fstest.MapFS{
+		"b.txt":             {Data: []byte("bb"), ModTime: modTime},
+		"a.txt":             {Data: []byte("a"), ModTime: modTime},
+		".secret":           {Data: []byte("hidden"), ModTime: modTime},
+		"zdir/c.txt":        {Data: []byte("ccc"), ModTime: modTime},
+		"adir/nested/d.txt": {Data: []byte("dddd"), ModTime: modTime},
+		"site/index.html":   {Data: []byte("<p>home</p>"), ModTime: modTime},
+		"odd/a b#1.txt":     {Data: []byte("x"), ModTime: modTime},
+		"odd/sub dir/e.txt": {Data: []byte("e"), ModTime: modTime},
+	}
+}
+
+// listDirectory requests url on a static route serving the fixture and decodes the JSON index.
+func listDirectory(t *testing.T, listing DirectoryListing, url string) DirectoryIndex {
+	t.Helper()
+
+	server := NewServer()
+	server.StaticFS("/files", listingFixture(), WithDirectoryListing(listing))
+
+	request := httptest.NewRequest("GET", url, nil)
+	request.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d, want 200", url, recorder.Code)
+	}
+
+	var index DirectoryIndex
+	if err := json.Unmarshal(recorder.Body.Bytes(), &index); err != nil {
+		t.Fatalf("%s: invalid JSON: %v", url, err)
+	}
+
+	return index
+}
+
+func TestDirectoryListingEntries(t *testing.T) {
+	index := listDirectory(t, DirectoryListing{}, "/files/")
+
+	var names []string
+	for _, entry := range index.Entries {
+		names = append(names, entry.Name)
+	}
+
+	// Directories come first, then the files, each sorted by name
+	want := "adir,odd,site,zdir,.secret,a.txt,b.txt"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("entries = %s, want %s", got, want)
+	}
+
+	if index.Path != "/files/" || index.Parent != "" {
+		t.Errorf("path = %q, parent = %q", index.Path, index.Parent)
+	}
+
+	for _, entry := range index.Entries {
+		switch entry.Name {
+		case "adir":
+			if !entry.IsDir || entry.URL != "/files/adir/" || entry.Size != 0 {
+				t.Errorf("adir = %+v", entry)
+			}
+		case "b.txt":
+			if entry.IsDir || entry.URL != "/files/b.txt" || entry.Size != 2 || !entry.ModTime.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("b.txt = %+v", entry)
+			}
+		}
+	}
+}
+
+func TestDirectoryListingParent(t *testing.T) {
+	tests := []struct {
+		url    string
+		parent string
+	}{
+		{"/files/zdir", "/files/"},
+		{"/files/adir/nested/", "/files/adir/"},
+	}
+
+	for _, test := range tests {
+		index := listDirectory(t, DirectoryListing{}, test.url)
+		if index.Parent != test.parent {
+			t.Errorf("%s: parent = %q, want %q", test.url, index.Parent, test.parent)
+		}
+	}
+}
+
+func TestDirectoryListingHidesDotfiles(t *testing.T) {
+	index := listDirectory(t, DirectoryListing{HideDotfiles: true}, "/files/")
+
+	for _, entry := range index.Entries {
+		if strings.HasPrefix(entry.Name, ".") {
+			t.Errorf("the dotfile %q is listed", entry.Name)
+		}
+	}
+}
+
+func TestDirectoryListingEscapesURLs(t *testing.T) {
+	index := listDirectory(t, DirectoryListing{}, "/files/odd/")
+
+	if len(index.Entries) != 2 || index.Entries[0].URL != "/files/odd/sub%20dir/" || index.Entries[1].URL != "/files/odd/a%20b%231.txt" {
+		t.Errorf("entries = %+v", index.Entries)
+	}
+
+	index = listDirectory(t, DirectoryListing{}, "/files/odd/sub%20dir/")
+	if index.Path != "/files/odd/sub dir/" || index.Parent != "/files/odd/" || index.Entries[0].URL != "/files/odd/sub%20dir/e.txt" {
+		t.Errorf("index = %+v", index)
+	}
+}
+
+func TestDirectoryListingHTML(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/files", listingFixture(), WithDirectoryListing(DirectoryListing{}))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/files/zdir/", nil))
+
+	body := recorder.Body.String()
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status = %d, content type = %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, `<a href="/files/zdir/c.txt">c.txt</a>`) || !strings.Contains(body, `<a href="/files/">../</a>`) {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestDirectoryListingTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("custom").Parse(`{{ range .Entries }}{{ .Name }};{{ end }}`))
+
+	server := NewServer()
+	server.StaticFS("/files", listingFixture(), WithDirectoryListing(DirectoryListing{Template: tmpl, HideDotfiles: true}))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/files/", nil))
+
+	if body := recorder.Body.String(); body != "adir;odd;site;zdir;a.txt;b.txt;" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestDirectoryListingServesIndex(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/files", listingFixture(), WithDirectoryListing(DirectoryListing{}))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/files/site/", nil))
+
+	if body := recorder.Body.String(); body != "<p>home</p>" {
+		t.Errorf("body = %q, want the index.html", body)
+	}
+}
+
+func TestDirectoryListingDisabledByDefault(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/files", listingFixture())
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("GET", "/files/", nil))
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", recorder.Code)
+	}
+}
+
+func TestDirectoryListingTraversal(t *testing.T) {
+	sub, err := fs.Sub(listingFixture(), "zdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	server.StaticFS("/files", sub, WithDirectoryListing(DirectoryListing{}))
+
+	for _, url := range []string{"/files/../", "/files/%2e%2e/", "/files/..%2f", "/files/zdir/../../"} {
+		request := httptest.NewRequest("GET", url, nil)
+		request.Header.Set("Accept", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+
+		for _, name := range []string{"a.txt", "adir", ".secret"} {
+			if strings.Contains(recorder.Body.String(), `"name":"`+name+`"`) {
+				t.Errorf("%s: the listing escapes the root: %s", url, recorder.Body.String())
+			}
+		}
+	}
+}
+
+// contentETagServer serves the folder dir with content ETags.
+func contentETagServer(dir string) *Server {
+	server := NewServer()
+	server.Static("/static", dir, WithContentETags())
+	return server
+}
+
+// etagOf requests url and returns its status and ETag, sending ifNoneMatch when not empty.
+func etagOf(server *Server, url string, ifNoneMatch string) (int, string) {
+	request := httptest.NewRequest("GET", url, nil)
+	if ifNoneMatch != "" {
+		request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	return recorder.Code, recorder.Header().Get("ETag")
+}
+
+func TestContentETags(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.js")
+	if err := os.WriteFile(file, []byte("console.log(1)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := contentETagServer(dir)
+
+	status, etag := etagOf(server, "/static/app.js", "")
+	sum := sha256.Sum256([]byte("console.log(1)"))
+	if status != http.StatusOK || etag != `"`+hex.EncodeToString(sum[:])+`"` {
+		t.Fatalf("got %d with ETag %q, want the SHA-256 of the content", status, etag)
+	}
+
+	if status, _ := etagOf(server, "/static/app.js", etag); status != http.StatusNotModified {
+		t.Errorf("status with a matching If-None-Match = %d, want 304", status)
+	}
+
+	// A touch keeps the ETag, so the browsers keep their cached copy
+	touched := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, touched, touched); err != nil {
+		t.Fatal(err)
+	}
+	if status, got := etagOf(server, "/static/app.js", etag); status != http.StatusNotModified || got != etag {
+		t.Errorf("after a touch: got %d with ETag %q, want 304 with %q", status, got, etag)
+	}
+
+	// A change of content gives a new ETag, even with the same size and modification time
+	if err := os.WriteFile(file, []byte("console.log(2)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed := touched.Add(time.Hour)
+	if err := os.Chtimes(file, changed, changed); err != nil {
+		t.Fatal(err)
+	}
+	status, newETag := etagOf(server, "/static/app.js", etag)
+	if status != http.StatusOK || newETag == etag || newETag == "" {
+		t.Errorf("after a change: got %d with ETag %q, want 200 with a new ETag", status, newETag)
+	}
+}
+
+func TestContentETagsMemoized(t *testing.T) {
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{"app.js": {Data: []byte("aaaa"), ModTime: modTime}}
+
+	server := NewServer()
+	server.StaticFS("/static", fsys, WithContentETags())
+
+	_, first := etagOf(server, "/static/app.js", "")
+
+	// The content changes without its modification time nor its size: the memoized ETag is kept
+	fsys["app.js"].Data = []byte("bbbb")
+	if _, got := etagOf(server, "/static/app.js", ""); got != first {
+		t.Errorf("ETag = %q, want the memoized %q", got, first)
+	}
+
+	// A new modification time invalidates it
+	fsys["app.js"].ModTime = modTime.Add(time.Second)
+	if _, got := etagOf(server, "/static/app.js", ""); got == first {
+		t.Error("the memoized ETag has not been invalidated by the new modification time")
+	}
+}
+
+func TestContentETagsBounded(t *testing.T) {
+	cache := &etagCache{entries: make(map[string]etagEntry)}
+	fsys := fstest.MapFS{}
+	for i := range contentETagCacheSize + 10 {
+		fsys[fmt.Sprintf("file%d.txt", i)] = &fstest.MapFile{Data: []byte("x")}
+	}
+
+	for name := range fsys {
+		info, _ := fs.Stat(fsys, name)
+		if _, err := cache.get(name, info, bytes.NewReader(fsys[name].Data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := len(cache.entries); n != contentETagCacheSize {
+		t.Errorf("the cache holds %d entries, want %d", n, contentETagCacheSize)
+	}
+}
+
+func TestContentETagsDisabledByDefault(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/static", fstest.MapFS{"app.js": {Data: []byte("x")}})
+
+	if _, etag := etagOf(server, "/static/app.js", ""); etag != "" {
+		t.Errorf("ETag = %q without WithContentETags", etag)
+	}
+}
+
+// precompressedFixture holds app.js with its Brotli and gzip variants, and style.css with its gzip variant only.
+func precompressedFixture() fstest.MapFS {
+	return fstest.MapFS{
+		"app.js":       {Data: []byte("raw js")},
+		"app.js.br":    {Data: []byte("br js")},
+		"app.js.gz":    {Data: []byte("gzip js")},
+		"style.css":    {Data: []byte("raw css")},
+		"style.css.gz": {Data: []byte("gzip css")},
+		"logo.png":     {Data: []byte("raw png")},
+		"orphan.js.br": {Data: []byte("br orphan")},
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		status         int
+		body           string
+		encoding       string
+		contentType    string
+	}{
+		{"brotli preferred", "/static/app.js", "gzip, deflate, br", http.StatusOK, "br js", "br", "text/javascript; charset=utf-8"},
+		{"gzip only", "/static/app.js", "gzip", http.StatusOK, "gzip js", "gzip", "text/javascript; charset=utf-8"},
+		{"brotli refused", "/static/app.js", "br;q=0, gzip", http.StatusOK, "gzip js", "gzip", "text/javascript; charset=utf-8"},
+		{"no Accept-Encoding", "/static/app.js", "", http.StatusOK, "raw js", "", "text/javascript; charset=utf-8"},
+		{"unsupported encoding", "/static/app.js", "deflate", http.StatusOK, "raw js", "", "text/javascript; charset=utf-8"},
+		{"missing brotli variant", "/static/style.css", "br, gzip", http.StatusOK, "gzip css", "gzip", "text/css; charset=utf-8"},
+		{"missing variants", "/static/logo.png", "br, gzip", http.StatusOK, "raw png", "", "image/png"},
+		{"variant without original", "/static/orphan.js", "br", http.StatusNotFound, "", "", ""},
+	}
+
+	server := NewServer()
+	server.StaticFS("/static", precompressedFixture(), WithPrecompressed())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if test.acceptEncoding != "" {
+				headers["Accept-Encoding"] = test.acceptEncoding
+			}
+			recorder := server.TestRequest("GET", test.path, nil, headers)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != test.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, test.encoding)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+			if recorder.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", recorder.Header().Get("Vary"))
+			}
+			if recorder.Body.String() != test.body {
+				t.Errorf("body = %q, want %q", recorder.Body.String(), test.body)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, test.contentType)
+			}
+		})
+	}
+}
+
+func TestPrecompressedDisabledByDefault(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/static", precompressedFixture())
+
+	recorder := server.TestRequest("GET", "/static/app.js", nil, map[string]string{"Accept-Encoding": "br, gzip"})
+
+	if recorder.Body.String() != "raw js" || recorder.Header().Get("Content-Encoding") != "" {
+		t.Errorf("got %q with Content-Encoding %q, want the raw file", recorder.Body.String(), recorder.Header().Get("Content-Encoding"))
+	}
+}
+
+// unopenableVariantsFS is a filesystem whose Brotli variants can be found with Stat but not opened,
+// as when a variant is removed between the lookup and the serving.
+type unopenableVariantsFS struct {
+	fstest.MapFS
+}
+
+func (fsys unopenableVariantsFS) Open(name string) (fs.File, error) {
+	if strings.HasSuffix(name, ".br") {
+		return nil, fs.ErrNotExist
+	}
+
+	return fsys.MapFS.Open(name)
+}
+
+func TestPrecompressedErrorIsNotEncoded(t *testing.T) {
+	server := NewServer()
+	server.StaticFS("/static", unopenableVariantsFS{precompressedFixture()}, WithPrecompressed())
+
+	recorder := server.TestRequest("GET", "/static/app.js", nil, map[string]string{"Accept-Encoding": "br"})
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", recorder.Code)
+	}
+	if encoding := recorder.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("the 404 response has Content-Encoding %q", encoding)
+	}
+}
@@ -0,0 +1,112 @@
+package feather
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// prepareStream sets the headers of a streamed response and sends the status code.
+// The Content-Type is only set when the handler has not set one already.
+func (c *Context) prepareStream(contentType string) {
+	header := c.Writer.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", contentType)
+	}
+	if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", "no-cache")
+	}
+	header.Set("X-Accel-Buffering", "no") // Disable the buffering of reverse proxies such as Nginx
+
+	c.Writer.WriteHeader(http.StatusOK)
+}
+
+// flush sends the buffered data of the response to the client, if the response writer supports it.
+// Writers which cannot flush are ignored: the data is then sent when their buffer is full or the response ends.
+func (c *Context) flush() {
+	http.NewResponseController(c.Writer).Flush()
+}
+
+// StreamWriter streams a response by calling fn repeatedly, flushing the data to the
+// client between the calls.
+//
+// Parameters:
+//   - fn: The function writing the next chunk of the response to w. It returns false
+//     once the response is complete.
+//
+// The streaming stops when fn returns false or when the request's context is cancelled
+// (the client disconnected). The "Content-Type" header defaults to "text/plain; charset=utf-8"
+// and "Cache-Control" to "no-cache" when the handler has not set them. When the response
+// writer does not support flushing, the data is still written but delivered as buffered.
+// This function does not return any value.
+func (c *Context) StreamWriter(fn func(w io.Writer) bool) {
+	c.prepareStream("text/plain; charset=utf-8")
+	c.flush()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		default:
+		}
+
+		if !fn(c.Writer) {
+			c.flush()
+			return
+		}
+
+		c.flush()
+	}
+}
+
+// StreamJSONArray streams the values received from ch as a well-formed JSON array, so that
+// large collections (e.g. millions of database rows) can be sent without being buffered.
+//
+// Parameters:
+//   - ch: The channel of the values of the array. The array is closed when ch is closed.
+//
+// Each value is encoded and flushed to the client as soon as it is received. The streaming
+// stops when the request's context is cancelled, leaving the array unterminated since the
+// client is gone. The "Content-Type" header defaults to "application/json".
+//
+// Returns:
+//   - An error if a value cannot be encoded or written; the array is then left unterminated
+//     so that the client detects the truncated response.
+func (c *Context) StreamJSONArray(ch <-chan any) error {
+	c.prepareStream("application/json")
+
+	if _, err := io.WriteString(c.Writer, "["); err != nil {
+		return err
+	}
+
+	first := true
+	for {
+		select {
+		case <-c.Done():
+			return c.Err()
+		case value, ok := <-ch:
+			if !ok {
+				_, err := io.WriteString(c.Writer, "]")
+				c.flush()
+				return err
+			}
+
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+
+			if !first {
+				if _, err := io.WriteString(c.Writer, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+
+			if _, err := c.Writer.Write(encoded); err != nil {
+				return err
+			}
+			c.flush()
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package feather
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeRecorder is a response writer which buffers the written data and only hands it to the
+// reading end of a pipe when flushed, so tests can observe what reaches the client and when.
+type pipeRecorder struct {
+	header http.Header
+	status int
+	buffer bytes.Buffer
+	pipe   *io.PipeWriter
+}
+
+func newPipeRecorder() (*pipeRecorder, *bufio.Reader) {
+	reader, writer := io.Pipe()
+	return &pipeRecorder{header: http.Header{}, pipe: writer}, bufio.NewReader(reader)
+}
+
+func (recorder *pipeRecorder) Header() http.Header { return recorder.header }
+
+func (recorder *pipeRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+}
+
+func (recorder *pipeRecorder) Write(data []byte) (int, error) {
+	recorder.WriteHeader(http.StatusOK)
+	return recorder.buffer.Write(data)
+}
+
+func (recorder *pipeRecorder) Flush() {
+	if recorder.buffer.Len() > 0 {
+		recorder.pipe.Write(recorder.buffer.Bytes())
+		recorder.buffer.Reset()
+	}
+}
+
+// close delivers the remaining data, as the end of the response would, and closes the pipe.
+func (recorder *pipeRecorder) close() {
+	recorder.Flush()
+	recorder.pipe.Close()
+}
+
+// serveStream serves a request to handler on a pipeRecorder in the background.
+func serveStream(ctx context.Context, handler HandlerFunc) (*pipeRecorder, *bufio.Reader, <-chan struct{}) {
+	server := NewServer()
+	server.GET("/stream", handler)
+
+	recorder, reader := newPipeRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer recorder.close()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", "/stream", nil).WithContext(ctx))
+	}()
+
+	return recorder, reader, done
+}
+
+// readWithTimeout reads n bytes from reader, failing the test if they don't arrive in time.
+func readWithTimeout(t *testing.T, reader *bufio.Reader, n int) string {
+	t.Helper()
+
+	result := make(chan string, 1)
+	go func() {
+		data := make([]byte, n)
+		read, _ := io.ReadFull(reader, data)
+		result <- string(data[:read])
+	}()
+
+	select {
+	case data := <-result:
+		return data
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%d bytes have not been delivered", n)
+		return ""
+	}
+}
+
+func TestStreamWriterDeliversIncrementally(t *testing.T) {
+	next := make(chan string)
+
+	recorder, reader, done := serveStream(context.Background(), func(c *Context) {
+		c.StreamWriter(func(w io.Writer) bool {
+			chunk, ok := <-next
+			if !ok {
+				return false
+			}
+			io.WriteString(w, chunk)
+			return true
+		})
+	})
+
+	// Each chunk reaches the client before the next one is produced
+	for i := range 3 {
+		chunk := fmt.Sprintf("chunk %d;", i)
+		next <- chunk
+		if got := readWithTimeout(t, reader, len(chunk)); got != chunk {
+			t.Fatalf("read %q, want %q", got, chunk)
+		}
+	}
+	close(next)
+	<-done
+
+	if recorder.status != http.StatusOK {
+		t.Errorf("status = %d", recorder.status)
+	}
+	if got := recorder.header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := recorder.header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+}
+
+func TestStreamWriterKeepsHandlerHeaders(t *testing.T) {
+	server := NewServer()
+	server.GET("/stream", func(c *Context) {
+		c.SetHeader("Content-Type", "text/csv")
+		c.StreamWriter(func(w io.Writer) bool {
+			io.WriteString(w, "a,b\n")
+			return false
+		})
+	})
+
+	recorder := server.TestRequest("GET", "/stream", nil, nil)
+	if got := recorder.Header().Get("Content-Type"); got != "text/csv" || recorder.Body.String() != "a,b\n" {
+		t.Errorf("got %q with %q", recorder.Body.String(), got)
+	}
+}
+
+func TestStreamWriterStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, reader, done := serveStream(ctx, func(c *Context) {
+		c.StreamWriter(func(w io.Writer) bool {
+			io.WriteString(w, "x")
+			return true
+		})
+	})
+
+	readWithTimeout(t, reader, 1)
+	cancel()
+	go io.Copy(io.Discard, reader)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the stream is still running after the cancellation")
+	}
+}
+
+// plainWriter is a response writer which cannot flush.
+type plainWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (writer *plainWriter) Header() http.Header            { return writer.header }
+func (writer *plainWriter) WriteHeader(int)                {}
+func (writer *plainWriter) Write(data []byte) (int, error) { return writer.body.Write(data) }
+
+func TestStreamWithoutFlusher(t *testing.T) {
+	server := NewServer()
+	server.GET("/stream", func(c *Context) {
+		remaining := 3
+		c.StreamWriter(func(w io.Writer) bool {
+			io.WriteString(w, "x")
+			remaining--
+			return remaining > 0
+		})
+	})
+	server.GET("/array", func(c *Context) {
+		ch := make(chan any, 2)
+		ch <- 1
+		ch <- 2
+		close(ch)
+		c.StreamJSONArray(ch)
+	})
+
+	for url, want := range map[string]string{"/stream": "xxx", "/array": "[1,2]"} {
+		writer := &plainWriter{header: http.Header{}}
+		server.ServeHTTP(writer, httptest.NewRequest("GET", url, nil))
+
+		if got := writer.body.String(); got != want {
+			t.Errorf("%s: body = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestStreamJSONArrayFraming(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []any
+		want   string
+	}{
+		{"empty", nil, "[]"},
+		{"single", []any{1}, "[1]"},
+		{"several", []any{map[string]int{"id": 1}, "two", nil, []int{3}}, `[{"id":1},"two",null,[3]]`},
+	}
+
+	for _, test := range tests {
+		server := NewServer()
+		server.GET("/array", func(c *Context) {
+			ch := make(chan any)
+			go func() {
+				defer close(ch)
+				for _, value := range test.values {
+					ch <- value
+				}
+			}()
+			if err := c.StreamJSONArray(ch); err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			}
+		})
+
+		recorder := server.TestRequest("GET", "/array", nil, nil)
+		if body := recorder.Body.String(); body != test.want {
+			t.Errorf("%s: body = %s, want %s", test.name, body, test.want)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: Content-Type = %q", test.name, got)
+		}
+	}
+}
+
+func TestStreamJSONArrayDeliversIncrementally(t *testing.T) {
+	ch := make(chan any)
+
+	_, reader, done := serveStream(context.Background(), func(c *Context) {
+		c.StreamJSONArray(ch)
+	})
+
+	ch <- "first"
+	if got := readWithTimeout(t, reader, len(`["first"`)); got != `["first"` {
+		t.Fatalf("read %q", got)
+	}
+	ch <- 2
+	if got := readWithTimeout(t, reader, len(`,2`)); got != `,2` {
+		t.Fatalf("read %q", got)
+	}
+	close(ch)
+	if got := readWithTimeout(t, reader, 1); got != "]" {
+		t.Fatalf("read %q", got)
+	}
+	<-done
+}
+
+func TestStreamJSONArrayStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan any)
+	result := make(chan error, 1)
+
+	_, reader, done := serveStream(ctx, func(c *Context) {
+		result <- c.StreamJSONArray(ch)
+	})
+
+	ch <- 1
+	readWithTimeout(t, reader, len("[1"))
+	cancel()
+
+	rest, _ := io.ReadAll(reader)
+	<-done
+
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	// The array is left unterminated, so the client detects the truncated response
+	if strings.Contains(string(rest), "]") {
+		t.Errorf("the array has been terminated: %q", rest)
+	}
+}
+
+func TestStreamJSONArrayEncodingError(t *testing.T) {
+	server := NewServer()
+	server.GET("/array", func(c *Context) {
+		ch := make(chan any, 2)
+		ch <- 1
+		ch <- func() {}
+		close(ch)
+		if err := c.StreamJSONArray(ch); err == nil {
+			t.Error("no error for a value which cannot be encoded")
+		}
+	})
+
+	recorder := server.TestRequest("GET", "/array", nil, nil)
+	if body := recorder.Body.String(); body != "[1" {
+		t.Errorf("body = %q, want the unterminated array", body)
+	}
+}
@@ -0,0 +1,59 @@
+package feather
+
+import (
+	"html/template"
+	"strings"
+	"sync"
+)
+
+// templateCache stores the parsed templates used by Context.Template, keyed by the list of files they were parsed from.
+// It is safe for concurrent use, as the same template can be rendered by many requests at the same time.
+type templateCache struct {
+	mutex     sync.RWMutex                  // mutex protects the templates map.
+	templates map[string]*template.Template // templates maps a cache key (see templateKey) to its parsed template.
+}
+
+// newTemplateCache creates an empty template cache.
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// templateKey builds the cache key of a template from the list of files it is parsed from.
+func templateKey(files []string) string {
+	return strings.Join(files, "\x00")
+}
+
+// get returns the template stored under key, and whether it was found.
+func (cache *templateCache) get(key string) (*template.Template, bool) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	tmpl, ok := cache.templates[key]
+	return tmpl, ok
+}
+
+// set stores tmpl under key, replacing any previous template.
+func (cache *templateCache) set(key string, tmpl *template.Template) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.templates[key] = tmpl
+}
+
+// invalidate removes the template stored under key, forcing it to be parsed again on its next use.
+func (cache *templateCache) invalidate(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.templates, key)
+}
+
+// clear removes every template from the cache.
+func (cache *templateCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.templates = make(map[string]*template.Template)
+}
@@ -0,0 +1,88 @@
+package feather
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"sync"
+)
+
+/*
+	TestRequest sends a request through the server without opening a connection, for handler tests.
+
+	It builds the request with httptest.NewRequest, so the request comes from "192.0.2.1:1234" and targets
+	"example.com" unless path is an absolute URL.
+
+	Parameters:
+		- method (string): The HTTP method of the request (e.g. "GET").
+		- path (string): The target of the request, e.g. "/user/42?fields=name".
+		- body (io.Reader): The body of the request, nil for no body.
+		- headers (map[string]string): The headers of the request, nil for none.
+
+	Returns:
+		- *httptest.ResponseRecorder: The recorder holding the response written by the server.
+*/
+func (server *Server) TestRequest(method string, path string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, body)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+/*
+	TestRequestJSON sends a request with a JSON body through the server, see TestRequest.
+
+	Parameters:
+		- method (string): The HTTP method of the request (e.g. "POST").
+		- path (string): The target of the request.
+		- obj (any): The value encoded as the JSON body of the request. The "Content-Type" header is set to "application/json".
+
+	Returns:
+		- *httptest.ResponseRecorder: The recorder holding the response written by the server.
+				It panics if obj cannot be encoded, which is a mistake of the test.
+*/
+func (server *Server) TestRequestJSON(method string, path string, obj any) *httptest.ResponseRecorder {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		panic(fmt.Sprintf("feather: TestRequestJSON cannot encode the body: %v", err))
+	}
+
+	return server.TestRequest(method, path, bytes.NewReader(body), map[string]string{"Content-Type": "application/json"})
+}
+
+/*
+	NewTestContext creates a Context backed by a recorder, to unit-test a handler or a middleware without a server.
+
+	The Params and Data maps are initialized, so the path parameters can be set directly
+	(ctx.Params["id"] = "42"). Abort and Post work as on a routed request, but the post functions
+	are not run automatically. The Context is not attached to a server: the server configuration
+	(development mode, error handler, ...) uses its defaults.
+
+	Parameters:
+		- method (string): The HTTP method of the request (e.g. "GET").
+		- path (string): The target of the request, e.g. "/user/42".
+		- body (io.Reader): The body of the request, nil for no body.
+
+	Returns:
+		- *Context: The Context of the request.
+		- *httptest.ResponseRecorder: The recorder holding the response written through the Context.
+*/
+func NewTestContext(method string, path string, body io.Reader) (*Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+
+	return &Context{
+		Writer:  recorder,
+		Request: httptest.NewRequest(method, path, body),
+		Params:  make(map[string]string),
+		Data:    make(map[string]any),
+
+		dataMutex: new(sync.RWMutex),
+	}, recorder
+}
@@ -0,0 +1,56 @@
+package feather
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTestRequest(t *testing.T) {
+	server := NewServer()
+	server.POST("/echo/:id", func(c *Context) {
+		var body strings.Builder
+		body.WriteString(c.Params["id"] + " " + c.Header("X-Token") + " ")
+		payload := make([]byte, 16)
+		n, _ := c.Request.Body.Read(payload)
+		body.Write(payload[:n])
+
+		c.String(http.StatusOK, body.String())
+	})
+
+	recorder := server.TestRequest("POST", "/echo/42", strings.NewReader("hello"), map[string]string{"X-Token": "secret"})
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "42 secret hello" {
+		t.Errorf("got %d %q, want 200 %q", recorder.Code, recorder.Body.String(), "42 secret hello")
+	}
+}
+
+func TestTestRequestJSON(t *testing.T) {
+	server := NewServer()
+	server.POST("/users", func(c *Context) {
+		var user struct {
+			Name string `json:"name"`
+		}
+		if err := c.JSONBody(&user); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		c.String(http.StatusCreated, c.Header("Content-Type")+" "+user.Name)
+	})
+
+	recorder := server.TestRequestJSON("POST", "/users", map[string]string{"name": "Ana"})
+	if recorder.Code != http.StatusCreated || recorder.Body.String() != "application/json Ana" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestNewTestContext(t *testing.T) {
+	c, recorder := NewTestContext("GET", "/user/42", nil)
+	c.Params["id"] = "42"
+	c.Set("role", "admin")
+
+	c.String(http.StatusOK, c.Params["id"]+" "+c.GetString("role"))
+	if recorder.Body.String() != "42 admin" {
+		t.Errorf("got %q", recorder.Body.String())
+	}
+}